@@ -1,13 +1,70 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
 	"ubl-converter/internal/api/routes"
+	"ubl-converter/internal/core/services"
+	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/exchange"
+	"ubl-converter/internal/pkg/logging"
+	"ubl-converter/internal/pkg/metrics"
+	"ubl-converter/internal/pkg/soap"
+	"ubl-converter/internal/pkg/tracing"
 )
 
 func main() {
-	// Por defecto iniciamos en modo beta (isProd = false)
-	r := routes.SetupRouter(false)
+	logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT"))
+
+	// Trazas de OpenTelemetry (OTEL_TRACES_EXPORTER=otlp o stdout)
+	shutdownTracing, err := tracing.Setup(context.Background(), config.GetTracingConfig())
+	if err != nil {
+		log.Fatal("Error configurando las trazas:", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// Archivo en disco de los intercambios SOAP con SUNAT, para auditoría
+	if dir := os.Getenv("SUNAT_ARCHIVO_DIR"); dir != "" {
+		sunat.SetExchangeArchive(soap.NewArchiveRecorder(dir))
+	}
+
+	// Tabla de tipos de cambio de la SBS para comprobantes en moneda extranjera
+	if path := os.Getenv("SBS_TIPO_CAMBIO_FILE"); path != "" {
+		provider, err := exchange.NewSBSFileProvider(path)
+		if err != nil {
+			log.Fatal("Error cargando tipos de cambio:", err)
+		}
+		services.SetRateProvider(provider)
+	}
+
+	// Por defecto iniciamos en modo beta; la API y la consulta de tickets usan
+	// siempre el mismo entorno de SUNAT
+	isProd := config.GetSUNATProduccion()
+
+	// Consulta en segundo plano de tickets (resúmenes, bajas y guías de remisión)
+	scheduler, err := services.NewTicketScheduler(sunat.NewService(isProd), config.GetTicketPollingConfig())
+	if err != nil {
+		log.Fatal("Error cargando los tickets pendientes:", err)
+	}
+	scheduler.Start()
+	defer scheduler.Stop()
+	services.SetTicketScheduler(scheduler)
+
+	// Métricas de Prometheus en un listener interno, separado de la API pública
+	if addr := config.GetMetricsConfig().Addr; addr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Fatal("Error iniciando el servidor de métricas:", err)
+			}
+		}()
+	}
+
+	r := routes.SetupRouter(isProd)
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal("Error iniciando el servidor:", err)
 	}
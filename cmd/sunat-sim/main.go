@@ -0,0 +1,58 @@
+// Comando sunat-sim inicia un simulador local de los servicios SOAP de SUNAT
+// (billService, billConsultService y otros CPE). Para usarlo desde la API:
+//
+//	SUNAT_BILL_SERVICE_URL=http://localhost:8090/billService
+//	SUNAT_CONSULT_SERVICE_URL=http://localhost:8090/billConsultService
+//	SUNAT_OTROS_CPE_SERVICE_URL=http://localhost:8090/otrosCPE
+//
+// Las excepciones se inyectan al azar con -falla y -tasa, o durante las
+// pruebas con POST /sim/fallas {"codigo": "1033", "veces": 1}.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/signature"
+	"ubl-converter/internal/pkg/sunatfake"
+)
+
+func main() {
+	sol := config.GetSUNATCredentials()
+
+	addr := flag.String("addr", ":8090", "dirección en la que escucha el simulador")
+	usuario := flag.String("usuario", sol.Username, "usuario SOL aceptado (el username es el RUC seguido del usuario)")
+	clave := flag.String("clave", sol.Password, "clave SOL aceptada")
+	latencia := flag.Duration("latencia", 0, "demora agregada a cada respuesta, por ejemplo 500ms")
+	falla := flag.String("falla", "", "código de excepción inyectado al azar, por ejemplo 0130")
+	tasa := flag.Float64("tasa", 0, "proporción de solicitudes que fallan con -falla (0 a 1)")
+	enProceso := flag.Int("en-proceso", 1, "consultas de un ticket que responden \"en proceso\"")
+	certPath := flag.String("cert", "certificados/C23022479065.pem", "PEM con el certificado y la clave que firman los CDR")
+	flag.Parse()
+
+	cert, key, err := signature.LoadKeyPairFromPEM(*certPath)
+	if err != nil {
+		log.Fatal("Error cargando el certificado de los CDR:", err)
+	}
+
+	sim := sunatfake.NewBillServer(sunatfake.BillConfig{
+		Usuario:         *usuario,
+		Clave:           *clave,
+		Latencia:        *latencia,
+		FallaCodigo:     *falla,
+		FallaTasa:       *tasa,
+		TicketEnProceso: *enProceso,
+		Certificado: &signature.CertificateInfo{
+			CertPath:    *certPath,
+			Certificate: cert,
+			PrivateKey:  key,
+		},
+	})
+
+	log.Printf("Simulador de SUNAT escuchando en %s", *addr)
+	if err := http.ListenAndServe(*addr, sim); err != nil {
+		log.Fatal("Error iniciando el simulador:", err)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"ubl-converter/internal/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// autorizarRUC verifica que el cliente pueda operar con el RUC emisor y, si
+// no puede, responde 403. Un RUC vacío solo lo autorizan los clientes con
+// acceso a todos los emisores.
+func autorizarRUC(c *gin.Context, ruc string) bool {
+	principal := auth.FromContext(c.Request.Context())
+	if ruc == "" {
+		ruc = auth.TodosLosRUC
+	}
+	if principal.AutorizaRUC(ruc) {
+		return true
+	}
+	mensaje := "el cliente no está autorizado para el RUC " + ruc
+	if ruc == auth.TodosLosRUC {
+		mensaje = "el cliente no está autorizado para consultar documentos sin RUC emisor"
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": mensaje})
+	return false
+}
+
+// autorizarPermiso verifica que el cliente tenga el permiso y, si no lo
+// tiene, responde 403
+func autorizarPermiso(c *gin.Context, permiso string) bool {
+	if auth.FromContext(c.Request.Context()).Permite(permiso) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "el cliente no tiene el permiso " + permiso})
+	return false
+}
+
+// rucDocumento retorna el RUC de un identificador RUC-TIPO-SERIE-NUMERO
+func rucDocumento(documentID string) string {
+	ruc, _, found := strings.Cut(documentID, "-")
+	if !found {
+		return ""
+	}
+	return ruc
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
 	"ubl-converter/internal/pkg/pdfutil"
 
 	"github.com/gin-gonic/gin"
@@ -30,16 +32,25 @@ func (h *ConvertHandler) ConvertirAUBL(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !autorizarRUC(c, request.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(request.Detalle)) || !limitarEmisor(c, request.Emisor.RUC) {
+		return
+	}
 
 	// Convertir a XML
-	xmlContent, err := services.ConvertirAUBL(&request)
+	inicio := time.Now()
+	xmlContent, err := services.ConvertirAUBL(c.Request.Context(), &request)
+	metrics.ObservarConversion(request.Comprobante.TipoComprobante, inicio, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Preparar y validar el documento
-	invoiceID := request.Comprobante.Serie + "-" + request.Comprobante.Numero
+	// Preparar y validar el documento; el identificador incluye el RUC para
+	// que los archivos de distintos emisores no se sobrescriban
+	invoiceID := request.Emisor.RUC + "-" + request.Comprobante.TipoComprobante + "-" + request.Comprobante.Serie + "-" + request.Comprobante.Numero
 	result, err := h.sunatService.PrepareAndValidate(xmlContent, invoiceID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -53,11 +64,13 @@ func (h *ConvertHandler) ConvertirAUBL(c *gin.Context) {
 		return
 	}
 	pdfPath := pdfutil.BuildPDFPath(invoiceID)
-	if err := pdfutil.GenerateInvoicePDF(xmlPath, pdfPath); err != nil {
+	if err := pdfutil.GenerateInvoicePDF(c.Request.Context(), xmlPath, pdfPath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	result["pdf"] = pdfPath
+	result["pdf_url"] = downloadURL(invoiceID, FormatoPDF)
+	result["document_id"] = invoiceID
 
 	// Devolver el resultado
 	c.JSON(http.StatusOK, result)
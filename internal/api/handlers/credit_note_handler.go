@@ -6,8 +6,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,9 +33,17 @@ func (h *CreditNoteHandler) Handle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !autorizarRUC(c, req.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(req.Detalle)) || !limitarEmisor(c, req.Emisor.RUC) {
+		return
+	}
 
 	// Convertir a UBL y firmar
-	xmlContent, err := services.ConvertToUBLCreditNote(&req)
+	inicio := time.Now()
+	xmlContent, err := services.ConvertToUBLCreditNote(c.Request.Context(), &req)
+	metrics.ObservarConversion("07", inicio, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -54,11 +64,11 @@ func (h *CreditNoteHandler) Handle(c *gin.Context) {
 	}
 
 	// Enviar a SUNAT
-	ticket, err := h.sunatService.SendCreditNote(xmlPath)
+	resultado, err := h.sunatService.SendCreditNote(c.Request.Context(), xmlPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondEnvioError(c, invoiceID, err)
 		return
 	}
 
-			c.JSON(http.StatusOK, gin.H{"ticket": ticket, "document_id": invoiceID})
+	c.JSON(http.StatusOK, envioResponse(invoiceID, resultado))
 }
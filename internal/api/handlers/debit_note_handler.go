@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,9 +29,17 @@ func (h *DebitNoteHandler) Handle(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !autorizarRUC(c, req.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(req.Detalle)) || !limitarEmisor(c, req.Emisor.RUC) {
+		return
+	}
 
 	// Convertir a UBL y firmar
-	xmlContent, err := services.ConvertToUBLDebitNote(&req)
+	inicio := time.Now()
+	xmlContent, err := services.ConvertToUBLDebitNote(c.Request.Context(), &req)
+	metrics.ObservarConversion(req.Comprobante.TipoComprobante, inicio, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -50,11 +60,11 @@ func (h *DebitNoteHandler) Handle(c *gin.Context) {
 		return
 	}
 
-	ticket, err := h.sunatService.SendDebitNote(xmlPath)
+	resultado, err := h.sunatService.SendDebitNote(c.Request.Context(), xmlPath)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondEnvioError(c, invoiceID, err)
 		return
 	}
 
-			c.JSON(http.StatusOK, gin.H{"ticket": ticket, "document_id": invoiceID})
+	c.JSON(http.StatusOK, envioResponse(invoiceID, resultado))
 }
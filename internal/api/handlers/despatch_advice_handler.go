@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"ubl-converter/internal/core/services"
+	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DespatchAdviceHandler estructura para el manejador de guías de remisión
+type DespatchAdviceHandler struct {
+	sunatService sunat.Service
+}
+
+// NewDespatchAdviceHandler crea una nueva instancia de DespatchAdviceHandler
+func NewDespatchAdviceHandler(isProd bool) *DespatchAdviceHandler {
+	return &DespatchAdviceHandler{
+		sunatService: sunat.NewService(isProd),
+	}
+}
+
+// Handle maneja la emisión de una guía de remisión (09 remitente, 31 transportista)
+func (h *DespatchAdviceHandler) Handle(c *gin.Context) {
+	var req services.DespatchAdviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !autorizarRUC(c, req.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(req.Detalle)) || !limitarEmisor(c, req.Emisor.RUC) {
+		return
+	}
+
+	// Convertir a UBL y firmar
+	inicio := time.Now()
+	xmlContent, err := services.ConvertToUBLDespatchAdvice(c.Request.Context(), &req)
+	metrics.ObservarConversion(req.Comprobante.TipoComprobante, inicio, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Preparar y validar el documento
+	invoiceID := req.Emisor.RUC + "-" + req.Comprobante.TipoComprobante + "-" + req.Comprobante.Serie + "-" + req.Comprobante.Numero
+	result, err := h.sunatService.PrepareAndValidate(xmlContent, invoiceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Enviar a la API REST de SUNAT
+	xmlPath, ok := result["file"].(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ruta XML no encontrada"})
+		return
+	}
+
+	ticket, err := h.sunatService.SendDespatchAdvice(c.Request.Context(), xmlPath)
+	if err != nil {
+		respondEnvioError(c, invoiceID, err)
+		return
+	}
+
+	// El resultado se obtiene consultando el ticket en segundo plano
+	if scheduler := services.GetTicketScheduler(); scheduler != nil {
+		if _, err := scheduler.Registrar(ticket, services.TicketGRE, req.Emisor.RUC, invoiceID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "document_id": invoiceID})
+}
+
+// ConsultaTicket consulta el estado del envío de una guía de remisión;
+// el RUC del emisor (?ruc=) determina las credenciales usadas
+func (h *DespatchAdviceHandler) ConsultaTicket(c *gin.Context) {
+	ruc := c.Query("ruc")
+	if ruc == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el parámetro ruc es obligatorio"})
+		return
+	}
+	if !autorizarRUC(c, ruc) {
+		return
+	}
+
+	status, err := h.sunatService.ConsultaTicketGRE(c.Request.Context(), ruc, c.Param("ticket"))
+	if err != nil {
+		respondSUNATError(c, err)
+		return
+	}
+
+	response := gin.H{"codigo_respuesta": status.CodRespuesta}
+	switch status.CodRespuesta {
+	case "0":
+		response["estado"] = "aceptado"
+	case "98":
+		response["estado"] = "en proceso"
+	default:
+		response["estado"] = "rechazado"
+	}
+	if status.Error != nil {
+		response["error"] = gin.H{"codigo": status.Error.NumError, "descripcion": status.Error.DesError}
+	}
+	if status.ArcCdr != "" {
+		response["cdr_zip"] = status.ArcCdr
+	}
+
+	c.JSON(http.StatusOK, response)
+}
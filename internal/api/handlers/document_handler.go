@@ -1,11 +1,7 @@
 package handlers
 
 import (
-	"encoding/base64"
-	"fmt"
-	"io/ioutil"
 	"net/http"
-	"path/filepath"
 	"strings"
 	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
@@ -28,6 +24,9 @@ func NewDocumentHandler(isProd bool) *DocumentHandler {
 // GetStatus maneja la consulta de estado de un documento
 func (h *DocumentHandler) GetStatus(c *gin.Context) {
 	id := c.Param("id")
+	if !autorizarRUC(c, rucDocumento(id)) {
+		return
+	}
 
 	// Intentar obtener de la memoria primero
 	if docData, found := services.GetDocument(id); found {
@@ -35,9 +34,10 @@ func (h *DocumentHandler) GetStatus(c *gin.Context) {
 			"success":     true,
 			"document_id": id,
 			"estado":      docData.Status,
-			"xml_url":     fmt.Sprintf("/document/%s/xml", id),
-			"pdf_url":     docData.PDFURL,
-			"cdr_zip_url": fmt.Sprintf("/document/%s/cdr", id), // Asumiendo una ruta para el CDR
+			"cdr":         docData.CDR,
+			"xml_url":     downloadURL(id, FormatoXML),
+			"pdf_url":     downloadURL(id, FormatoPDF),
+			"cdr_zip_url": downloadURL(id, FormatoCDR),
 		})
 		return
 	}
@@ -53,59 +53,43 @@ func (h *DocumentHandler) GetStatus(c *gin.Context) {
 	serie := parts[2]
 	numero := parts[3]
 
-	status, err := h.sunatService.ConsultaEstado(ruc, tipo, serie, numero)
+	status, err := h.sunatService.ConsultaEstado(c.Request.Context(), ruc, tipo, serie, numero)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSUNATError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"id": id, "status": status})
+	c.JSON(http.StatusOK, gin.H{
+		"id":      id,
+		"codigo":  status.Codigo,
+		"mensaje": status.Mensaje,
+		"estado":  estadoConsulta(status.Codigo),
+	})
 }
 
 // GetXML maneja la obtención del XML de un documento
 func (h *DocumentHandler) GetXML(c *gin.Context) {
 	id := c.Param("id")
-
-	var xmlContent []byte
-	var err error
-
-	// Intentar obtener de la memoria primero
-	if docData, found := services.GetDocument(id); found {
-		xmlContent = []byte(docData.XMLContent)
-	} else {
-		// Si no está en memoria, leer del archivo de respaldo
-		filePath := filepath.Join("temp", id+".xml")
-		xmlContent, err = ioutil.ReadFile(filePath)
-		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("XML no encontrado para el ID: %s", id)})
-			return
-		}
-	}
-
-	// Decodificar de Base64 si es necesario (el contenido del archivo también está codificado)
-	decodedXML, err := base64.StdEncoding.DecodeString(string(xmlContent))
-	if err != nil {
-		// Si falla la decodificación, podría ser que no estaba en Base64. Servirlo directamente.
-		c.Data(http.StatusOK, "application/xml", xmlContent)
+	if !autorizarRUC(c, rucDocumento(id)) {
 		return
 	}
-
-	c.Data(http.StatusOK, "application/xml", decodedXML)
+	serveXML(c, id)
 }
 
 // GetPDF maneja la obtención del PDF de un documento
 func (h *DocumentHandler) GetPDF(c *gin.Context) {
 	id := c.Param("id")
-
-	// El PDF se genera y guarda en 'temp' durante el envío.
-	// El nombre del archivo es {id}.pdf
-	filePath := filepath.Join("temp", id+".pdf")
-
-	content, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("PDF no encontrado para el ID: %s", id)})
+	if !autorizarRUC(c, rucDocumento(id)) {
 		return
 	}
+	servePDF(c, id)
+}
 
-	c.Data(http.StatusOK, "application/pdf", content)
+// GetCDR maneja la obtención del ZIP del CDR de un documento
+func (h *DocumentHandler) GetCDR(c *gin.Context) {
+	id := c.Param("id")
+	if !autorizarRUC(c, rucDocumento(id)) {
+		return
+	}
+	serveCDR(c, id)
 }
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"ubl-converter/internal/core/services"
+	"ubl-converter/internal/pkg/signedurl"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Formatos que se pueden descargar de un documento
+const (
+	FormatoXML = "xml"
+	FormatoPDF = "pdf"
+	FormatoCDR = "cdr"
+)
+
+var (
+	downloadSigner  *signedurl.Signer
+	downloadBaseURL string
+	downloadMutex   = &sync.RWMutex{}
+)
+
+// SetDownloads configura el firmador de los enlaces de descarga y la URL
+// pública con la que se construyen. La URL es de configuración y no se toma
+// del Host de la solicitud, que el cliente puede falsificar.
+func SetDownloads(signer *signedurl.Signer, baseURL string) {
+	downloadMutex.Lock()
+	defer downloadMutex.Unlock()
+	downloadSigner = signer
+	downloadBaseURL = baseURL
+}
+
+func getDownloads() (*signedurl.Signer, string) {
+	downloadMutex.RLock()
+	defer downloadMutex.RUnlock()
+	return downloadSigner, downloadBaseURL
+}
+
+// downloadPath retorna la ruta pública de descarga de un formato del documento
+func downloadPath(documentID, formato string) string {
+	return fmt.Sprintf("/downloads/%s/%s", documentID, formato)
+}
+
+// downloadURL retorna un enlace firmado y con vencimiento para descargar el
+// documento sin credenciales
+func downloadURL(documentID, formato string) string {
+	signer, baseURL := getDownloads()
+	if signer == nil {
+		return ""
+	}
+	return baseURL + signer.Sign(downloadPath(documentID, formato))
+}
+
+// Download sirve un documento a través de un enlace firmado, sin credenciales
+func (h *DocumentHandler) Download(c *gin.Context) {
+	id := c.Param("id")
+	formato := c.Param("formato")
+
+	signer, _ := getDownloads()
+	if signer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "las descargas firmadas no están habilitadas"})
+		return
+	}
+	if err := signer.Verify(downloadPath(id, formato), c.Request.URL.Query()); err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch formato {
+	case FormatoXML:
+		serveXML(c, id)
+	case FormatoPDF:
+		servePDF(c, id)
+	case FormatoCDR:
+		serveCDR(c, id)
+	default:
+		c.JSON(http.StatusNotFound, gin.H{"error": "formato de descarga desconocido: " + formato})
+	}
+}
+
+// serveXML responde el XML firmado del documento, de la memoria o del
+// archivo de respaldo en temp
+func serveXML(c *gin.Context, id string) {
+	var xmlContent []byte
+	if docData, found := services.GetDocument(id); found && docData.XMLContent != "" {
+		xmlContent = []byte(docData.XMLContent)
+	} else {
+		content, err := os.ReadFile(filepath.Join("temp", filepath.Base(id)+".xml"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("XML no encontrado para el ID: %s", id)})
+			return
+		}
+		xmlContent = content
+	}
+
+	// El contenido puede estar en Base64; si no lo está se sirve tal cual
+	if decoded, err := base64.StdEncoding.DecodeString(string(xmlContent)); err == nil {
+		xmlContent = decoded
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", id+".xml"))
+	c.Data(http.StatusOK, "application/xml", xmlContent)
+}
+
+// servePDF responde el PDF generado en temp durante el envío
+func servePDF(c *gin.Context, id string) {
+	content, err := os.ReadFile(filepath.Join("temp", filepath.Base(id)+".pdf"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("PDF no encontrado para el ID: %s", id)})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", id+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", content)
+}
+
+// serveCDR responde el ZIP del CDR recibido de SUNAT
+func serveCDR(c *gin.Context, id string) {
+	docData, found := services.GetDocument(id)
+	if !found || docData.CDRZip == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("CDR no encontrado para el ID: %s", id)})
+		return
+	}
+	content, err := base64.StdEncoding.DecodeString(docData.CDRZip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "el CDR almacenado está dañado"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "R-"+id+".zip"))
+	c.Data(http.StatusOK, "application/zip", content)
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"ubl-converter/internal/core/services"
+	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// envioResponse registra el CDR recibido y construye la respuesta del envío
+func envioResponse(documentID string, resultado *sunat.EnvioResultado) gin.H {
+	services.SaveCDR(documentID, resultado.CDR, resultado.CDRZip)
+	registrarEnvio(documentID, resultado.CDR.Estado())
+	return gin.H{
+		"document_id": documentID,
+		"estado":      resultado.CDR.Estado(),
+		"cdr":         resultado.CDR,
+		"cdr_zip":     base64.StdEncoding.EncodeToString(resultado.CDRZip),
+	}
+}
+
+// respondEnvioError registra el envío fallido y responde el error de SUNAT
+func respondEnvioError(c *gin.Context, documentID string, err error) {
+	registrarEnvio(documentID, metrics.ResultadoError)
+	respondSUNATError(c, err)
+}
+
+// registrarEnvio cuenta el envío con el tipo y el RUC tomados del identificador
+// RUC-TIPO-SERIE-NUMERO
+func registrarEnvio(documentID, resultado string) {
+	partes := strings.SplitN(documentID, "-", 3)
+	if len(partes) < 3 {
+		return
+	}
+	metrics.RegistrarDocumento(partes[1], partes[0], resultado)
+}
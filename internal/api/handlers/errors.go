@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/logging"
+	"ubl-converter/internal/pkg/ratelimit"
+	"ubl-converter/internal/pkg/soap"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sunatRetryAfter segundos sugeridos antes de reintentar tras una falla temporal de SUNAT
+const sunatRetryAfter = "30"
+
+// respondSUNATError traduce un error de SUNAT a un estado HTTP y un cuerpo
+// JSON con el código y la categoría: 502 si SUNAT rechaza las credenciales
+// SOL, 409 si el comprobante ya fue registrado, 503 con Retry-After ante
+// fallas temporales, 429 con Retry-After si se excede el límite de llamadas
+// a SUNAT y 422 para los demás rechazos. Los errores que no vienen de SUNAT
+// se responden con 500.
+func respondSUNATError(c *gin.Context, err error) {
+	logger := logging.FromContext(c.Request.Context())
+	sunatErr, ok := soap.AsSunatError(err)
+	if !ok {
+		logger.Error("error llamando a SUNAT", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	logger.Warn("error de SUNAT", "codigo_sunat", sunatErr.Codigo, "categoria", sunatErr.Categoria, "error", sunatErr.Mensaje)
+
+	status := http.StatusUnprocessableEntity
+	switch sunatErr.Categoria {
+	case catalog.ErrorAutenticacion:
+		// Las credenciales SOL del emisor son configuración del servidor
+		status = http.StatusBadGateway
+	case catalog.ErrorDuplicado:
+		status = http.StatusConflict
+	case catalog.ErrorTransitorio:
+		status = http.StatusServiceUnavailable
+		c.Header("Retry-After", sunatRetryAfter)
+	}
+	var rateErr *httpclient.RateLimitError
+	if errors.As(err, &rateErr) {
+		status = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfter(rateErr.RetryAfter)))
+	}
+
+	body := gin.H{
+		"error":        sunatErr.Mensaje,
+		"categoria":    sunatErr.Categoria,
+		"reintentable": sunatErr.Reintentable(),
+	}
+	if sunatErr.Codigo != "" {
+		body["codigo_sunat"] = sunatErr.Codigo
+	}
+	if sunatErr.Detalle != "" && sunatErr.Detalle != sunatErr.Mensaje {
+		body["detalle"] = sunatErr.Detalle
+	}
+	c.JSON(status, body)
+}
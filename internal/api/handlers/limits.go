@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	emisorLimiter *ratelimit.Limiter
+	maxLineas     int
+	limitsMutex   = &sync.RWMutex{}
+)
+
+// SetLimits configura el límite de comprobantes por minuto de cada RUC
+// emisor y la cantidad máxima de líneas por comprobante
+func SetLimits(cfg config.APILimitsConfig) {
+	limitsMutex.Lock()
+	defer limitsMutex.Unlock()
+	emisorLimiter = ratelimit.New(cfg.EmisorPorMinuto/60, cfg.EmisorRafaga)
+	maxLineas = cfg.MaxLineas
+}
+
+// limitarEmisor consume un turno del RUC emisor y, si no hay, responde 429
+// con Retry-After
+func limitarEmisor(c *gin.Context, ruc string) bool {
+	limitsMutex.RLock()
+	limiter := emisorLimiter
+	limitsMutex.RUnlock()
+
+	if ok, espera := limiter.Allow(ruc); !ok {
+		c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfter(espera)))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "se excedió el límite de comprobantes del RUC " + ruc})
+		return false
+	}
+	return true
+}
+
+// validarLineas rechaza con 413 los comprobantes con más líneas que el máximo
+func validarLineas(c *gin.Context, lineas int) bool {
+	limitsMutex.RLock()
+	max := maxLineas
+	limitsMutex.RUnlock()
+
+	if max > 0 && lineas > max {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("el comprobante tiene %d líneas; el máximo es %d", lineas, max)})
+		return false
+	}
+	return true
+}
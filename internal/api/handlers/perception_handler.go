@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"ubl-converter/internal/core/services"
+	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PerceptionHandler estructura para el manejador de comprobantes de percepción
+type PerceptionHandler struct {
+	sunatService sunat.Service
+}
+
+// NewPerceptionHandler crea una nueva instancia de PerceptionHandler
+func NewPerceptionHandler(isProd bool) *PerceptionHandler {
+	return &PerceptionHandler{
+		sunatService: sunat.NewService(isProd),
+	}
+}
+
+// Handle maneja la emisión de un comprobante de percepción
+func (h *PerceptionHandler) Handle(c *gin.Context) {
+	var req services.PerceptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !autorizarRUC(c, req.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(req.Documentos)) || !limitarEmisor(c, req.Emisor.RUC) {
+		return
+	}
+
+	// Convertir a UBL y firmar
+	inicio := time.Now()
+	xmlContent, err := services.ConvertToUBLPerception(c.Request.Context(), &req)
+	metrics.ObservarConversion("40", inicio, err)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// El tipo de comprobante para percepción es '40'
+	invoiceID := req.Emisor.RUC + "-40-" + req.Comprobante.Serie + "-" + req.Comprobante.Numero
+	result, err := h.sunatService.PrepareAndValidate(xmlContent, invoiceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Enviar a SUNAT
+	xmlPath, ok := result["file"].(string)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "ruta XML no encontrada"})
+		return
+	}
+
+	resultado, err := h.sunatService.SendPerception(c.Request.Context(), xmlPath)
+	if err != nil {
+		respondEnvioError(c, invoiceID, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, envioResponse(invoiceID, resultado))
+}
@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/logging"
+	"ubl-converter/internal/pkg/metrics"
 	"ubl-converter/internal/pkg/pdfutil"
-	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/gin-gonic/gin"
 )
@@ -35,9 +37,17 @@ func (h *SendHandler) Handle(c *gin.Context) {
 		})
 		return
 	}
+	if !autorizarRUC(c, req.Emisor.RUC) {
+		return
+	}
+	if !validarLineas(c, len(req.Detalle)) || !limitarEmisor(c, req.Emisor.RUC) {
+		return
+	}
 
 	// Convertir a XML
-	xmlContent, err := services.ConvertirAUBL(&req)
+	inicio := time.Now()
+	xmlContent, err := services.ConvertirAUBL(c.Request.Context(), &req)
+	metrics.ObservarConversion(req.Comprobante.TipoComprobante, inicio, err)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -66,18 +76,12 @@ func (h *SendHandler) Handle(c *gin.Context) {
 		return
 	}
 	pdfPath := pdfutil.BuildPDFPath(invoiceID)
-	if err := pdfutil.GenerateInvoicePDF(xmlPath, pdfPath); err != nil {
+	if err := pdfutil.GenerateInvoicePDF(c.Request.Context(), xmlPath, pdfPath); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
 	}
-	// Construir URL completa para el PDF
-	host := c.Request.Host
-	scheme := "http"
-	if c.Request.TLS != nil {
-		scheme = "https"
-	}
-	// Asumiendo que la ruta para obtener el PDF es /document/{id}/pdf
-	pdfURL := fmt.Sprintf("%s://%s/document/%s/pdf", scheme, host, invoiceID)
+	// Enlace firmado y con vencimiento para descargar el PDF sin credenciales
+	pdfURL := downloadURL(invoiceID, FormatoPDF)
 
 	// Extraer datos del resultado de forma segura
 	estado, _ := result["estado"].(string)
@@ -89,14 +93,13 @@ func (h *SendHandler) Handle(c *gin.Context) {
 	xmlFirmadoPath := filepath.Join("temp", invoiceID+".xml")
 	if err := os.WriteFile(xmlFirmadoPath, []byte(xmlFirmado), 0644); err != nil {
 		// Loggear el error pero no interrumpir el flujo
-		fmt.Printf("Error al guardar el XML firmado en disco: %v\n", err)
+		logging.FromContext(c.Request.Context()).Error("error al guardar el XML firmado en disco", "documento", invoiceID, "error", err.Error())
 	}
 
 	// Guardar en memoria
 	docData := services.DocumentData{
 		Status:     estado,
 		XMLContent: xmlFirmado,
-		PDFURL:     pdfURL,
 		CDRZip:     cdrZip,
 	}
 	services.SaveDocument(invoiceID, docData)
@@ -1,9 +1,18 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"ubl-converter/internal/core/services"
 	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/auth"
+	"ubl-converter/internal/pkg/catalog"
 
 	"github.com/gin-gonic/gin"
 )
@@ -32,14 +41,27 @@ func (h *SUNATHandler) ConsultaCDR(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !autorizarRUC(c, req.RUC) {
+		return
+	}
 
-	result, err := h.sunatService.ConsultaCDR(req.RUC, req.TipoComprobante, req.Serie, req.Numero)
+	result, err := h.sunatService.ConsultaCDR(c.Request.Context(), req.RUC, req.TipoComprobante, req.Serie, req.Numero)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSUNATError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"cdr": result})
+	response := gin.H{"codigo": result.Codigo, "mensaje": result.Mensaje}
+	if result.CDR != nil {
+		id := req.RUC + "-" + req.TipoComprobante + "-" + req.Serie + "-" + req.Numero
+		services.SaveCDR(id, result.CDR, result.CDRZip)
+		response["document_id"] = id
+		response["estado"] = result.CDR.Estado()
+		response["cdr"] = result.CDR
+		response["cdr_zip"] = base64.StdEncoding.EncodeToString(result.CDRZip)
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 // GET /sunat/consulta-estado
@@ -49,18 +71,26 @@ func (h *SUNATHandler) ConsultaEstado(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if !autorizarRUC(c, req.RUC) {
+		return
+	}
 
-	result, err := h.sunatService.ConsultaEstado(req.RUC, req.TipoComprobante, req.Serie, req.Numero)
+	result, err := h.sunatService.ConsultaEstado(c.Request.Context(), req.RUC, req.TipoComprobante, req.Serie, req.Numero)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondSUNATError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"estado": result})
+	c.JSON(http.StatusOK, gin.H{
+		"codigo":  result.Codigo,
+		"mensaje": result.Mensaje,
+		"estado":  estadoConsulta(result.Codigo),
+	})
 }
 
 type ConsultaTicketRequest struct {
 	Ticket string `json:"ticket" binding:"required"`
+	RUC    string `json:"ruc" binding:"required"` // emisor que envió el resumen o la baja
 }
 
 // GET /sunat/consulta-ticket
@@ -70,12 +100,214 @@ func (h *SUNATHandler) ConsultaTicket(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// SUNAT solo responde al emisor que envió el ticket, con su usuario SOL
+	if !autorizarRUC(c, req.RUC) {
+		return
+	}
 
-	result, err := h.sunatService.ConsultaTicket(req.Ticket)
+	result, err := h.sunatService.ConsultaTicket(c.Request.Context(), req.RUC, req.Ticket)
+	if err != nil {
+		respondSUNATError(c, err)
+		return
+	}
+
+	response := gin.H{"codigo": result.Codigo, "mensaje": result.Mensaje}
+	if result.CDR != nil {
+		response["cdr"] = result.CDR
+		response["cdr_zip"] = base64.StdEncoding.EncodeToString(result.CDRZip)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+type EnvioResumenRequest struct {
+	Archivo string `json:"archivo" binding:"required"` // RUC-TIPO-FECHA-CORRELATIVO, sin extensión
+	XML     string `json:"xml" binding:"required"`     // XML firmado en Base64
+}
+
+// archivoResumen valida el nombre de un resumen: RUC-TIPO-FECHA-CORRELATIVO
+var archivoResumen = regexp.MustCompile(`^\d{11}-(RC|RA|RR)-\d{8}-\d{1,5}$`)
+
+// Tipo de ticket según el tipo de resumen
+var ticketPorResumen = map[string]string{
+	"RC": services.TicketResumen,
+	"RA": services.TicketBaja,
+	"RR": services.TicketOtrosCPE,
+}
+
+// POST /sunat/resumenes
+// Envía un resumen diario, una comunicación de baja o una reversión firmados
+// y registra el ticket para consultarlo en segundo plano
+func (h *SUNATHandler) EnviarResumen(c *gin.Context) {
+	var req EnvioResumenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !archivoResumen.MatchString(req.Archivo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nombre de archivo inválido. Formato esperado: RUC-RC|RA|RR-AAAAMMDD-CORRELATIVO"})
+		return
+	}
+	partes := strings.Split(req.Archivo, "-")
+	ruc, tipo := partes[0], ticketPorResumen[partes[1]]
+	if !autorizarRUC(c, ruc) {
+		return
+	}
+	if tipo != services.TicketResumen && !autorizarPermiso(c, auth.PermisoVoid) {
+		return
+	}
+	if !limitarEmisor(c, ruc) {
+		return
+	}
+
+	scheduler := services.GetTicketScheduler()
+	if scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "la consulta de tickets en segundo plano no está activa"})
+		return
+	}
+
+	xmlContent, err := base64.StdEncoding.DecodeString(req.XML)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el XML debe enviarse en Base64"})
+		return
+	}
+	xmlPath := filepath.Join("temp", req.Archivo+".xml")
+	if err := os.WriteFile(xmlPath, xmlContent, 0644); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "error escribiendo XML: " + err.Error()})
+		return
+	}
+
+	ticket, err := h.sunatService.SendSummary(c.Request.Context(), xmlPath)
+	if err != nil {
+		respondSUNATError(c, err)
+		return
+	}
+
+	data, err := scheduler.Registrar(ticket, tipo, ruc, req.Archivo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"estado": result})
+	c.JSON(http.StatusAccepted, data)
+}
+
+type RegistrarTicketRequest struct {
+	Ticket     string `json:"ticket" binding:"required"`
+	Tipo       string `json:"tipo" binding:"required"`
+	RUC        string `json:"ruc"`
+	DocumentID string `json:"document_id"`
+}
+
+// POST /sunat/tickets
+// Registra un ticket para consultarlo periódicamente en segundo plano
+func (h *SUNATHandler) RegistrarTicket(c *gin.Context) {
+	var req RegistrarTicketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	// El documento vinculado recibe el estado y el CDR del ticket: debe ser
+	// del mismo emisor, de modo que autorizar el RUC autoriza ambos
+	ruc := req.RUC
+	if ruc == "" {
+		ruc = rucDocumento(req.DocumentID)
+	}
+	if req.DocumentID != "" && rucDocumento(req.DocumentID) != ruc {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "el documento vinculado no pertenece al RUC " + ruc})
+		return
+	}
+	if !autorizarRUC(c, ruc) {
+		return
+	}
+	if req.Tipo == services.TicketBaja && !autorizarPermiso(c, auth.PermisoVoid) {
+		return
+	}
+
+	scheduler := services.GetTicketScheduler()
+	if scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "la consulta de tickets en segundo plano no está activa"})
+		return
+	}
+
+	// Un ticket ya registrado solo puede volver a registrarlo su emisor
+	if actual, found := scheduler.Get(req.Ticket); found && !autorizarRUC(c, rucTicketData(actual)) {
+		return
+	}
+	data, err := scheduler.Registrar(req.Ticket, req.Tipo, ruc, req.DocumentID)
+	if errors.Is(err, services.ErrTicketAjeno) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, data)
+}
+
+// GET /sunat/tickets/:ticket
+// Retorna el estado de un ticket registrado
+func (h *SUNATHandler) GetTicket(c *gin.Context) {
+	scheduler := services.GetTicketScheduler()
+	if scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "la consulta de tickets en segundo plano no está activa"})
+		return
+	}
+
+	data, found := scheduler.Get(c.Param("ticket"))
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ticket no registrado"})
+		return
+	}
+	if !autorizarRUC(c, rucTicketData(data)) {
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
+
+// rucTicketData retorna el RUC de un ticket o el de su documento vinculado
+func rucTicketData(data services.TicketData) string {
+	if data.RUC != "" {
+		return data.RUC
+	}
+	return rucDocumento(data.DocumentID)
+}
+
+// estadoConsulta traduce el código de getStatus al estado del comprobante
+func estadoConsulta(codigo string) string {
+	switch codigo {
+	case catalog.ConsultaAceptado:
+		return "aceptado"
+	case catalog.ConsultaRechazado:
+		return "rechazado"
+	case catalog.ConsultaBaja:
+		return "de baja"
+	case catalog.ConsultaNoExiste:
+		return "no existe"
+	default:
+		return "desconocido"
+	}
+}
+
+// POST /sunat/validez
+// Valida comprobantes emitidos por terceros con la consulta integrada de SUNAT
+func (h *SUNATHandler) ConsultaValidez(c *gin.Context) {
+	var req services.ValidezRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !autorizarRUC(c, req.RUCConsultante) {
+		return
+	}
+
+	resultados, err := services.ConsultarValidez(c.Request.Context(), h.sunatService, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"resultados": resultados})
 }
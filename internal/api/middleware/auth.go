@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"ubl-converter/internal/pkg/auth"
+	"ubl-converter/internal/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Authenticate exige una API key o un JWT válido y deja el cliente
+// autenticado en el contexto de la solicitud
+func Authenticate(authenticator *auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, err := authenticator.Authenticate(c.Request)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Warn("autenticación rechazada", "error", err.Error())
+			mensaje := auth.ErrCredencialesInvalidas.Error()
+			if errors.Is(err, auth.ErrSinCredenciales) {
+				mensaje = err.Error()
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="ubl-converter"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": mensaje})
+			return
+		}
+
+		ctx := auth.WithPrincipal(c.Request.Context(), principal)
+		logger := logging.FromContext(ctx).With("cliente", principal.ID)
+		c.Request = c.Request.WithContext(logging.WithLogger(ctx, logger))
+		c.Next()
+	}
+}
+
+// RequirePermiso rechaza con 403 a los clientes sin el permiso indicado
+func RequirePermiso(permiso string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.FromContext(c.Request.Context()).Permite(permiso) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "el cliente no tiene el permiso " + permiso})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ubl-converter/internal/pkg/auth"
+	"ubl-converter/internal/pkg/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limita las solicitudes de cada cliente autenticado; sin
+// autenticación el límite se aplica por IP. Responde 429 con Retry-After.
+func RateLimit(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clave := "ip:" + c.ClientIP()
+		if principal := auth.FromContext(c.Request.Context()); principal != nil && principal.Metodo != "ninguno" {
+			clave = principal.Metodo + ":" + principal.ID
+		}
+		if ok, espera := limiter.Allow(clave); !ok {
+			abortRateLimit(c, espera, "se excedió el límite de solicitudes del cliente")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitIP limita las solicitudes de cada IP. Va antes de la
+// autenticación para que también se limiten los intentos con credenciales
+// inválidas. Responde 429 con Retry-After.
+func RateLimitIP(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ok, espera := limiter.Allow(c.ClientIP()); !ok {
+			abortRateLimit(c, espera, "se excedió el límite de solicitudes desde la IP")
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortRateLimit(c *gin.Context, espera time.Duration, mensaje string) {
+	c.Header("Retry-After", strconv.Itoa(ratelimit.RetryAfter(espera)))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": mensaje})
+}
+
+// MaxBodySize rechaza con 413 los cuerpos de más de max bytes
+func MaxBodySize(max int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > max {
+			abortBodyTooLarge(c, max)
+			return
+		}
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		// Sin Content-Length (chunked) se lee hasta un byte más del máximo
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, max+1))
+		c.Request.Body.Close()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "error leyendo el cuerpo de la solicitud"})
+			return
+		}
+		if int64(len(body)) > max {
+			abortBodyTooLarge(c, max)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func abortBodyTooLarge(c *gin.Context, max int64) {
+	c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("el cuerpo de la solicitud excede %d bytes", max)})
+}
@@ -0,0 +1,88 @@
+// Package middleware contiene los middlewares de gin de la API
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"ubl-converter/internal/pkg/logging"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader cabecera con el identificador de la solicitud
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID asigna a cada solicitud el request ID recibido en X-Request-ID o
+// uno nuevo, lo devuelve en la respuesta y deja en el contexto de la
+// solicitud un logger que lo incluye, junto con el ID de la traza si la hay
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+
+		logger := logging.FromContext(c.Request.Context()).With("request_id", id)
+		if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+			logger = logger.With("trace_id", span.TraceID().String())
+		}
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), logger))
+		c.Next()
+	}
+}
+
+// AccessLog registra cada solicitud con el logger estructurado
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		inicio := time.Now()
+		c.Next()
+
+		logger := logging.FromContext(c.Request.Context())
+		attrs := []any{
+			"metodo", c.Request.Method,
+			"ruta", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"duracion_ms", time.Since(inicio).Milliseconds(),
+			"ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errores", c.Errors.String())
+		}
+		switch {
+		case c.Writer.Status() >= 500:
+			logger.Error("solicitud", attrs...)
+		case c.Writer.Status() >= 400:
+			logger.Warn("solicitud", attrs...)
+		default:
+			logger.Info("solicitud", attrs...)
+		}
+	}
+}
+
+// validRequestID acepta solo identificadores cortos con caracteres seguros
+// para los logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,13 +1,28 @@
 package routes
 
 import (
+	"fmt"
+	"log/slog"
+
 	"ubl-converter/internal/api/handlers"
+	"ubl-converter/internal/api/middleware"
+	"ubl-converter/internal/pkg/auth"
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/ratelimit"
+	"ubl-converter/internal/pkg/signedurl"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func SetupRouter(isProd bool) *gin.Engine {
-	r := gin.Default()
+	r := gin.New()
+	r.Use(
+		gin.Recovery(),
+		otelgin.Middleware(config.GetTracingConfig().ServiceName),
+		middleware.RequestID(),
+		middleware.AccessLog(),
+	)
 
 	// Health check endpoints
 	r.GET("/", func(c *gin.Context) {
@@ -17,35 +32,88 @@ func SetupRouter(isProd bool) *gin.Engine {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
 
-	// Exponer archivos generados (XML, PDF, etc.)
-	r.Static("/files", "./temp")
+	// Los XML, PDF y CDR generados solo se descargan por las rutas
+	// autenticadas o con un enlace firmado y con vencimiento
+	downloads := config.GetDownloadConfig()
+	if err := downloads.Validate(); err != nil {
+		panic(fmt.Sprintf("error configurando los enlaces de descarga: %v", err))
+	}
+	signer, err := signedurl.New([]byte(downloads.Secret), downloads.TTL)
+	if err != nil {
+		panic(fmt.Sprintf("error configurando los enlaces de descarga: %v", err))
+	}
+	handlers.SetDownloads(signer, downloads.BaseURL)
+	documentHandler := handlers.NewDocumentHandler(isProd)
+	r.GET("/downloads/:id/:formato", documentHandler.Download)
+
+	// Las rutas de la API exigen una API key o un JWT; cada cliente solo
+	// opera con sus RUC y permisos
+	authenticator, err := auth.NewAuthenticator(config.GetAuthConfig())
+	if err != nil {
+		panic(fmt.Sprintf("error configurando la autenticación: %v", err))
+	}
+	if !authenticator.Configurado() {
+		slog.Warn("no hay API keys ni JWT configurados: las rutas /api/v1 rechazarán todas las solicitudes")
+	}
+	convert := middleware.RequirePermiso(auth.PermisoConvert)
+	send := middleware.RequirePermiso(auth.PermisoSend)
+	read := middleware.RequirePermiso(auth.PermisoRead)
 
-	api := r.Group("/api/v1")
+	// Límites por cliente, por RUC emisor y de tamaño de las solicitudes
+	limits := config.GetAPILimitsConfig()
+	handlers.SetLimits(limits)
+
+	// La IP del cliente solo se toma de X-Forwarded-For si el proxy es confiable
+	if err := r.SetTrustedProxies(limits.ProxiesConfiables); err != nil {
+		panic(fmt.Sprintf("error configurando los proxies confiables: %v", err))
+	}
+
+	// El límite por IP y el tamaño del cuerpo se aplican antes de autenticar,
+	// para no permitir probar credenciales sin límite ni leer cuerpos grandes
+	api := r.Group("/api/v1",
+		middleware.RateLimitIP(ratelimit.New(limits.IPPorMinuto/60, limits.IPRafaga)),
+		middleware.MaxBodySize(limits.MaxBodyBytes),
+		middleware.Authenticate(authenticator),
+		middleware.RateLimit(ratelimit.New(limits.ClientePorMinuto/60, limits.ClienteRafaga)),
+	)
 	{
 		// Core functionality endpoints
 		convertHandler := handlers.NewConvertHandler(isProd)
 		sendHandler := handlers.NewSendHandler(isProd)
-		api.POST("/convert", convertHandler.ConvertirAUBL)
-		api.POST("/send", sendHandler.Handle)
+		api.POST("/convert", convert, convertHandler.ConvertirAUBL)
+		api.POST("/send", send, sendHandler.Handle)
 
 		creditNoteHandler := handlers.NewCreditNoteHandler(isProd)
 		debitNoteHandler := handlers.NewDebitNoteHandler(isProd)
-		api.POST("/credit-notes", creditNoteHandler.Handle)
-		api.POST("/debit-notes", debitNoteHandler.Handle)
+		api.POST("/credit-notes", send, creditNoteHandler.Handle)
+		api.POST("/debit-notes", send, debitNoteHandler.Handle)
+
+		retentionHandler := handlers.NewRetentionHandler(isProd)
+		perceptionHandler := handlers.NewPerceptionHandler(isProd)
+		api.POST("/retentions", send, retentionHandler.Handle)
+		api.POST("/perceptions", send, perceptionHandler.Handle)
+
+		despatchAdviceHandler := handlers.NewDespatchAdviceHandler(isProd)
+		api.POST("/despatch-advices", send, despatchAdviceHandler.Handle)
+		api.GET("/despatch-advices/tickets/:ticket", read, despatchAdviceHandler.ConsultaTicket)
 
 		// SUNAT consultation endpoints
 		sunatHandler := handlers.NewSUNATHandler(isProd)
 		sunat := api.Group("/sunat")
 		{
-			sunat.POST("/consulta-cdr", sunatHandler.ConsultaCDR)
-			sunat.POST("/consulta-estado", sunatHandler.ConsultaEstado)
-			sunat.GET("/consulta-ticket", sunatHandler.ConsultaTicket)
+			sunat.POST("/consulta-cdr", read, sunatHandler.ConsultaCDR)
+			sunat.POST("/consulta-estado", read, sunatHandler.ConsultaEstado)
+			sunat.GET("/consulta-ticket", read, sunatHandler.ConsultaTicket)
+			sunat.POST("/resumenes", send, sunatHandler.EnviarResumen)
+			sunat.POST("/tickets", send, sunatHandler.RegistrarTicket)
+			sunat.GET("/tickets/:ticket", read, sunatHandler.GetTicket)
+			sunat.POST("/validez", read, sunatHandler.ConsultaValidez)
 		}
 
-		documentHandler := handlers.NewDocumentHandler(isProd)
-		api.GET("/documents/:id/status", documentHandler.GetStatus)
-		api.GET("/documents/:id/xml", documentHandler.GetXML)
-		api.GET("/documents/:id/pdf", documentHandler.GetPDF)
+		api.GET("/documents/:id/status", read, documentHandler.GetStatus)
+		api.GET("/documents/:id/xml", read, documentHandler.GetXML)
+		api.GET("/documents/:id/pdf", read, documentHandler.GetPDF)
+		api.GET("/documents/:id/cdr", read, documentHandler.GetCDR)
 	}
 
 	return r
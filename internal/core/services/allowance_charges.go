@@ -0,0 +1,148 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// CargoDescuentoData estructura para un cargo o descuento (catálogo 53).
+// Basta con indicar el factor o el monto; el otro valor se calcula a partir
+// de la base, que por defecto es el valor de venta del ítem o de la operación.
+type CargoDescuentoData struct {
+	Codigo string `json:"codigo"`
+	Factor string `json:"factor"`
+	Monto  string `json:"monto"`
+	Base   string `json:"base"`
+}
+
+// cargosGlobales contiene los cargos y descuentos a nivel de comprobante
+type cargosGlobales struct {
+	descuentosBase   float64 // descuentos que afectan la base imponible (02)
+	cargosBase       float64 // cargos que afectan la base imponible (49)
+	descuentos       float64 // descuentos que no afectan la base imponible (03)
+	cargos           float64 // cargos que no afectan la base imponible (45, 46, 50)
+	allowanceCharges []ubl.AllowanceCharge
+}
+
+// buildAllowanceCharge valida un cargo o descuento y construye el elemento cac:AllowanceCharge
+func buildAllowanceCharge(data CargoDescuentoData, base float64, moneda string) (ubl.AllowanceCharge, catalog.CargoDescuento, error) {
+	cargo, ok := catalog.GetCargoDescuento(data.Codigo)
+	if !ok {
+		return ubl.AllowanceCharge{}, cargo, fmt.Errorf("código de cargo/descuento inválido: %s", data.Codigo)
+	}
+
+	if data.Base != "" {
+		var err error
+		base, err = strconv.ParseFloat(data.Base, 64)
+		if err != nil || base <= 0 {
+			return ubl.AllowanceCharge{}, cargo, fmt.Errorf("base del cargo/descuento %s inválida", data.Codigo)
+		}
+	}
+
+	var factor, monto float64
+	switch {
+	case data.Monto != "":
+		var err error
+		monto, err = strconv.ParseFloat(data.Monto, 64)
+		if err != nil || monto <= 0 {
+			return ubl.AllowanceCharge{}, cargo, fmt.Errorf("monto del cargo/descuento %s inválido", data.Codigo)
+		}
+		if base > 0 {
+			factor = roundFactor(monto / base)
+		}
+	case data.Factor != "":
+		var err error
+		factor, err = strconv.ParseFloat(data.Factor, 64)
+		if err != nil || factor <= 0 || factor > 1 {
+			return ubl.AllowanceCharge{}, cargo, fmt.Errorf("factor del cargo/descuento %s inválido", data.Codigo)
+		}
+		monto = round2(base * factor)
+	default:
+		return ubl.AllowanceCharge{}, cargo, fmt.Errorf("el cargo/descuento %s requiere factor o monto", data.Codigo)
+	}
+
+	if !cargo.Cargo && monto > base {
+		return ubl.AllowanceCharge{}, cargo, fmt.Errorf("el descuento %s supera su base", data.Codigo)
+	}
+
+	return ubl.AllowanceCharge{
+		ChargeIndicator:           cargo.Cargo,
+		AllowanceChargeReasonCode: cargo.Codigo,
+		MultiplierFactorNumeric:   factor,
+		Amount:                    ubl.MonetaryAmount{Value: monto, CurrencyID: moneda},
+		BaseAmount:                &ubl.MonetaryAmount{Value: base, CurrencyID: moneda},
+	}, cargo, nil
+}
+
+// buildLineAllowanceCharges construye los cargos y descuentos de un ítem
+// (códigos 00, 01, 47 y 48) y retorna el ajuste que producen en su valor de venta.
+func buildLineAllowanceCharges(item DetalleItem, base float64, moneda string) ([]ubl.AllowanceCharge, float64, error) {
+	var allowances []ubl.AllowanceCharge
+	var ajuste float64
+	for _, data := range item.CargosDescuentos {
+		allowance, cargo, err := buildAllowanceCharge(data, base, moneda)
+		if err != nil {
+			return nil, 0, fmt.Errorf("ítem %d: %v", item.Item, err)
+		}
+		if cargo.Global {
+			return nil, 0, fmt.Errorf("ítem %d: el código %s solo aplica a nivel de comprobante", item.Item, cargo.Codigo)
+		}
+		if cargo.AfectaBase {
+			if cargo.Cargo {
+				ajuste += allowance.Amount.Value
+			} else {
+				ajuste -= allowance.Amount.Value
+			}
+		}
+		allowances = append(allowances, allowance)
+	}
+	return allowances, ajuste, nil
+}
+
+// buildCargosGlobales construye los cargos y descuentos a nivel de comprobante
+// (códigos 02, 03, 45, 46, 49 y 50). Los anticipos (04 a 06) y las percepciones
+// (51 a 53) se generan a partir de sus propios datos en la solicitud.
+func buildCargosGlobales(request *FacturaRequest, valorVenta float64) (*cargosGlobales, error) {
+	if len(request.CargosDescuentos) == 0 {
+		return nil, nil
+	}
+
+	result := &cargosGlobales{}
+	for _, data := range request.CargosDescuentos {
+		switch data.Codigo {
+		case "04", "05", "06":
+			return nil, fmt.Errorf("los descuentos por anticipos se indican en el campo anticipos")
+		case "51", "52", "53":
+			return nil, fmt.Errorf("la percepción se indica en el campo percepcion")
+		}
+
+		allowance, cargo, err := buildAllowanceCharge(data, valorVenta, request.Comprobante.Moneda)
+		if err != nil {
+			return nil, err
+		}
+		if !cargo.Global {
+			return nil, fmt.Errorf("el código %s solo aplica a nivel de ítem", cargo.Codigo)
+		}
+
+		monto := allowance.Amount.Value
+		switch {
+		case cargo.Cargo && cargo.AfectaBase:
+			result.cargosBase += monto
+		case cargo.Cargo:
+			result.cargos += monto
+		case cargo.AfectaBase:
+			result.descuentosBase += monto
+		default:
+			result.descuentos += monto
+		}
+		result.allowanceCharges = append(result.allowanceCharges, allowance)
+	}
+
+	if result.descuentosBase > valorVenta {
+		return nil, fmt.Errorf("los descuentos globales superan el valor de venta")
+	}
+	return result, nil
+}
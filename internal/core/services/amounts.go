@@ -0,0 +1,13 @@
+package services
+
+import "math"
+
+// round2 redondea un importe a dos decimales
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// roundFactor redondea un factor (MultiplierFactorNumeric) a cinco decimales
+func roundFactor(v float64) float64 {
+	return math.Round(v*100000) / 100000
+}
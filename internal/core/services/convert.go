@@ -2,12 +2,18 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
+	"math"
 	"strconv"
 
+	"ubl-converter/internal/pkg/catalog"
 	"ubl-converter/internal/pkg/signature"
+	"ubl-converter/internal/pkg/tracing"
 	"ubl-converter/internal/pkg/ubl"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // EmisorData estructura para los datos del emisor
@@ -15,16 +21,27 @@ type EmisorData struct {
 	RUC          string `json:"ruc"`
 	RazonSocial  string `json:"razon_social"`
 	Direccion    string `json:"direccion"`
+	Urbanizacion string `json:"urbanizacion"`
 	Distrito     string `json:"distrito"`
 	Provincia    string `json:"provincia"`
 	Departamento string `json:"departamento"`
 	Ubigeo       string `json:"ubigeo"`
+	// CodigoEstablecimiento es el código del establecimiento anexo; por defecto "0000" (domicilio fiscal)
+	CodigoEstablecimiento string `json:"codigo_establecimiento"`
+	Email                 string `json:"email"`
+	Telefono              string `json:"telefono"`
 }
 
 // ReceptorData estructura para los datos del receptor
 type ReceptorData struct {
+	// TipoDocumento es el tipo de documento de identidad (catálogo 06); por defecto RUC
+	TipoDocumento string `json:"tipo_documento"`
+	// RUC es el número del documento de identidad del receptor
 	RUC         string `json:"ruc"`
 	RazonSocial string `json:"razon_social"`
+	Direccion   string `json:"direccion"`
+	Ubigeo      string `json:"ubigeo"`
+	Email       string `json:"email"`
 }
 
 // ComprobanteData estructura para los datos del comprobante
@@ -34,7 +51,9 @@ type ComprobanteData struct {
 	FechaEmision    string `json:"fecha_emision"`
 	HoraEmision     string `json:"hora_emision"`
 	TipoComprobante string `json:"tipo_comprobante"`
+	TipoOperacion   string `json:"tipo_operacion"`
 	Moneda          string `json:"moneda"`
+	TipoCambio      string `json:"tipo_cambio"`
 	TotalGravado    string `json:"total_gravado"`
 	TotalIGV        string `json:"total_igv"`
 	Total           string `json:"total"`
@@ -43,6 +62,7 @@ type ComprobanteData struct {
 // DetalleItem estructura para los items del detalle
 type DetalleItem struct {
 	Item              int    `json:"item"`
+	Codigo            string `json:"codigo"`
 	Descripcion       string `json:"descripcion"`
 	Cantidad          string `json:"cantidad"`
 	ValorUnitario     string `json:"valor_unitario"`
@@ -54,16 +74,32 @@ type DetalleItem struct {
 	PorcentajeIGV     string `json:"porcentaje_igv"`
 	UnidadMedida      string `json:"unidad_medida"`
 	Total             string `json:"total"`
+
+	// CodigoProductoSunat es el código de producto del catálogo 25 (UNSPSC)
+	CodigoProductoSunat    string                   `json:"codigo_producto_sunat"`
+	PropiedadesAdicionales []PropiedadAdicionalData `json:"propiedades_adicionales"`
+
+	CargosDescuentos []CargoDescuentoData `json:"cargos_descuentos"`
 }
 
 // FacturaRequest estructura para la solicitud de conversión
 type FacturaRequest struct {
-	Emisor      EmisorData      `json:"emisor"`
-	Receptor    ReceptorData    `json:"receptor"`
-	Comprobante ComprobanteData `json:"comprobante"`
-	Detalle     []DetalleItem   `json:"detalle"`
+	Emisor      EmisorData       `json:"emisor"`
+	Receptor    ReceptorData     `json:"receptor"`
+	Comprobante ComprobanteData  `json:"comprobante"`
+	Detalle     []DetalleItem    `json:"detalle"`
+	FormaPago   FormaPagoData    `json:"forma_pago"`
+	Detraccion  *DetraccionData  `json:"detraccion"`
+	Percepcion  *PercepcionData  `json:"percepcion"`
+	Anticipos   []AnticipoData   `json:"anticipos"`
+	Exportacion *ExportacionData `json:"exportacion"`
+
+	CargosDescuentos []CargoDescuentoData `json:"cargos_descuentos"`
 }
 
+// TipoOperacionVentaInterna es el tipo de operación por defecto (catálogo 51)
+const TipoOperacionVentaInterna = "0101"
+
 // UBLInvoiceWithExtensions estructura para la factura con extensiones
 type UBLInvoiceWithExtensions struct {
 	XMLName    xml.Name      `xml:"urn:oasis:names:specification:ubl:schema:xsd:Invoice-2 Invoice"`
@@ -87,113 +123,263 @@ type ExtensionContent struct {
 }
 
 // ConvertirAUBL convierte los datos de la factura a formato UBL XML
-func ConvertirAUBL(request *FacturaRequest) (string, error) {
+func ConvertirAUBL(ctx context.Context, request *FacturaRequest) (xmlContent string, err error) {
 	if request == nil {
 		return "", fmt.Errorf("request cannot be nil")
 	}
+	ctx, span := startConversion(ctx, "ConvertirAUBL", request.Emisor, request.Comprobante.TipoComprobante, request.Comprobante)
+	defer func() { tracing.End(span, err) }()
 
 	// Validar datos requeridos
 	if err := validateRequest(request); err != nil {
 		return "", err
 	}
 
-	// Convertir valores string a float64
-	totalGravado, err := strconv.ParseFloat(request.Comprobante.TotalGravado, 64)
+	// Construir líneas de detalle con sus cargos y descuentos
+	lines, subtotales, err := buildInvoiceLines(request)
 	if err != nil {
-		return "", fmt.Errorf("total gravado inválido: %v", err)
+		return "", err
 	}
-	totalIGV, err := strconv.ParseFloat(request.Comprobante.TotalIGV, 64)
+
+	// Calcular totales, aplicando cargos y descuentos globales y deduciendo los anticipos
+	anticipos, err := buildAnticipos(request)
 	if err != nil {
-		return "", fmt.Errorf("total IGV inválido: %v", err)
+		return "", err
 	}
-	total, err := strconv.ParseFloat(request.Comprobante.Total, 64)
+	totals, err := calcularTotales(request, subtotales, anticipos)
 	if err != nil {
-		return "", fmt.Errorf("total inválido: %v", err)
+		return "", err
+	}
+
+	tipoOperacion, err := resolveTipoOperacion(request)
+	if err != nil {
+		return "", err
+	}
+	exportacion, err := buildExportacion(request, tipoOperacion)
+	if err != nil {
+		return "", err
+	}
+	exchangeRate, err := resolveTipoCambio(request.Comprobante)
+	if err != nil {
+		return "", err
+	}
+	leyendas, err := buildLeyendas(totals.importe, request.Comprobante.Moneda, hasOperacionGratuita(request.Detalle))
+	if err != nil {
+		return "", err
 	}
 
 	// Construir estructura UBL base
 	invoice := &ubl.Invoice{
-		UBLVersionID:         "2.1",
-		CustomizationID:      "2.0",
-		ID:                   fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
-		IssueDate:            request.Comprobante.FechaEmision,
-		IssueTime:            request.Comprobante.HoraEmision,
-		InvoiceTypeCode:      request.Comprobante.TipoComprobante,
+		UBLVersionID:    "2.1",
+		CustomizationID: "2.0",
+		ID:              fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
+		IssueDate:       request.Comprobante.FechaEmision,
+		IssueTime:       request.Comprobante.HoraEmision,
+		InvoiceTypeCode: ubl.InvoiceTypeCode{
+			Value:  request.Comprobante.TipoComprobante,
+			ListID: tipoOperacion,
+		},
+		Notes:                leyendas,
 		DocumentCurrencyCode: request.Comprobante.Moneda,
 
-		AccountingSupplierParty: ubl.SupplierParty{
-			CustomerAssignedAccountID: request.Emisor.RUC,
-			Party: ubl.Party{
-				PartyName: []ubl.PartyName{{Name: request.Emisor.RazonSocial}},
-				PartyLegalEntity: []ubl.PartyLegalEntity{{
-					RegistrationName: request.Emisor.RazonSocial,
-					CompanyID:        request.Emisor.RUC,
-				}},
-			},
-		},
+		AccountingSupplierParty: buildSupplierParty(request.Emisor),
 
-		AccountingCustomerParty: ubl.CustomerParty{
-			CustomerAssignedAccountID: request.Receptor.RUC,
-			Party: ubl.Party{
-				PartyLegalEntity: []ubl.PartyLegalEntity{{
-					RegistrationName: request.Receptor.RazonSocial,
-					CompanyID:        request.Receptor.RUC,
-				}},
-			},
-		},
+		AccountingCustomerParty: buildCustomerParty(request.Receptor),
 
-		TaxTotal: []ubl.TaxTotal{{
-			TaxAmount: ubl.MonetaryAmount{
-				Value:      totalIGV,
-				CurrencyID: request.Comprobante.Moneda,
-			},
-			TaxSubtotal: []ubl.TaxSubtotal{{
-				TaxableAmount: ubl.MonetaryAmount{
-					Value:      totalGravado,
-					CurrencyID: request.Comprobante.Moneda,
-				},
-				TaxAmount: ubl.MonetaryAmount{
-					Value:      totalIGV,
-					CurrencyID: request.Comprobante.Moneda,
-				},
-				TaxCategory: ubl.TaxCategory{
-					ID:      "S",
-					Percent: 18.0,
-					TaxScheme: ubl.TaxScheme{
-						ID:          "1000",
-						Name:        "IGV",
-						TaxTypeCode: "VAT",
-					},
-				},
-			}},
-		}},
+		PaymentExchangeRate: exchangeRate,
+
+		TaxTotal: buildTaxTotal(totals, request.Comprobante.Moneda),
 
 		LegalMonetaryTotal: ubl.MonetaryTotal{
 			LineExtensionAmount: ubl.MonetaryAmount{
-				Value:      totalGravado,
+				Value:      totals.valorVenta,
 				CurrencyID: request.Comprobante.Moneda,
 			},
 			TaxInclusiveAmount: ubl.MonetaryAmount{
-				Value:      total,
+				Value:      totals.precioVenta,
 				CurrencyID: request.Comprobante.Moneda,
 			},
 			PayableAmount: ubl.MonetaryAmount{
-				Value:      total,
+				Value:      totals.importe,
 				CurrencyID: request.Comprobante.Moneda,
 			},
 		},
 	}
 
+	// Agregar domicilio del comprador, envío e Incoterm de la exportación
+	if exportacion != nil {
+		invoice.AccountingCustomerParty.Party.PartyLegalEntity[0].RegistrationAddress = exportacion.address
+		invoice.Delivery = exportacion.delivery
+		invoice.DeliveryTerms = exportacion.deliveryTerms
+	}
+
+	// Agregar cargos y descuentos globales
+	if len(totals.allowanceCharges) > 0 {
+		invoice.AllowanceCharges = append(invoice.AllowanceCharges, totals.allowanceCharges...)
+	}
+	if totals.descuentos > 0 {
+		invoice.LegalMonetaryTotal.AllowanceTotalAmount = &ubl.MonetaryAmount{
+			Value:      totals.descuentos,
+			CurrencyID: request.Comprobante.Moneda,
+		}
+	}
+	if totals.cargos > 0 {
+		invoice.LegalMonetaryTotal.ChargeTotalAmount = &ubl.MonetaryAmount{
+			Value:      totals.cargos,
+			CurrencyID: request.Comprobante.Moneda,
+		}
+	}
+
+	// Agregar anticipos deducidos
+	if anticipos != nil {
+		invoice.AdditionalDocumentReferences = append(invoice.AdditionalDocumentReferences, anticipos.references...)
+		invoice.PrepaidPayments = anticipos.payments
+		invoice.AllowanceCharges = append(invoice.AllowanceCharges, anticipos.allowanceCharge(totals, request.Comprobante.Moneda))
+		invoice.LegalMonetaryTotal.PrepaidAmount = &ubl.MonetaryAmount{
+			Value:      totals.anticipos,
+			CurrencyID: request.Comprobante.Moneda,
+		}
+	}
+
+	// Agregar percepción cobrada en la venta
+	percepcion, err := buildPercepcion(request, totals)
+	if err != nil {
+		return "", err
+	}
+	if percepcion != nil {
+		invoice.Notes = append(invoice.Notes, percepcion.note)
+		invoice.AllowanceCharges = append(invoice.AllowanceCharges, percepcion.allowanceCharge)
+	}
+
+	// Agregar detracción (SPOT)
+	detraccion, err := buildDetraccion(request, totals.importe, exchangeRate)
+	if err != nil {
+		return "", err
+	}
+	pendiente := totals.importe
+	if detraccion != nil {
+		invoice.Notes = append(invoice.Notes, detraccion.note)
+		invoice.PaymentMeans = append(invoice.PaymentMeans, detraccion.paymentMeans)
+		invoice.PaymentTerms = append(invoice.PaymentTerms, detraccion.paymentTerms)
+		pendiente = round2(pendiente - detraccion.montoMoneda)
+	}
+
+	// Agregar forma de pago y cuotas
+	paymentTerms, err := buildPaymentTerms(request, totals.importe, pendiente)
+	if err != nil {
+		return "", err
+	}
+	invoice.PaymentTerms = append(invoice.PaymentTerms, paymentTerms...)
+
 	// Agregar líneas de detalle
-	invoice.InvoiceLines = make([]ubl.InvoiceLine, len(request.Detalle))
+	invoice.InvoiceLines = lines
+
+	// Serializar sin firma
+	xmlBytes, err := xml.MarshalIndent(invoice, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error serializando XML: %v", err)
+	}
+
+	// Cargar certificado
+	certInfo, err := signature.LoadCertificate()
+	if err != nil {
+		return "", fmt.Errorf("error cargando certificado: %v", err)
+	}
+
+	// Firmar XML
+	signatureXML, err := signature.SignXMLAsElement(ctx, string(xmlBytes), certInfo)
+	if err != nil {
+		return "", fmt.Errorf("error firmando XML: %v", err)
+	}
+
+	// Crear factura con extensiones y firma
+	wrapped := UBLInvoiceWithExtensions{
+		Extensions: UBLExtensions{
+			Extension: []UBLExtension{{
+				ExtensionContent: ExtensionContent{
+					XML: signatureXML,
+				},
+			}},
+		},
+		Invoice: invoice,
+	}
+
+	// Serializar XML final
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(wrapped); err != nil {
+		return "", fmt.Errorf("error codificando XML final: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// startConversion inicia el span de la conversión de un comprobante
+func startConversion(ctx context.Context, nombre string, emisor EmisorData, tipo string, comprobante ComprobanteData) (context.Context, trace.Span) {
+	documento := fmt.Sprintf("%s-%s-%s-%s", emisor.RUC, tipo, comprobante.Serie, comprobante.Numero)
+	return tracing.Start(ctx, nombre, tracing.AttrDocumento.String(documento), tracing.AttrTipo.String(tipo))
+}
+
+// buildInvoiceLines construye las líneas de detalle de la factura y acumula
+// sus importes por tributo según el tipo de afectación del IGV. Cuando un
+// ítem tiene cargos o descuentos que afectan su base, el valor de venta y el
+// IGV del ítem se calculan (o validan, si vienen en la solicitud) a partir de
+// la cantidad y el valor unitario.
+func buildInvoiceLines(request *FacturaRequest) ([]ubl.InvoiceLine, []subtotalTributo, error) {
+	lines := make([]ubl.InvoiceLine, len(request.Detalle))
+	var subtotales []subtotalTributo
+	var sumaValorVenta float64
+	conCargosDescuentos := false
 	for i, item := range request.Detalle {
+		tipoAfectacion := item.TipoAfectacionIGV
+		if tipoAfectacion == "" {
+			tipoAfectacion = "10"
+		}
+		afectacion, ok := catalog.GetAfectacionIGV(tipoAfectacion)
+		if !ok {
+			return nil, nil, fmt.Errorf("ítem %d: tipo de afectación del IGV inválido: %s", item.Item, tipoAfectacion)
+		}
+
 		cantidad, _ := strconv.ParseFloat(item.Cantidad, 64)
 		valorUnitario, _ := strconv.ParseFloat(item.ValorUnitario, 64)
 		totalBase, _ := strconv.ParseFloat(item.TotalBase, 64)
 		igv, _ := strconv.ParseFloat(item.IGV, 64)
 		porcentajeIGV, _ := strconv.ParseFloat(item.PorcentajeIGV, 64)
 
-		invoice.InvoiceLines[i] = ubl.InvoiceLine{
+		ublItem, err := buildItem(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		if tiposOperacionConCodigoProducto[request.Comprobante.TipoOperacion] && item.CodigoProductoSunat == "" {
+			return nil, nil, fmt.Errorf("ítem %d: el código de producto SUNAT es requerido para el tipo de operación %s", item.Item, request.Comprobante.TipoOperacion)
+		}
+
+		allowances, ajuste, err := buildLineAllowanceCharges(item, round2(cantidad*valorUnitario), request.Comprobante.Moneda)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(allowances) > 0 {
+			conCargosDescuentos = true
+			calculado := round2(cantidad*valorUnitario + ajuste)
+			if item.TotalBase == "" {
+				totalBase = calculado
+			} else if math.Abs(totalBase-calculado) > 0.01 {
+				return nil, nil, fmt.Errorf("ítem %d: el total base (%.2f) no coincide con el calculado (%.2f)", item.Item, totalBase, calculado)
+			}
+			if item.IGV == "" {
+				igv = round2(totalBase * porcentajeIGV / 100)
+			}
+		}
+		if afectacion.Tributo == catalog.TributoIGV {
+			sumaValorVenta += totalBase
+		} else if afectacion.Onerosa && igv != 0 {
+			return nil, nil, fmt.Errorf("ítem %d: una operación %s no puede tener IGV", item.Item, afectacion.Tributo.Nombre)
+		}
+		subtotales = acumularSubtotal(subtotales, afectacion, totalBase, igv)
+
+		lines[i] = ubl.InvoiceLine{
 			ID: strconv.Itoa(item.Item),
 			InvoicedQuantity: ubl.Quantity{
 				Value:    cantidad,
@@ -203,6 +389,7 @@ func ConvertirAUBL(request *FacturaRequest) (string, error) {
 				Value:      totalBase,
 				CurrencyID: request.Comprobante.Moneda,
 			},
+			AllowanceCharges: allowances,
 			TaxTotal: []ubl.TaxTotal{{
 				TaxAmount: ubl.MonetaryAmount{
 					Value:      igv,
@@ -218,20 +405,14 @@ func ConvertirAUBL(request *FacturaRequest) (string, error) {
 						CurrencyID: request.Comprobante.Moneda,
 					},
 					TaxCategory: ubl.TaxCategory{
-						ID:                     "S",
+						ID:                     afectacion.Categoria,
 						Percent:                porcentajeIGV,
-						TaxExemptionReasonCode: item.TipoAfectacionIGV,
-						TaxScheme: ubl.TaxScheme{
-							ID:          "1000",
-							Name:        "IGV",
-							TaxTypeCode: "VAT",
-						},
+						TaxExemptionReasonCode: tipoAfectacion,
+						TaxScheme:              taxScheme(afectacion.Tributo),
 					},
 				}},
 			}},
-			Item: ubl.Item{
-				Description: item.Descripcion,
-			},
+			Item: ublItem,
 			Price: ubl.Price{
 				PriceAmount: ubl.MonetaryAmount{
 					Value:      valorUnitario,
@@ -241,60 +422,121 @@ func ConvertirAUBL(request *FacturaRequest) (string, error) {
 		}
 	}
 
-	// Serializar sin firma
-	xmlBytes, err := xml.MarshalIndent(invoice, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("error serializando XML: %v", err)
+	// El total gravado debe corresponder a los valores de venta ya descontados
+	if conCargosDescuentos {
+		totalGravado, _ := strconv.ParseFloat(request.Comprobante.TotalGravado, 64)
+		if math.Abs(totalGravado-sumaValorVenta) > 0.01 {
+			return nil, nil, fmt.Errorf("el total gravado (%.2f) no coincide con la suma de los valores de venta de los ítems (%.2f)", totalGravado, sumaValorVenta)
+		}
 	}
 
-	// Cargar certificado
-	certInfo, err := signature.LoadCertificate()
-	if err != nil {
-		return "", fmt.Errorf("error cargando certificado: %v", err)
-	}
+	return lines, subtotales, nil
+}
 
-	// Firmar XML
-	signatureXML, err := signature.SignXMLAsElement(string(xmlBytes), certInfo)
-	if err != nil {
-		return "", fmt.Errorf("error firmando XML: %v", err)
-	}
+// resolveTipoOperacion determina el tipo de operación (catálogo 51) de la factura
+func resolveTipoOperacion(request *FacturaRequest) (string, error) {
+	tipo := request.Comprobante.TipoOperacion
 
-	// Crear factura con extensiones y firma
-	wrapped := UBLInvoiceWithExtensions{
-		Extensions: UBLExtensions{
-			Extension: []UBLExtension{{
-				ExtensionContent: ExtensionContent{
-					XML: signatureXML,
-				},
-			}},
-		},
-		Invoice: invoice,
-	}
+	switch {
+	case request.Exportacion != nil:
+		if request.Detraccion != nil || request.Percepcion != nil {
+			return "", fmt.Errorf("una exportación no puede estar sujeta a detracción ni percepción")
+		}
+		if tipo == "" {
+			return TipoOperacionExportacionBienes, nil
+		}
+		if !isTipoOperacionExportacion(tipo) {
+			return "", fmt.Errorf("el tipo de operación %s no corresponde a una exportación", tipo)
+		}
+		return tipo, nil
 
-	// Serializar XML final
-	var buf bytes.Buffer
-	buf.WriteString(xml.Header)
-	encoder := xml.NewEncoder(&buf)
-	encoder.Indent("", "  ")
-	if err := encoder.Encode(wrapped); err != nil {
-		return "", fmt.Errorf("error codificando XML final: %v", err)
+	case isTipoOperacionExportacion(tipo):
+		return "", fmt.Errorf("el tipo de operación %s requiere los datos de exportación", tipo)
+
+	case request.Detraccion != nil && request.Percepcion != nil:
+		return "", fmt.Errorf("una operación no puede estar sujeta a detracción y percepción a la vez")
+
+	case request.Detraccion != nil:
+		switch tipo {
+		case "":
+			return TipoOperacionDetraccion, nil
+		case "1001", "1002", "1003", "1004":
+			return tipo, nil
+		}
+		return "", fmt.Errorf("el tipo de operación %s no corresponde a una operación sujeta a detracción", tipo)
+
+	case request.Percepcion != nil:
+		if tipo != "" && tipo != TipoOperacionPercepcion {
+			return "", fmt.Errorf("el tipo de operación %s no corresponde a una operación sujeta a percepción", tipo)
+		}
+		return TipoOperacionPercepcion, nil
 	}
 
-	return buf.String(), nil
+	if tipo == "" {
+		return TipoOperacionVentaInterna, nil
+	}
+	return tipo, nil
 }
 
 func validateRequest(req *FacturaRequest) error {
 	if req.Emisor.RUC == "" || len(req.Emisor.RUC) != 11 {
 		return fmt.Errorf("RUC del emisor inválido")
 	}
-	if req.Receptor.RUC == "" || len(req.Receptor.RUC) != 11 {
-		return fmt.Errorf("RUC del receptor inválido")
+	if req.Receptor.TipoDocumento == "" {
+		req.Receptor.TipoDocumento = catalog.DocumentoRUC
+	}
+	if err := validateDocumentoIdentidad(req.Receptor.TipoDocumento, req.Receptor.RUC); err != nil {
+		return fmt.Errorf("documento del receptor inválido: %v", err)
+	}
+	// Las boletas (03) admiten cualquier documento de identidad y las
+	// exportaciones un comprador no domiciliado; las demás facturas exigen RUC
+	boleta := req.Comprobante.TipoComprobante == "03"
+	exportacion := req.Exportacion != nil || isTipoOperacionExportacion(req.Comprobante.TipoOperacion)
+	if req.Receptor.TipoDocumento != catalog.DocumentoRUC && !boleta && !exportacion {
+		return fmt.Errorf("el receptor de una factura debe identificarse con RUC")
 	}
 	if req.Comprobante.Serie == "" || req.Comprobante.Numero == "" {
 		return fmt.Errorf("serie y número son requeridos")
 	}
+	if err := validateMoneda(req.Comprobante.Moneda); err != nil {
+		return err
+	}
+	if err := validateParties(req.Emisor, req.Receptor); err != nil {
+		return err
+	}
 	if len(req.Detalle) == 0 {
 		return fmt.Errorf("el detalle no puede estar vacío")
 	}
 	return nil
 }
+
+// validateDocumentoIdentidad valida el número de un documento de identidad según su tipo (catálogo 06)
+func validateDocumentoIdentidad(tipo, numero string) error {
+	if _, ok := catalog.GetDocumentoIdentidad(tipo); !ok {
+		return fmt.Errorf("tipo de documento %s desconocido", tipo)
+	}
+	switch tipo {
+	case catalog.DocumentoRUC:
+		if len(numero) != 11 || !isDigits(numero) {
+			return fmt.Errorf("el RUC debe tener 11 dígitos")
+		}
+	case catalog.DocumentoDNI:
+		if len(numero) != 8 || !isDigits(numero) {
+			return fmt.Errorf("el DNI debe tener 8 dígitos")
+		}
+	default:
+		if numero == "" || len(numero) > 15 {
+			return fmt.Errorf("el número de documento debe tener entre 1 y 15 caracteres")
+		}
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
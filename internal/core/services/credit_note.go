@@ -2,11 +2,13 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"strconv"
 
 	"ubl-converter/internal/pkg/signature"
+	"ubl-converter/internal/pkg/tracing"
 	"ubl-converter/internal/pkg/ubl"
 )
 
@@ -34,10 +36,18 @@ type UBLCreditNoteWithExtensions struct {
 }
 
 // ConvertToUBLCreditNote convierte una solicitud a una nota de crédito UBL firmada
-func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
+func ConvertToUBLCreditNote(ctx context.Context, request *CreditNoteRequest) (xmlContent string, err error) {
 	if request == nil {
 		return "", fmt.Errorf("request cannot be nil")
 	}
+	ctx, span := startConversion(ctx, "ConvertToUBLCreditNote", request.Emisor, "07", request.Comprobante)
+	defer func() { tracing.End(span, err) }()
+	if err := validateMoneda(request.Comprobante.Moneda); err != nil {
+		return "", err
+	}
+	if err := validateParties(request.Emisor, request.Receptor); err != nil {
+		return "", err
+	}
 
 	totalIGV, err := strconv.ParseFloat(request.Comprobante.TotalIGV, 64)
 	if err != nil {
@@ -47,6 +57,10 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("total inválido: %v", err)
 	}
+	leyendas, err := buildLeyendas(total, request.Comprobante.Moneda, hasOperacionGratuita(request.Detalle))
+	if err != nil {
+		return "", err
+	}
 
 	creditNote := ubl.CreditNote{
 		UBLVersionID:         "2.1",
@@ -54,6 +68,7 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 		ID:                   fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
 		IssueDate:            request.Comprobante.FechaEmision,
 		IssueTime:            request.Comprobante.HoraEmision,
+		Notes:                leyendas,
 		DocumentCurrencyCode: request.Comprobante.Moneda,
 		DiscrepancyResponse: []ubl.DiscrepancyResponse{{
 			ReferenceID:  request.ComprobanteRef,
@@ -65,25 +80,8 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 				ID: request.ComprobanteRef,
 			},
 		}},
-		AccountingSupplierParty: ubl.SupplierParty{
-			CustomerAssignedAccountID: request.Emisor.RUC,
-			Party: ubl.Party{
-				PartyName: []ubl.PartyName{{Name: request.Emisor.RazonSocial}},
-				PartyLegalEntity: []ubl.PartyLegalEntity{{
-					RegistrationName: request.Emisor.RazonSocial,
-					CompanyID:        request.Emisor.RUC,
-				}},
-			},
-		},
-		AccountingCustomerParty: ubl.CustomerParty{
-			CustomerAssignedAccountID: request.Receptor.RUC,
-			Party: ubl.Party{
-				PartyLegalEntity: []ubl.PartyLegalEntity{{
-					RegistrationName: request.Receptor.RazonSocial,
-					CompanyID:        request.Receptor.RUC,
-				}},
-			},
-		},
+		AccountingSupplierParty: buildSupplierParty(request.Emisor),
+		AccountingCustomerParty: buildCustomerParty(request.Receptor),
 		TaxTotal: []ubl.TaxTotal{{
 			TaxAmount: ubl.MonetaryAmount{
 				Value:      totalIGV,
@@ -100,7 +98,7 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 			ID: "signatureKG",
 			SignatoryParty: ubl.SignatoryParty{
 				PartyIdentification: []ubl.PartyIdentification{{
-					ID: request.Emisor.RUC,
+					ID: ubl.Identifier{Value: request.Emisor.RUC},
 				}},
 				PartyName: []ubl.PartyName{{
 					Name: request.Emisor.RazonSocial,
@@ -121,6 +119,10 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 		valorUnitario, _ := strconv.ParseFloat(item.ValorUnitario, 64)
 		totalBase, _ := strconv.ParseFloat(item.TotalBase, 64)
 		igv, _ := strconv.ParseFloat(item.IGV, 64)
+		ublItem, err := buildItem(item)
+		if err != nil {
+			return "", err
+		}
 
 		creditNote.CreditNoteLines[i] = ubl.CreditNoteLine{
 			ID: strconv.Itoa(item.Item),
@@ -138,9 +140,7 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 					CurrencyID: request.Comprobante.Moneda,
 				},
 			}},
-			Item: ubl.Item{
-				Description: item.Descripcion,
-			},
+			Item: ublItem,
 			Price: ubl.Price{
 				PriceAmount: ubl.MonetaryAmount{
 					Value:      valorUnitario,
@@ -163,7 +163,7 @@ func ConvertToUBLCreditNote(request *CreditNoteRequest) (string, error) {
 	}
 
 	// Firmar el XML
-	signedXML, err := signature.SignXMLAsElement(string(xmlBytes), certInfo)
+	signedXML, err := signature.SignXMLAsElement(ctx, string(xmlBytes), certInfo)
 	if err != nil {
 		return "", fmt.Errorf("error firmando XML: %v", err)
 	}
@@ -0,0 +1,420 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/tracing"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// DespatchAdviceNamespace es el espacio de nombres de la guía de remisión electrónica
+const DespatchAdviceNamespace = "urn:oasis:names:specification:ubl:schema:xsd:DespatchAdvice-2"
+
+// DespatchAdviceRequest estructura para la solicitud de guía de remisión
+// electrónica (09 remitente, 31 transportista)
+type DespatchAdviceRequest struct {
+	Emisor       EmisorData      `json:"emisor"`
+	Destinatario ReceptorData    `json:"destinatario"`
+	Comprobante  ComprobanteData `json:"comprobante"`
+	Traslado     TrasladoData    `json:"traslado"`
+
+	// Transportista es la empresa de transporte público (guía remitente, modalidad 01)
+	Transportista *TransportistaData `json:"transportista"`
+	// Remitente es el remitente de los bienes (guía transportista)
+	Remitente *ReceptorData `json:"remitente"`
+	// RegistroMTC es el número de registro MTC del emisor (guía transportista)
+	RegistroMTC string `json:"registro_mtc"`
+
+	Conductores            []ConductorData     `json:"conductores"`
+	Vehiculos              []VehiculoData      `json:"vehiculos"`
+	DocumentosRelacionados []GuiaDocumentoData `json:"documentos_relacionados"`
+	Observaciones          string              `json:"observaciones"`
+	Detalle                []GuiaItemData      `json:"detalle"`
+}
+
+// TrasladoData estructura para los datos del traslado
+type TrasladoData struct {
+	Motivo       string        `json:"motivo"`
+	Descripcion  string        `json:"descripcion"`
+	Modalidad    string        `json:"modalidad"`
+	FechaInicio  string        `json:"fecha_inicio"`
+	PesoBruto    string        `json:"peso_bruto"`
+	UnidadPeso   string        `json:"unidad_peso"`
+	PuntoPartida DireccionData `json:"punto_partida"`
+	PuntoLlegada DireccionData `json:"punto_llegada"`
+}
+
+// DireccionData estructura para un punto de partida o de llegada
+type DireccionData struct {
+	Ubigeo                string `json:"ubigeo"`
+	Direccion             string `json:"direccion"`
+	CodigoEstablecimiento string `json:"codigo_establecimiento"`
+}
+
+// TransportistaData estructura para la empresa de transporte
+type TransportistaData struct {
+	RUC         string `json:"ruc"`
+	RazonSocial string `json:"razon_social"`
+	RegistroMTC string `json:"registro_mtc"`
+}
+
+// ConductorData estructura para un conductor; el primero es el conductor principal
+type ConductorData struct {
+	TipoDocumento   string `json:"tipo_documento"`
+	NumeroDocumento string `json:"numero_documento"`
+	Nombres         string `json:"nombres"`
+	Apellidos       string `json:"apellidos"`
+	Licencia        string `json:"licencia"`
+}
+
+// VehiculoData estructura para un vehículo; el primero es el vehículo principal
+type VehiculoData struct {
+	Placa string `json:"placa"`
+}
+
+// GuiaDocumentoData estructura para un documento relacionado con el traslado
+type GuiaDocumentoData struct {
+	TipoComprobante string `json:"tipo_comprobante"`
+	Serie           string `json:"serie"`
+	Numero          string `json:"numero"`
+	RUCEmisor       string `json:"ruc_emisor"`
+}
+
+// GuiaItemData estructura para un bien trasladado
+type GuiaItemData struct {
+	Item                int    `json:"item"`
+	Codigo              string `json:"codigo"`
+	Descripcion         string `json:"descripcion"`
+	Cantidad            string `json:"cantidad"`
+	UnidadMedida        string `json:"unidad_medida"`
+	CodigoProductoSunat string `json:"codigo_producto_sunat"`
+}
+
+// UBLDespatchAdviceWithExtensions guía de remisión con firma y extensiones
+type UBLDespatchAdviceWithExtensions struct {
+	XMLName    xml.Name            `xml:"DespatchAdvice"`
+	Xmlns      string              `xml:"xmlns,attr"`
+	XmlnsExt   string              `xml:"xmlns:ext,attr"`
+	XmlnsCac   string              `xml:"xmlns:cac,attr"`
+	XmlnsCbc   string              `xml:"xmlns:cbc,attr"`
+	Extensions CustomUBLExtensions `xml:"ext:UBLExtensions"`
+	ubl.DespatchAdvice
+}
+
+// ConvertToUBLDespatchAdvice convierte una solicitud a una guía de remisión electrónica firmada
+func ConvertToUBLDespatchAdvice(ctx context.Context, request *DespatchAdviceRequest) (xmlContent string, err error) {
+	if request == nil {
+		return "", fmt.Errorf("request cannot be nil")
+	}
+	ctx, span := startConversion(ctx, "ConvertToUBLDespatchAdvice", request.Emisor, request.Comprobante.TipoComprobante, request.Comprobante)
+	defer func() { tracing.End(span, err) }()
+	if err := validateDespatchAdvice(request); err != nil {
+		return "", err
+	}
+
+	guia := ubl.DespatchAdvice{
+		UBLVersionID:           "2.1",
+		CustomizationID:        "2.0",
+		ID:                     fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
+		IssueDate:              request.Comprobante.FechaEmision,
+		IssueTime:              request.Comprobante.HoraEmision,
+		DespatchAdviceTypeCode: request.Comprobante.TipoComprobante,
+		Signature:              buildSignature(request.Emisor),
+		DespatchSupplierParty: ubl.DespatchParty{
+			Party: buildAgentParty(request.Emisor.RUC, request.Emisor.RazonSocial),
+		},
+		DeliveryCustomerParty: ubl.DespatchParty{
+			Party: buildGuiaParty(request.Destinatario),
+		},
+	}
+	if request.Observaciones != "" {
+		guia.Notes = []ubl.Note{{Value: request.Observaciones}}
+	}
+	if request.RegistroMTC != "" {
+		guia.DespatchSupplierParty.Party.PartyLegalEntity[0].CompanyID = request.RegistroMTC
+	}
+
+	for _, doc := range request.DocumentosRelacionados {
+		ref := ubl.DocumentReference{
+			ID:               doc.Serie + "-" + doc.Numero,
+			DocumentTypeCode: doc.TipoComprobante,
+		}
+		if doc.RUCEmisor != "" {
+			ref.IssuerParty = &ubl.Party{
+				PartyIdentification: []ubl.PartyIdentification{{
+					ID: ubl.Identifier{Value: doc.RUCEmisor, SchemeID: catalog.DocumentoRUC},
+				}},
+			}
+		}
+		guia.AdditionalDocumentReferences = append(guia.AdditionalDocumentReferences, ref)
+	}
+
+	shipment, err := buildDespatchShipment(request)
+	if err != nil {
+		return "", err
+	}
+	guia.Shipment = *shipment
+
+	for _, item := range request.Detalle {
+		cantidad, _ := strconv.ParseFloat(item.Cantidad, 64)
+		ublItem, err := buildItem(DetalleItem{
+			Item:                item.Item,
+			Codigo:              item.Codigo,
+			Descripcion:         item.Descripcion,
+			CodigoProductoSunat: item.CodigoProductoSunat,
+		})
+		if err != nil {
+			return "", err
+		}
+		guia.DespatchLines = append(guia.DespatchLines, ubl.DespatchLine{
+			ID:                 strconv.Itoa(item.Item),
+			DeliveredQuantity:  ubl.Quantity{Value: cantidad, UnitCode: item.UnidadMedida},
+			OrderLineReference: ubl.OrderLineReference{LineID: strconv.Itoa(item.Item)},
+			Item:               ublItem,
+		})
+	}
+
+	// Firmar el XML
+	signedXML, err := signDocument(ctx, guia)
+	if err != nil {
+		return "", err
+	}
+
+	// Envolver en UBL con extensiones
+	wrapped := UBLDespatchAdviceWithExtensions{
+		Xmlns:    DespatchAdviceNamespace,
+		XmlnsExt: extensionComponentNS,
+		XmlnsCac: aggregateComponentNS,
+		XmlnsCbc: basicComponentNS,
+		Extensions: CustomUBLExtensions{
+			Extension: []CustomUBLExtension{{
+				ExtensionContent: CustomExtensionContent{
+					XML: signedXML,
+				},
+			}},
+		},
+		DespatchAdvice: guia,
+	}
+
+	// Serializar XML final
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(wrapped); err != nil {
+		return "", fmt.Errorf("error codificando XML final: %v", err)
+	}
+
+	return buf.String(), nil
+}
+
+// buildDespatchShipment construye los datos del traslado: motivo, peso,
+// modalidad con transportista o conductores, direcciones y vehículos
+func buildDespatchShipment(request *DespatchAdviceRequest) (*ubl.DespatchShipment, error) {
+	traslado := request.Traslado
+	peso, _ := strconv.ParseFloat(traslado.PesoBruto, 64)
+	unidad := traslado.UnidadPeso
+	if unidad == "" {
+		unidad = "KGM"
+	}
+
+	shipment := &ubl.DespatchShipment{
+		ID:                 "SUNAT_Envio",
+		GrossWeightMeasure: ubl.Measure{Value: peso, UnitCode: unidad},
+		Delivery: ubl.DespatchDelivery{
+			DeliveryAddress: buildGuiaAddress(traslado.PuntoLlegada),
+			Despatch: ubl.Despatch{
+				DespatchAddress: buildGuiaAddress(traslado.PuntoPartida),
+			},
+		},
+	}
+
+	stage := ubl.DespatchShipmentStage{
+		TransitPeriod: ubl.TransitPeriod{StartDate: traslado.FechaInicio},
+	}
+
+	if request.Comprobante.TipoComprobante == catalog.GuiaRemitente {
+		descripcion := traslado.Descripcion
+		if descripcion == "" {
+			descripcion, _ = catalog.GetMotivoTraslado(traslado.Motivo)
+		}
+		shipment.HandlingCode = traslado.Motivo
+		shipment.HandlingInstructions = descripcion
+		stage.TransportModeCode = traslado.Modalidad
+
+		if traslado.Modalidad == catalog.TransportePublico {
+			carrier := buildAgentParty(request.Transportista.RUC, request.Transportista.RazonSocial)
+			carrier.PartyName = nil
+			carrier.PartyLegalEntity[0].CompanyID = request.Transportista.RegistroMTC
+			stage.CarrierParty = &carrier
+		}
+	} else {
+		// En la guía transportista el remitente se informa en el punto de partida
+		remitente := buildGuiaParty(*request.Remitente)
+		shipment.Delivery.Despatch.DespatchParty = &remitente
+	}
+
+	for i, c := range request.Conductores {
+		cargo := "Principal"
+		if i > 0 {
+			cargo = "Secundario"
+		}
+		stage.DriverPersons = append(stage.DriverPersons, ubl.DriverPerson{
+			ID:                        ubl.Identifier{Value: c.NumeroDocumento, SchemeID: c.TipoDocumento},
+			FirstName:                 c.Nombres,
+			FamilyName:                c.Apellidos,
+			JobTitle:                  cargo,
+			IdentityDocumentReference: ubl.IdentityDocumentReference{ID: c.Licencia},
+		})
+	}
+	shipment.ShipmentStages = []ubl.DespatchShipmentStage{stage}
+
+	if len(request.Vehiculos) > 0 {
+		unit := ubl.TransportHandlingUnit{}
+		for _, v := range request.Vehiculos {
+			unit.TransportEquipment = append(unit.TransportEquipment, ubl.TransportEquipment{
+				ID: strings.ToUpper(strings.ReplaceAll(v.Placa, "-", "")),
+			})
+		}
+		shipment.TransportHandlingUnits = []ubl.TransportHandlingUnit{unit}
+	}
+
+	return shipment, nil
+}
+
+// buildGuiaParty construye el destinatario o el remitente de una guía
+func buildGuiaParty(p ReceptorData) ubl.Party {
+	tipoDocumento := p.TipoDocumento
+	if tipoDocumento == "" {
+		tipoDocumento = catalog.DocumentoRUC
+	}
+	return ubl.Party{
+		PartyIdentification: []ubl.PartyIdentification{{
+			ID: ubl.Identifier{Value: p.RUC, SchemeID: tipoDocumento},
+		}},
+		PartyLegalEntity: []ubl.PartyLegalEntity{{
+			RegistrationName: p.RazonSocial,
+		}},
+	}
+}
+
+// buildGuiaAddress construye un punto de partida o de llegada
+func buildGuiaAddress(d DireccionData) ubl.Address {
+	return ubl.Address{
+		ID:              d.Ubigeo,
+		AddressTypeCode: d.CodigoEstablecimiento,
+		AddressLine:     &ubl.AddressLine{Line: d.Direccion},
+	}
+}
+
+func validateDespatchAdvice(req *DespatchAdviceRequest) error {
+	if len(req.Emisor.RUC) != 11 || !isDigits(req.Emisor.RUC) {
+		return fmt.Errorf("RUC del emisor inválido")
+	}
+	if req.Destinatario.TipoDocumento == "" {
+		req.Destinatario.TipoDocumento = catalog.DocumentoRUC
+	}
+	if err := validateDocumentoIdentidad(req.Destinatario.TipoDocumento, req.Destinatario.RUC); err != nil {
+		return fmt.Errorf("documento del destinatario inválido: %v", err)
+	}
+	if req.Comprobante.Serie == "" || req.Comprobante.Numero == "" {
+		return fmt.Errorf("serie y número son requeridos")
+	}
+	emision, err := time.Parse("2006-01-02", req.Comprobante.FechaEmision)
+	if err != nil {
+		return fmt.Errorf("fecha de emisión inválida: %v", err)
+	}
+	inicio, err := time.Parse("2006-01-02", req.Traslado.FechaInicio)
+	if err != nil {
+		return fmt.Errorf("fecha de inicio del traslado inválida: %v", err)
+	}
+	if inicio.Before(emision) {
+		return fmt.Errorf("la fecha de inicio del traslado no puede ser anterior a la fecha de emisión")
+	}
+
+	switch req.Comprobante.TipoComprobante {
+	case catalog.GuiaRemitente:
+		if !strings.HasPrefix(req.Comprobante.Serie, "T") {
+			return fmt.Errorf("la serie de una guía remitente debe iniciar con T")
+		}
+		if _, ok := catalog.GetMotivoTraslado(req.Traslado.Motivo); !ok {
+			return fmt.Errorf("motivo de traslado inválido: %s", req.Traslado.Motivo)
+		}
+		switch req.Traslado.Modalidad {
+		case catalog.TransportePublico:
+			if req.Transportista == nil || len(req.Transportista.RUC) != 11 || req.Transportista.RazonSocial == "" {
+				return fmt.Errorf("el transportista es requerido para el transporte público")
+			}
+		case catalog.TransportePrivado:
+			if len(req.Conductores) == 0 || len(req.Vehiculos) == 0 {
+				return fmt.Errorf("el conductor y el vehículo son requeridos para el transporte privado")
+			}
+		default:
+			return fmt.Errorf("modalidad de traslado inválida: %s", req.Traslado.Modalidad)
+		}
+	case catalog.GuiaTransportista:
+		if !strings.HasPrefix(req.Comprobante.Serie, "V") {
+			return fmt.Errorf("la serie de una guía transportista debe iniciar con V")
+		}
+		if req.Remitente == nil || req.Remitente.RUC == "" || req.Remitente.RazonSocial == "" {
+			return fmt.Errorf("el remitente es requerido en la guía transportista")
+		}
+		if len(req.Conductores) == 0 || len(req.Vehiculos) == 0 {
+			return fmt.Errorf("el conductor y el vehículo son requeridos en la guía transportista")
+		}
+	default:
+		return fmt.Errorf("tipo de guía inválido: %s", req.Comprobante.TipoComprobante)
+	}
+
+	for i, c := range req.Conductores {
+		if err := validateDocumentoIdentidad(c.TipoDocumento, c.NumeroDocumento); err != nil {
+			return fmt.Errorf("conductor %d: %v", i+1, err)
+		}
+		if c.Nombres == "" || c.Apellidos == "" || c.Licencia == "" {
+			return fmt.Errorf("conductor %d: nombres, apellidos y licencia son requeridos", i+1)
+		}
+	}
+	for i, v := range req.Vehiculos {
+		placa := strings.ReplaceAll(v.Placa, "-", "")
+		if len(placa) < 6 || len(placa) > 8 {
+			return fmt.Errorf("vehículo %d: placa inválida: %s", i+1, v.Placa)
+		}
+	}
+
+	peso, err := strconv.ParseFloat(req.Traslado.PesoBruto, 64)
+	if err != nil || peso <= 0 {
+		return fmt.Errorf("peso bruto inválido: %s", req.Traslado.PesoBruto)
+	}
+	for _, punto := range []struct {
+		nombre string
+		d      DireccionData
+	}{{"partida", req.Traslado.PuntoPartida}, {"llegada", req.Traslado.PuntoLlegada}} {
+		if len(punto.d.Ubigeo) != 6 || !isDigits(punto.d.Ubigeo) {
+			return fmt.Errorf("ubigeo del punto de %s inválido: %s", punto.nombre, punto.d.Ubigeo)
+		}
+		if punto.d.Direccion == "" {
+			return fmt.Errorf("la dirección del punto de %s es requerida", punto.nombre)
+		}
+	}
+
+	if len(req.Detalle) == 0 {
+		return fmt.Errorf("el detalle no puede estar vacío")
+	}
+	for _, item := range req.Detalle {
+		cantidad, err := strconv.ParseFloat(item.Cantidad, 64)
+		if err != nil || cantidad <= 0 {
+			return fmt.Errorf("ítem %d: cantidad inválida: %s", item.Item, item.Cantidad)
+		}
+		if item.Descripcion == "" || item.UnidadMedida == "" {
+			return fmt.Errorf("ítem %d: descripción y unidad de medida son requeridas", item.Item)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+const (
+	// TipoOperacionDetraccion es el tipo de operación sujeta a detracción (catálogo 51)
+	TipoOperacionDetraccion = "1001"
+
+	// LeyendaDetraccion es el código de leyenda de operación sujeta a detracción (catálogo 52)
+	LeyendaDetraccion = "2006"
+)
+
+// DetraccionData estructura para los datos de detracción (SPOT) de la factura
+type DetraccionData struct {
+	Codigo       string `json:"codigo"`
+	Porcentaje   string `json:"porcentaje"`
+	Monto        string `json:"monto"`
+	NumeroCuenta string `json:"numero_cuenta"`
+	MedioPago    string `json:"medio_pago"`
+}
+
+// detraccion contiene los elementos UBL generados para una operación sujeta a detracción
+type detraccion struct {
+	monto        float64 // monto de la detracción en soles enteros
+	montoMoneda  float64 // monto de la detracción en la moneda del comprobante
+	paymentMeans ubl.PaymentMeans
+	paymentTerms ubl.PaymentTerms
+	note         ubl.Note
+}
+
+// buildDetraccion valida los datos de detracción y construye los elementos
+// cac:PaymentMeans, cac:PaymentTerms y la leyenda 2006. El porcentaje se
+// obtiene del catálogo 54 si no se indica en la solicitud. El monto se
+// redondea a soles enteros; en comprobantes en moneda extranjera se calcula
+// con el tipo de cambio y se convierte de vuelta para el monto pendiente.
+func buildDetraccion(request *FacturaRequest, total float64, exchangeRate *ubl.ExchangeRate) (*detraccion, error) {
+	data := request.Detraccion
+	if data == nil {
+		return nil, nil
+	}
+
+	bienServicio, ok := catalog.GetDetraccion(data.Codigo)
+	if !ok {
+		return nil, fmt.Errorf("código de detracción inválido: %s", data.Codigo)
+	}
+	if data.NumeroCuenta == "" {
+		return nil, fmt.Errorf("la cuenta del Banco de la Nación es requerida para la detracción")
+	}
+
+	porcentaje := bienServicio.Porcentaje
+	if data.Porcentaje != "" {
+		var err error
+		porcentaje, err = strconv.ParseFloat(data.Porcentaje, 64)
+		if err != nil || porcentaje <= 0 || porcentaje > 100 {
+			return nil, fmt.Errorf("porcentaje de detracción inválido: %s", data.Porcentaje)
+		}
+	}
+
+	// El umbral se evalúa siempre sobre el importe en soles, aunque la
+	// solicitud indique el monto de la detracción
+	totalSoles := total
+	if exchangeRate != nil {
+		totalSoles = round2(total * exchangeRate.CalculationRate)
+	}
+	if totalSoles <= catalog.MontoMinimoDetraccion {
+		return nil, fmt.Errorf("el importe de la operación no supera S/ %.2f, no corresponde detracción", catalog.MontoMinimoDetraccion)
+	}
+
+	// SUNAT exige el depósito de la detracción en soles enteros
+	monto := math.Round(totalSoles * porcentaje / 100)
+	if data.Monto != "" {
+		var err error
+		monto, err = strconv.ParseFloat(data.Monto, 64)
+		if err != nil || monto <= 0 {
+			return nil, fmt.Errorf("monto de detracción inválido: %s", data.Monto)
+		}
+		if monto != math.Trunc(monto) {
+			return nil, fmt.Errorf("el monto de detracción debe expresarse en soles enteros: %s", data.Monto)
+		}
+	}
+
+	medioPago := data.MedioPago
+	if medioPago == "" {
+		medioPago = catalog.CuentaBancoNacionMedioPago
+	}
+
+	montoMoneda := monto
+	if exchangeRate != nil {
+		montoMoneda = round2(monto / exchangeRate.CalculationRate)
+	}
+
+	return &detraccion{
+		monto:       monto,
+		montoMoneda: montoMoneda,
+		paymentMeans: ubl.PaymentMeans{
+			ID:                    "Detraccion",
+			PaymentMeansCode:      medioPago,
+			PayeeFinancialAccount: &ubl.FinancialAccount{ID: data.NumeroCuenta},
+		},
+		paymentTerms: ubl.PaymentTerms{
+			ID:             "Detraccion",
+			PaymentMeansID: bienServicio.Codigo,
+			PaymentPercent: porcentaje,
+			// El monto de la detracción siempre se expresa en soles
+			Amount: &ubl.MonetaryAmount{Value: monto, CurrencyID: "PEN"},
+		},
+		note: ubl.Note{
+			Value:            "Operación sujeta al Sistema de Pago de Obligaciones Tributarias",
+			LanguageLocaleID: LeyendaDetraccion,
+		},
+	}, nil
+}
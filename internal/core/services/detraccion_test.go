@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"ubl-converter/internal/pkg/ubl"
+)
+
+func solicitudDetraccion(monto string) *FacturaRequest {
+	return &FacturaRequest{
+		Detraccion: &DetraccionData{
+			Codigo:       "037",
+			Monto:        monto,
+			NumeroCuenta: "00-000-123456",
+		},
+	}
+}
+
+func TestBuildDetraccionUmbral(t *testing.T) {
+	tests := []struct {
+		nombre string
+		total  float64
+		tipo   float64 // tipo de cambio; cero para soles
+		sujeta bool
+	}{
+		{"soles sobre el umbral", 700.01, 0, true},
+		{"soles en el umbral", 700, 0, false},
+		{"dólares que superan S/ 700", 200, 3.75, true},
+		{"dólares que no superan S/ 700", 180, 3.75, false},
+		{"dólares que en soles apenas superan el umbral", 186.67, 3.75, true},
+	}
+	for _, tt := range tests {
+		var exchangeRate *ubl.ExchangeRate
+		if tt.tipo != 0 {
+			exchangeRate = &ubl.ExchangeRate{SourceCurrencyCode: "USD", TargetCurrencyCode: "PEN", CalculationRate: tt.tipo}
+		}
+		d, err := buildDetraccion(solicitudDetraccion(""), tt.total, exchangeRate)
+		if tt.sujeta && err != nil {
+			t.Errorf("%s: %v", tt.nombre, err)
+		}
+		if !tt.sujeta && err == nil {
+			t.Errorf("%s: detracción = %+v, se esperaba error por no superar el umbral", tt.nombre, d)
+		}
+	}
+}
+
+func TestBuildDetraccionMontos(t *testing.T) {
+	tests := []struct {
+		nombre     string
+		total      float64
+		tipo       float64
+		monto      string
+		want       float64 // monto en soles
+		wantMoneda float64 // monto en la moneda del comprobante
+	}{
+		{"redondea hacia abajo", 1234.56, 0, "", 148, 148},
+		{"redondea hacia arriba", 1237.5, 0, "", 149, 149},
+		{"monto indicado", 1234.56, 0, "150", 150, 150},
+		{"dólares", 200, 3.75, "", 90, 24},
+		{"dólares con conversión inexacta", 1000, 3.712, "", 445, 119.88},
+	}
+	for _, tt := range tests {
+		var exchangeRate *ubl.ExchangeRate
+		if tt.tipo != 0 {
+			exchangeRate = &ubl.ExchangeRate{SourceCurrencyCode: "USD", TargetCurrencyCode: "PEN", CalculationRate: tt.tipo}
+		}
+		d, err := buildDetraccion(solicitudDetraccion(tt.monto), tt.total, exchangeRate)
+		if err != nil {
+			t.Errorf("%s: %v", tt.nombre, err)
+			continue
+		}
+		if d.monto != tt.want || d.montoMoneda != tt.wantMoneda {
+			t.Errorf("%s: monto = %v, montoMoneda = %v, se esperaba %v y %v", tt.nombre, d.monto, d.montoMoneda, tt.want, tt.wantMoneda)
+		}
+		if d.paymentTerms.Amount.Value != tt.want || d.paymentTerms.Amount.CurrencyID != "PEN" {
+			t.Errorf("%s: PaymentTerms.Amount = %+v, se esperaba %v PEN", tt.nombre, *d.paymentTerms.Amount, tt.want)
+		}
+	}
+}
+
+func TestBuildDetraccionMontoNoEntero(t *testing.T) {
+	for _, monto := range []string{"148.50", "0", "-10", "abc"} {
+		if d, err := buildDetraccion(solicitudDetraccion(monto), 1234.56, nil); err == nil {
+			t.Errorf("monto %q: detracción = %+v, se esperaba error", monto, d)
+		}
+	}
+}
@@ -1,13 +1,18 @@
 package services
 
-import "sync"
+import (
+	"encoding/base64"
+	"sync"
+
+	"ubl-converter/internal/pkg/cdr"
+)
 
 // DocumentData almacena la información de un documento procesado.
 type DocumentData struct {
 	Status     string
 	XMLContent string
-	PDFURL     string
 	CDRZip     string
+	CDR        *cdr.CDR
 }
 
 var (
@@ -29,3 +34,25 @@ func GetDocument(id string) (DocumentData, bool) {
 	data, found := documentStore[id]
 	return data, found
 }
+
+// SaveDocumentStatus actualiza el estado de un documento, conservando los
+// demás datos si ya estaba almacenado.
+func SaveDocumentStatus(id, status string) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	data := documentStore[id]
+	data.Status = status
+	documentStore[id] = data
+}
+
+// SaveCDR registra el CDR de un documento, conservando los demás datos si ya
+// estaba almacenado.
+func SaveCDR(id string, constancia *cdr.CDR, zipContent []byte) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	data := documentStore[id]
+	data.Status = constancia.Estado()
+	data.CDR = constancia
+	data.CDRZip = base64.StdEncoding.EncodeToString(zipContent)
+	documentStore[id] = data
+}
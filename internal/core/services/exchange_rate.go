@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/exchange"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// rateProvider obtiene el tipo de cambio cuando la solicitud no lo indica
+var rateProvider exchange.RateProvider
+
+// SetRateProvider configura el proveedor de tipo de cambio de los comprobantes en moneda extranjera
+func SetRateProvider(p exchange.RateProvider) {
+	rateProvider = p
+}
+
+// validateMoneda valida que la moneda pertenezca al catálogo 02
+func validateMoneda(moneda string) error {
+	if moneda == "" {
+		return fmt.Errorf("la moneda es requerida")
+	}
+	if _, ok := catalog.GetMoneda(moneda); !ok {
+		return fmt.Errorf("moneda inválida: %s", moneda)
+	}
+	return nil
+}
+
+// resolveTipoCambio obtiene el tipo de cambio a soles de un comprobante en
+// moneda extranjera y construye el cac:PaymentExchangeRate. Si la solicitud
+// no indica el tipo de cambio, se usa el tipo de cambio venta publicado para
+// la fecha de emisión. Los comprobantes en soles no llevan tipo de cambio.
+func resolveTipoCambio(comprobante ComprobanteData) (*ubl.ExchangeRate, error) {
+	if comprobante.Moneda == catalog.MonedaSoles {
+		if comprobante.TipoCambio != "" {
+			return nil, fmt.Errorf("un comprobante en soles no lleva tipo de cambio")
+		}
+		return nil, nil
+	}
+
+	var tipoCambio float64
+	switch {
+	case comprobante.TipoCambio != "":
+		var err error
+		tipoCambio, err = strconv.ParseFloat(comprobante.TipoCambio, 64)
+		if err != nil || tipoCambio <= 0 {
+			return nil, fmt.Errorf("tipo de cambio inválido: %s", comprobante.TipoCambio)
+		}
+	case rateProvider != nil:
+		fecha, err := time.Parse("2006-01-02", comprobante.FechaEmision)
+		if err != nil {
+			return nil, fmt.Errorf("fecha de emisión inválida: %s", comprobante.FechaEmision)
+		}
+		rate, err := rateProvider.Rate(comprobante.Moneda, fecha)
+		if errors.Is(err, exchange.ErrRateNotFound) {
+			return nil, fmt.Errorf("no hay tipo de cambio publicado para %s al %s", comprobante.Moneda, comprobante.FechaEmision)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error obteniendo tipo de cambio: %v", err)
+		}
+		tipoCambio = rate.Venta
+	default:
+		return nil, fmt.Errorf("el tipo de cambio es requerido para comprobantes en %s", comprobante.Moneda)
+	}
+
+	return &ubl.ExchangeRate{
+		SourceCurrencyCode: comprobante.Moneda,
+		TargetCurrencyCode: catalog.MonedaSoles,
+		CalculationRate:    tipoCambio,
+		Date:               comprobante.FechaEmision,
+	}, nil
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// Tipos de operación de exportación (catálogo 51)
+const (
+	TipoOperacionExportacionBienes = "0200"
+	AfectacionExportacion          = "40"
+)
+
+// ExportacionData estructura para los datos de una factura de exportación
+type ExportacionData struct {
+	PaisComprador      string `json:"pais_comprador"`
+	DireccionComprador string `json:"direccion_comprador"`
+	CiudadComprador    string `json:"ciudad_comprador"`
+	Incoterm           string `json:"incoterm"`
+	PaisDestino        string `json:"pais_destino"`
+	PuertoEmbarque     string `json:"puerto_embarque"`
+	PuertoDestino      string `json:"puerto_destino"`
+	ModoTransporte     string `json:"modo_transporte"`
+	PesoBruto          string `json:"peso_bruto"`
+	UnidadPeso         string `json:"unidad_peso"`
+}
+
+// exportacion contiene los elementos UBL de una factura de exportación
+type exportacion struct {
+	address       *ubl.Address
+	delivery      *ubl.Delivery
+	deliveryTerms *ubl.DeliveryTerms
+}
+
+// isTipoOperacionExportacion indica si el tipo de operación corresponde a una exportación (0200-0208)
+func isTipoOperacionExportacion(tipo string) bool {
+	if len(tipo) != 4 || tipo[:3] != "020" {
+		return false
+	}
+	return tipo[3] >= '0' && tipo[3] <= '8'
+}
+
+// buildExportacion valida los datos de exportación y construye el domicilio del
+// comprador, los datos del envío y el Incoterm. La exportación de bienes (0200)
+// requiere Incoterm; las exportaciones de servicios (0201-0208) no.
+func buildExportacion(request *FacturaRequest, tipoOperacion string) (*exportacion, error) {
+	data := request.Exportacion
+	if data == nil {
+		return nil, nil
+	}
+
+	if request.Receptor.TipoDocumento == catalog.DocumentoRUC || request.Receptor.TipoDocumento == catalog.DocumentoDNI {
+		return nil, fmt.Errorf("el comprador de una exportación debe ser no domiciliado")
+	}
+	if len(data.PaisComprador) != 2 || data.PaisComprador == "PE" {
+		return nil, fmt.Errorf("país del comprador inválido: %s", data.PaisComprador)
+	}
+	for _, item := range request.Detalle {
+		if item.TipoAfectacionIGV != AfectacionExportacion {
+			return nil, fmt.Errorf("ítem %d: el tipo de afectación de una exportación debe ser %s", item.Item, AfectacionExportacion)
+		}
+	}
+	if igv, _ := strconv.ParseFloat(request.Comprobante.TotalIGV, 64); igv != 0 {
+		return nil, fmt.Errorf("una exportación no puede tener IGV")
+	}
+
+	e := &exportacion{
+		address: &ubl.Address{
+			CityName: data.CiudadComprador,
+			Country:  &ubl.Country{IdentificationCode: data.PaisComprador},
+		},
+	}
+	if data.DireccionComprador != "" {
+		e.address.AddressLine = &ubl.AddressLine{Line: data.DireccionComprador}
+	}
+
+	if tipoOperacion == TipoOperacionExportacionBienes {
+		if !catalog.IsIncoterm(data.Incoterm) {
+			return nil, fmt.Errorf("Incoterm inválido: %s", data.Incoterm)
+		}
+	} else if data.Incoterm != "" && !catalog.IsIncoterm(data.Incoterm) {
+		return nil, fmt.Errorf("Incoterm inválido: %s", data.Incoterm)
+	}
+	if data.Incoterm != "" {
+		e.deliveryTerms = &ubl.DeliveryTerms{ID: data.Incoterm}
+	}
+
+	shipment, err := buildShipment(data)
+	if err != nil {
+		return nil, err
+	}
+	if shipment != nil || data.PaisDestino != "" {
+		e.delivery = &ubl.Delivery{Shipment: shipment}
+		if data.PaisDestino != "" {
+			e.delivery.DeliveryLocation = &ubl.Location{
+				Address: &ubl.Address{Country: &ubl.Country{IdentificationCode: data.PaisDestino}},
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// buildShipment construye los datos del envío de una exportación, si los hay
+func buildShipment(data *ExportacionData) (*ubl.Shipment, error) {
+	if data.PuertoEmbarque == "" && data.PuertoDestino == "" && data.ModoTransporte == "" && data.PesoBruto == "" {
+		return nil, nil
+	}
+
+	shipment := &ubl.Shipment{ID: "1"}
+	if data.PesoBruto != "" {
+		peso, err := strconv.ParseFloat(data.PesoBruto, 64)
+		if err != nil || peso <= 0 {
+			return nil, fmt.Errorf("peso bruto inválido: %s", data.PesoBruto)
+		}
+		unidad := data.UnidadPeso
+		if unidad == "" {
+			unidad = "KGM"
+		}
+		shipment.GrossWeightMeasure = &ubl.Measure{Value: peso, UnitCode: unidad}
+	}
+	if data.ModoTransporte != "" {
+		shipment.ShipmentStage = &ubl.ShipmentStage{TransportModeCode: data.ModoTransporte}
+	}
+	if data.PuertoDestino != "" {
+		shipment.FirstArrivalPortLocation = &ubl.Location{ID: data.PuertoDestino}
+	}
+	if data.PuertoEmbarque != "" {
+		shipment.LastExitPortLocation = &ubl.Location{ID: data.PuertoEmbarque}
+	}
+	return shipment, nil
+}
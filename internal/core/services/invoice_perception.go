@@ -0,0 +1,83 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+const (
+	// TipoOperacionPercepcion es el tipo de operación sujeta a percepción (catálogo 51)
+	TipoOperacionPercepcion = "2001"
+
+	// LeyendaPercepcion es el código de leyenda de comprobante de percepción (catálogo 52)
+	LeyendaPercepcion = "2000"
+)
+
+// PercepcionData estructura para la percepción cobrada dentro de la factura
+type PercepcionData struct {
+	Codigo        string `json:"codigo"`
+	Porcentaje    string `json:"porcentaje"`
+	BaseImponible string `json:"base_imponible"`
+}
+
+// percepcion contiene los elementos UBL generados para una venta sujeta a percepción
+type percepcion struct {
+	monto           float64 // monto de la percepción en soles
+	allowanceCharge ubl.AllowanceCharge
+	note            ubl.Note
+}
+
+// buildPercepcion valida los datos de la percepción y construye el cargo
+// cac:AllowanceCharge (catálogo 53, códigos 51 a 53) y la leyenda 2000. Por
+// defecto la base imponible de la percepción es el importe total de la venta.
+func buildPercepcion(request *FacturaRequest, t *totales) (*percepcion, error) {
+	data := request.Percepcion
+	if data == nil {
+		return nil, nil
+	}
+
+	cargo, ok := catalog.GetCargoDescuento(data.Codigo)
+	if !ok || !catalog.EsPercepcion(data.Codigo) {
+		return nil, fmt.Errorf("código de percepción inválido: %s", data.Codigo)
+	}
+	if request.Comprobante.Moneda != "PEN" {
+		return nil, fmt.Errorf("la percepción solo aplica a comprobantes en soles")
+	}
+
+	porcentaje := cargo.Porcentaje
+	if data.Porcentaje != "" {
+		var err error
+		porcentaje, err = strconv.ParseFloat(data.Porcentaje, 64)
+		if err != nil || porcentaje <= 0 || porcentaje > 100 {
+			return nil, fmt.Errorf("porcentaje de percepción inválido: %s", data.Porcentaje)
+		}
+	}
+
+	base := t.importe
+	if data.BaseImponible != "" {
+		var err error
+		base, err = strconv.ParseFloat(data.BaseImponible, 64)
+		if err != nil || base <= 0 {
+			return nil, fmt.Errorf("base imponible de percepción inválida: %s", data.BaseImponible)
+		}
+	}
+
+	monto := round2(base * porcentaje / 100)
+	return &percepcion{
+		monto: monto,
+		allowanceCharge: ubl.AllowanceCharge{
+			ChargeIndicator:           true,
+			AllowanceChargeReasonCode: cargo.Codigo,
+			MultiplierFactorNumeric:   roundFactor(porcentaje / 100),
+			Amount:                    ubl.MonetaryAmount{Value: monto, CurrencyID: "PEN"},
+			BaseAmount:                &ubl.MonetaryAmount{Value: base, CurrencyID: "PEN"},
+		},
+		note: ubl.Note{
+			Value:            "COMPROBANTE DE PERCEPCIÓN",
+			LanguageLocaleID: LeyendaPercepcion,
+		},
+	}, nil
+}
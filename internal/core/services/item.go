@@ -1,11 +1,72 @@
 package services
 
-// Item representa un ítem o producto en una factura
-type Item struct {
-	Codigo      string  // Código del producto
-	Descripcion string  // Descripción del producto
-	Cantidad    float64 // Cantidad
-	PrecioUnit  float64 // Precio unitario
-	Subtotal    float64 // Subtotal (sin IGV)
-	IGV         float64 // IGV del ítem
+import (
+	"fmt"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// PropiedadAdicionalData estructura para una propiedad adicional de un ítem
+// (placa del vehículo, datos del huésped, etc.)
+type PropiedadAdicionalData struct {
+	Codigo string `json:"codigo"`
+	Nombre string `json:"nombre"`
+	Valor  string `json:"valor"`
+}
+
+// tiposOperacionConCodigoProducto son los tipos de operación (catálogo 51) en
+// los que el código de producto SUNAT es obligatorio en todos los ítems: venta
+// interna que sustenta gastos deducibles de persona natural y servicios de
+// hospedaje a no domiciliados
+var tiposOperacionConCodigoProducto = map[string]bool{
+	"0112": true,
+	"0202": true,
+}
+
+// buildItem construye los datos del ítem: descripción, código del producto,
+// código de producto SUNAT (catálogo 25) y propiedades adicionales (catálogo 55)
+func buildItem(item DetalleItem) (ubl.Item, error) {
+	result := ubl.Item{Description: item.Descripcion}
+
+	if item.Codigo != "" {
+		result.SellersItemIdentification = &ubl.ItemIdentification{ID: item.Codigo}
+	}
+
+	if item.CodigoProductoSunat != "" {
+		if !catalog.IsFormatoCodigoProductoSUNAT(item.CodigoProductoSunat) {
+			return ubl.Item{}, fmt.Errorf("ítem %d: el código de producto SUNAT debe ser un código UNSPSC de 8 dígitos: %s", item.Item, item.CodigoProductoSunat)
+		}
+		result.CommodityClassification = []ubl.CommodityClassification{{
+			ItemClassificationCode: ubl.ClassificationCode{
+				Value:          item.CodigoProductoSunat,
+				ListID:         "UNSPSC",
+				ListAgencyName: "GS1 US",
+				ListName:       "Item Classification",
+			},
+		}}
+	}
+
+	for _, p := range item.PropiedadesAdicionales {
+		nombre := p.Nombre
+		if p.Codigo != "" {
+			descripcion, ok := catalog.GetConceptoTributario(p.Codigo)
+			if !ok {
+				return ubl.Item{}, fmt.Errorf("ítem %d: código de propiedad adicional inválido: %s", item.Item, p.Codigo)
+			}
+			if nombre == "" {
+				nombre = descripcion
+			}
+		}
+		if nombre == "" || p.Valor == "" {
+			return ubl.Item{}, fmt.Errorf("ítem %d: la propiedad adicional requiere nombre y valor", item.Item)
+		}
+		result.AdditionalItemProperty = append(result.AdditionalItemProperty, ubl.ItemProperty{
+			Name:     nombre,
+			NameCode: p.Codigo,
+			Value:    p.Valor,
+		})
+	}
+
+	return result, nil
 }
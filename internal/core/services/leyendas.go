@@ -0,0 +1,60 @@
+package services
+
+import (
+	"fmt"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/numletras"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// Códigos de leyenda (catálogo 52). Las leyendas de percepción (2000) y de
+// detracción (2006) se generan junto con sus elementos.
+const (
+	// LeyendaMontoEnLetras es el código de leyenda del importe total en letras
+	LeyendaMontoEnLetras = "1000"
+
+	// LeyendaTransferenciaGratuita es el código de leyenda de transferencia gratuita de bienes o servicios
+	LeyendaTransferenciaGratuita = "1002"
+)
+
+// buildLeyendas construye las leyendas generales de un comprobante: el importe
+// total en letras y, si incluye operaciones gratuitas, la leyenda de
+// transferencia gratuita
+func buildLeyendas(importe float64, moneda string, gratuita bool) ([]ubl.Note, error) {
+	monto, err := buildLeyendaMonto(importe, moneda)
+	if err != nil {
+		return nil, err
+	}
+	notes := []ubl.Note{monto}
+	if gratuita {
+		notes = append(notes, ubl.Note{
+			Value:            "TRANSFERENCIA GRATUITA DE UN BIEN Y/O SERVICIO PRESTADO GRATUITAMENTE",
+			LanguageLocaleID: LeyendaTransferenciaGratuita,
+		})
+	}
+	return notes, nil
+}
+
+// buildLeyendaMonto construye la leyenda 1000 con el importe en letras y el nombre de la moneda
+func buildLeyendaMonto(importe float64, moneda string) (ubl.Note, error) {
+	m, ok := catalog.GetMoneda(moneda)
+	if !ok {
+		return ubl.Note{}, fmt.Errorf("moneda inválida: %s", moneda)
+	}
+	letras, err := numletras.Leyenda(importe, m.Nombre)
+	if err != nil {
+		return ubl.Note{}, fmt.Errorf("error generando importe en letras: %v", err)
+	}
+	return ubl.Note{Value: letras, LanguageLocaleID: LeyendaMontoEnLetras}, nil
+}
+
+// hasOperacionGratuita indica si alguno de los ítems corresponde a una transferencia gratuita
+func hasOperacionGratuita(detalle []DetalleItem) bool {
+	for _, item := range detalle {
+		if a, ok := catalog.GetAfectacionIGV(item.TipoAfectacionIGV); ok && !a.Onerosa {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ubl-converter/internal/pkg/signature"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// Espacios de nombres de los comprobantes de retención y percepción
+const (
+	RetentionNamespace   = "urn:sunat:names:specification:ubl:peru:schema:xsd:Retention-1"
+	PerceptionNamespace  = "urn:sunat:names:specification:ubl:peru:schema:xsd:Perception-1"
+	SunatAggregateNS     = "urn:sunat:names:specification:ubl:peru:schema:xsd:SunatAggregateComponents-1"
+	extensionComponentNS = "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+	aggregateComponentNS = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	basicComponentNS     = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// DocumentoRelacionadoData estructura para un comprobante afectado por una retención o percepción
+type DocumentoRelacionadoData struct {
+	TipoComprobante string `json:"tipo_comprobante"`
+	Serie           string `json:"serie"`
+	Numero          string `json:"numero"`
+	FechaEmision    string `json:"fecha_emision"`
+	Moneda          string `json:"moneda"`
+	ImporteTotal    string `json:"importe_total"`
+	NumeroPago      string `json:"numero_pago"`
+	ImportePago     string `json:"importe_pago"`
+	FechaPago       string `json:"fecha_pago"`
+	TipoCambio      string `json:"tipo_cambio"`
+	FechaTipoCambio string `json:"fecha_tipo_cambio"`
+}
+
+// importes contiene los importes de un documento relacionado ya convertidos
+type importes struct {
+	total        float64
+	pago         float64
+	pagoSoles    float64
+	exchangeRate *ubl.ExchangeRate
+}
+
+// parseDocumentoRelacionado valida el documento relacionado y convierte el pago a soles
+func parseDocumentoRelacionado(i int, doc DocumentoRelacionadoData) (*importes, error) {
+	if doc.TipoComprobante == "" || doc.Serie == "" || doc.Numero == "" {
+		return nil, fmt.Errorf("documento %d: tipo, serie y número son requeridos", i+1)
+	}
+	if _, err := time.Parse("2006-01-02", doc.FechaEmision); err != nil {
+		return nil, fmt.Errorf("documento %d: fecha de emisión inválida: %v", i+1, err)
+	}
+	if _, err := time.Parse("2006-01-02", doc.FechaPago); err != nil {
+		return nil, fmt.Errorf("documento %d: fecha de pago inválida: %v", i+1, err)
+	}
+
+	total, err := strconv.ParseFloat(doc.ImporteTotal, 64)
+	if err != nil {
+		return nil, fmt.Errorf("documento %d: importe total inválido: %v", i+1, err)
+	}
+	pago, err := strconv.ParseFloat(doc.ImportePago, 64)
+	if err != nil || pago <= 0 {
+		return nil, fmt.Errorf("documento %d: importe de pago inválido", i+1)
+	}
+	if pago > total {
+		return nil, fmt.Errorf("documento %d: el importe de pago supera el importe total", i+1)
+	}
+
+	result := &importes{total: total, pago: pago, pagoSoles: pago}
+	if doc.Moneda != "" && doc.Moneda != "PEN" {
+		tipoCambio, err := strconv.ParseFloat(doc.TipoCambio, 64)
+		if err != nil || tipoCambio <= 0 {
+			return nil, fmt.Errorf("documento %d: tipo de cambio requerido para moneda %s", i+1, doc.Moneda)
+		}
+		fecha := doc.FechaTipoCambio
+		if fecha == "" {
+			fecha = doc.FechaPago
+		}
+		result.pagoSoles = round2(pago * tipoCambio)
+		result.exchangeRate = &ubl.ExchangeRate{
+			SourceCurrencyCode: doc.Moneda,
+			TargetCurrencyCode: "PEN",
+			CalculationRate:    tipoCambio,
+			Date:               fecha,
+		}
+	}
+	return result, nil
+}
+
+// buildAgentParty construye la parte (agente o proveedor/cliente) de un comprobante de retención o percepción
+func buildAgentParty(ruc, razonSocial string) ubl.Party {
+	return ubl.Party{
+		PartyIdentification: []ubl.PartyIdentification{{
+			ID: ubl.Identifier{Value: ruc, SchemeID: "6"},
+		}},
+		PartyName: []ubl.PartyName{{Name: razonSocial}},
+		PartyLegalEntity: []ubl.PartyLegalEntity{{
+			RegistrationName: razonSocial,
+		}},
+	}
+}
+
+// buildSignature construye el elemento cac:Signature del emisor
+func buildSignature(emisor EmisorData) ubl.Signature {
+	return ubl.Signature{
+		ID: "signatureKG",
+		SignatoryParty: ubl.SignatoryParty{
+			PartyIdentification: []ubl.PartyIdentification{{
+				ID: ubl.Identifier{Value: emisor.RUC},
+			}},
+			PartyName: []ubl.PartyName{{
+				Name: emisor.RazonSocial,
+			}},
+		},
+		DigitalSignatureAttachment: ubl.DigitalSignatureAttachment{
+			ExternalReference: ubl.ExternalReference{
+				URI: "#signatureKG",
+			},
+		},
+	}
+}
+
+// signDocument serializa el documento sin firma y retorna el elemento ds:Signature
+func signDocument(ctx context.Context, document interface{}) (string, error) {
+	xmlBytes, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error serializando XML: %v", err)
+	}
+
+	certInfo, err := signature.LoadCertificate()
+	if err != nil {
+		return "", fmt.Errorf("error cargando certificado: %v", err)
+	}
+
+	signedXML, err := signature.SignXMLAsElement(ctx, string(xmlBytes), certInfo)
+	if err != nil {
+		return "", fmt.Errorf("error firmando XML: %v", err)
+	}
+	return signedXML, nil
+}
+
+func validateOtrosCPE(emisor EmisorData, receptor ReceptorData, comprobante ComprobanteData, documentos int) error {
+	if len(emisor.RUC) != 11 {
+		return fmt.Errorf("RUC del emisor inválido")
+	}
+	if len(receptor.RUC) != 11 {
+		return fmt.Errorf("RUC del receptor inválido")
+	}
+	if comprobante.Serie == "" || comprobante.Numero == "" {
+		return fmt.Errorf("serie y número son requeridos")
+	}
+	if _, err := time.Parse("2006-01-02", comprobante.FechaEmision); err != nil {
+		return fmt.Errorf("fecha de emisión inválida: %v", err)
+	}
+	if documentos == 0 {
+		return fmt.Errorf("se requiere al menos un documento relacionado")
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// CodigoEstablecimientoPrincipal es el código de establecimiento anexo del domicilio fiscal
+const CodigoEstablecimientoPrincipal = "0000"
+
+// buildSupplierParty construye los datos del emisor: RUC, nombre, domicilio
+// fiscal con ubigeo y código de establecimiento, y contacto
+func buildSupplierParty(emisor EmisorData) ubl.SupplierParty {
+	codigo := emisor.CodigoEstablecimiento
+	if codigo == "" {
+		codigo = CodigoEstablecimientoPrincipal
+	}
+
+	address := &ubl.Address{
+		ID:                  emisor.Ubigeo,
+		AddressTypeCode:     codigo,
+		CitySubdivisionName: emisor.Urbanizacion,
+		CityName:            emisor.Provincia,
+		CountrySubentity:    emisor.Departamento,
+		District:            emisor.Distrito,
+		Country:             &ubl.Country{IdentificationCode: "PE"},
+	}
+	if emisor.Direccion != "" {
+		address.AddressLine = &ubl.AddressLine{Line: emisor.Direccion}
+	}
+
+	return ubl.SupplierParty{
+		CustomerAssignedAccountID: emisor.RUC,
+		Party: ubl.Party{
+			PartyIdentification: []ubl.PartyIdentification{{
+				ID: ubl.Identifier{Value: emisor.RUC, SchemeID: catalog.DocumentoRUC},
+			}},
+			PartyName: []ubl.PartyName{{Name: emisor.RazonSocial}},
+			PartyLegalEntity: []ubl.PartyLegalEntity{{
+				RegistrationName:    emisor.RazonSocial,
+				CompanyID:           emisor.RUC,
+				RegistrationAddress: address,
+			}},
+			Contact: buildContact(emisor.Telefono, emisor.Email),
+		},
+	}
+}
+
+// buildCustomerParty construye los datos del receptor: documento de identidad,
+// nombre, dirección y contacto
+func buildCustomerParty(receptor ReceptorData) ubl.CustomerParty {
+	tipoDocumento := receptor.TipoDocumento
+	if tipoDocumento == "" {
+		tipoDocumento = catalog.DocumentoRUC
+	}
+
+	entity := ubl.PartyLegalEntity{
+		RegistrationName: receptor.RazonSocial,
+		CompanyID:        receptor.RUC,
+	}
+	if receptor.Direccion != "" {
+		entity.RegistrationAddress = &ubl.Address{
+			ID:          receptor.Ubigeo,
+			AddressLine: &ubl.AddressLine{Line: receptor.Direccion},
+			Country:     &ubl.Country{IdentificationCode: "PE"},
+		}
+	}
+
+	return ubl.CustomerParty{
+		CustomerAssignedAccountID: receptor.RUC,
+		Party: ubl.Party{
+			PartyIdentification: []ubl.PartyIdentification{{
+				ID: ubl.Identifier{Value: receptor.RUC, SchemeID: tipoDocumento},
+			}},
+			PartyLegalEntity: []ubl.PartyLegalEntity{entity},
+			Contact:          buildContact("", receptor.Email),
+		},
+	}
+}
+
+func buildContact(telefono, email string) *ubl.Contact {
+	if telefono == "" && email == "" {
+		return nil
+	}
+	return &ubl.Contact{Telephone: telefono, ElectronicMail: email}
+}
+
+// validateParties valida el ubigeo, el código de establecimiento y los correos del emisor y del receptor
+func validateParties(emisor EmisorData, receptor ReceptorData) error {
+	if emisor.Ubigeo != "" && (len(emisor.Ubigeo) != 6 || !isDigits(emisor.Ubigeo)) {
+		return fmt.Errorf("ubigeo del emisor inválido: %s", emisor.Ubigeo)
+	}
+	if emisor.CodigoEstablecimiento != "" && (len(emisor.CodigoEstablecimiento) != 4 || !isDigits(emisor.CodigoEstablecimiento)) {
+		return fmt.Errorf("código de establecimiento inválido: %s", emisor.CodigoEstablecimiento)
+	}
+	if receptor.Ubigeo != "" && (len(receptor.Ubigeo) != 6 || !isDigits(receptor.Ubigeo)) {
+		return fmt.Errorf("ubigeo del receptor inválido: %s", receptor.Ubigeo)
+	}
+	for _, email := range []string{emisor.Email, receptor.Email} {
+		if email == "" {
+			continue
+		}
+		if _, err := mail.ParseAddress(email); err != nil {
+			return fmt.Errorf("correo electrónico inválido: %s", email)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/ubl"
+)
+
+const (
+	// FormaPagoContado indica pago al contado
+	FormaPagoContado = "Contado"
+	// FormaPagoCredito indica pago al crédito en cuotas
+	FormaPagoCredito = "Credito"
+)
+
+// CuotaData estructura para una cuota de una venta al crédito
+type CuotaData struct {
+	Monto            string `json:"monto"`
+	FechaVencimiento string `json:"fecha_vencimiento"`
+}
+
+// FormaPagoData estructura para la forma de pago del comprobante
+type FormaPagoData struct {
+	Tipo           string      `json:"tipo"`
+	MontoPendiente string      `json:"monto_pendiente"`
+	Cuotas         []CuotaData `json:"cuotas"`
+}
+
+// buildPaymentTerms construye los elementos cac:PaymentTerms de forma de pago.
+// Si no se indica la forma de pago se asume Contado. Para ventas al crédito
+// el monto neto pendiente de pago es por defecto pendiente, es decir, el total
+// del comprobante menos las deducciones (detracción) ya calculadas.
+func buildPaymentTerms(request *FacturaRequest, total, pendiente float64) ([]ubl.PaymentTerms, error) {
+	formaPago := request.FormaPago
+	moneda := request.Comprobante.Moneda
+
+	switch normalizeFormaPago(formaPago.Tipo) {
+	case FormaPagoContado:
+		if len(formaPago.Cuotas) > 0 {
+			return nil, fmt.Errorf("las cuotas solo aplican a la forma de pago Credito")
+		}
+		return []ubl.PaymentTerms{{
+			ID:             "FormaPago",
+			PaymentMeansID: FormaPagoContado,
+		}}, nil
+
+	case FormaPagoCredito:
+		if len(formaPago.Cuotas) == 0 {
+			return nil, fmt.Errorf("la forma de pago Credito requiere al menos una cuota")
+		}
+
+		montoPendiente := pendiente
+		if formaPago.MontoPendiente != "" {
+			var err error
+			montoPendiente, err = strconv.ParseFloat(formaPago.MontoPendiente, 64)
+			if err != nil {
+				return nil, fmt.Errorf("monto pendiente inválido: %v", err)
+			}
+		}
+		if montoPendiente <= 0 || montoPendiente > total {
+			return nil, fmt.Errorf("el monto pendiente debe ser mayor a cero y no superar el total")
+		}
+
+		fechaEmision, err := time.Parse("2006-01-02", request.Comprobante.FechaEmision)
+		if err != nil {
+			return nil, fmt.Errorf("fecha de emisión inválida: %v", err)
+		}
+
+		terms := []ubl.PaymentTerms{{
+			ID:             "FormaPago",
+			PaymentMeansID: FormaPagoCredito,
+			Amount:         &ubl.MonetaryAmount{Value: montoPendiente, CurrencyID: moneda},
+		}}
+
+		var suma float64
+		for i, cuota := range formaPago.Cuotas {
+			monto, err := strconv.ParseFloat(cuota.Monto, 64)
+			if err != nil || monto <= 0 {
+				return nil, fmt.Errorf("monto de la cuota %d inválido", i+1)
+			}
+			vencimiento, err := time.Parse("2006-01-02", cuota.FechaVencimiento)
+			if err != nil {
+				return nil, fmt.Errorf("fecha de vencimiento de la cuota %d inválida: %v", i+1, err)
+			}
+			if !vencimiento.After(fechaEmision) {
+				return nil, fmt.Errorf("la fecha de vencimiento de la cuota %d debe ser posterior a la fecha de emisión", i+1)
+			}
+			suma += monto
+
+			terms = append(terms, ubl.PaymentTerms{
+				ID:             "FormaPago",
+				PaymentMeansID: fmt.Sprintf("Cuota%03d", i+1),
+				Amount:         &ubl.MonetaryAmount{Value: monto, CurrencyID: moneda},
+				PaymentDueDate: cuota.FechaVencimiento,
+			})
+		}
+
+		if math.Abs(suma-montoPendiente) > 0.01 {
+			return nil, fmt.Errorf("la suma de las cuotas (%.2f) no coincide con el monto pendiente de pago (%.2f)", suma, montoPendiente)
+		}
+		return terms, nil
+
+	default:
+		return nil, fmt.Errorf("forma de pago inválida: %s", formaPago.Tipo)
+	}
+}
+
+// normalizeFormaPago acepta variantes con tilde y mayúsculas de la forma de pago
+func normalizeFormaPago(tipo string) string {
+	switch strings.ToLower(strings.TrimSpace(tipo)) {
+	case "", "contado":
+		return FormaPagoContado
+	case "credito", "crédito":
+		return FormaPagoCredito
+	}
+	return tipo
+}
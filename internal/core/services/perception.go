@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/tracing"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// PerceptionRequest estructura para la solicitud de comprobante de percepción
+type PerceptionRequest struct {
+	Emisor        EmisorData                 `json:"emisor"`
+	Receptor      ReceptorData               `json:"receptor"`
+	Comprobante   ComprobanteData            `json:"comprobante"`
+	Regimen       string                     `json:"regimen"`
+	Observaciones string                     `json:"observaciones"`
+	Documentos    []DocumentoRelacionadoData `json:"documentos"`
+}
+
+// UBLPerceptionWithExtensions comprobante de percepción con firma y extensiones
+type UBLPerceptionWithExtensions struct {
+	XMLName    xml.Name            `xml:"Perception"`
+	Xmlns      string              `xml:"xmlns,attr"`
+	XmlnsExt   string              `xml:"xmlns:ext,attr"`
+	XmlnsCac   string              `xml:"xmlns:cac,attr"`
+	XmlnsCbc   string              `xml:"xmlns:cbc,attr"`
+	XmlnsSac   string              `xml:"xmlns:sac,attr"`
+	Extensions CustomUBLExtensions `xml:"ext:UBLExtensions"`
+	ubl.Perception
+}
+
+// ConvertToUBLPerception convierte una solicitud a un comprobante de percepción firmado
+func ConvertToUBLPerception(ctx context.Context, request *PerceptionRequest) (xmlContent string, err error) {
+	if request == nil {
+		return "", fmt.Errorf("request cannot be nil")
+	}
+	ctx, span := startConversion(ctx, "ConvertToUBLPerception", request.Emisor, "40", request.Comprobante)
+	defer func() { tracing.End(span, err) }()
+	if err := validateOtrosCPE(request.Emisor, request.Receptor, request.Comprobante, len(request.Documentos)); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(request.Comprobante.Serie, "P") {
+		return "", fmt.Errorf("la serie de un comprobante de percepción debe iniciar con P")
+	}
+
+	regimen, ok := catalog.GetRegimenPercepcion(request.Regimen)
+	if !ok {
+		return "", fmt.Errorf("régimen de percepción inválido: %s", request.Regimen)
+	}
+
+	perception := ubl.Perception{
+		UBLVersionID:              "2.0",
+		CustomizationID:           "1.0",
+		Signature:                 buildSignature(request.Emisor),
+		ID:                        fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
+		IssueDate:                 request.Comprobante.FechaEmision,
+		IssueTime:                 request.Comprobante.HoraEmision,
+		AgentParty:                buildAgentParty(request.Emisor.RUC, request.Emisor.RazonSocial),
+		ReceiverParty:             buildAgentParty(request.Receptor.RUC, request.Receptor.RazonSocial),
+		SUNATPerceptionSystemCode: regimen.Codigo,
+		SUNATPerceptionPercent:    regimen.Porcentaje,
+		Note:                      request.Observaciones,
+	}
+
+	// Agregar documentos relacionados; los importes percibidos siempre se expresan en soles
+	var totalPercibido, totalCobrado float64
+	for i, doc := range request.Documentos {
+		montos, err := parseDocumentoRelacionado(i, doc)
+		if err != nil {
+			return "", err
+		}
+		percibido := round2(montos.pagoSoles * regimen.Porcentaje / 100)
+		neto := round2(montos.pagoSoles + percibido)
+		totalPercibido += percibido
+		totalCobrado += neto
+
+		numeroPago := doc.NumeroPago
+		if numeroPago == "" {
+			numeroPago = "1"
+		}
+		moneda := doc.Moneda
+		if moneda == "" {
+			moneda = "PEN"
+		}
+
+		perception.DocumentReferences = append(perception.DocumentReferences, ubl.PerceptionDocumentReference{
+			ID:                 ubl.Identifier{Value: doc.Serie + "-" + doc.Numero, SchemeID: doc.TipoComprobante},
+			IssueDate:          doc.FechaEmision,
+			TotalInvoiceAmount: ubl.MonetaryAmount{Value: montos.total, CurrencyID: moneda},
+			Payment: ubl.Payment{
+				ID:         numeroPago,
+				PaidAmount: ubl.MonetaryAmount{Value: montos.pago, CurrencyID: moneda},
+				PaidDate:   doc.FechaPago,
+			},
+			PerceptionInformation: ubl.PerceptionInformation{
+				SUNATPerceptionAmount: ubl.MonetaryAmount{Value: percibido, CurrencyID: "PEN"},
+				SUNATPerceptionDate:   doc.FechaPago,
+				SUNATNetTotalCashed:   ubl.MonetaryAmount{Value: neto, CurrencyID: "PEN"},
+				ExchangeRate:          montos.exchangeRate,
+			},
+		})
+	}
+	perception.TotalInvoiceAmount = ubl.MonetaryAmount{Value: round2(totalPercibido), CurrencyID: "PEN"}
+	perception.SUNATTotalCashed = ubl.MonetaryAmount{Value: round2(totalCobrado), CurrencyID: "PEN"}
+
+	// Firmar el XML
+	signedXML, err := signDocument(ctx, perception)
+	if err != nil {
+		return "", err
+	}
+
+	// Envolver en UBL con extensiones
+	wrapped := UBLPerceptionWithExtensions{
+		Xmlns:    PerceptionNamespace,
+		XmlnsExt: extensionComponentNS,
+		XmlnsCac: aggregateComponentNS,
+		XmlnsCbc: basicComponentNS,
+		XmlnsSac: SunatAggregateNS,
+		Extensions: CustomUBLExtensions{
+			Extension: []CustomUBLExtension{{
+				ExtensionContent: CustomExtensionContent{
+					XML: signedXML,
+				},
+			}},
+		},
+		Perception: perception,
+	}
+
+	// Serializar XML final
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(wrapped); err != nil {
+		return "", fmt.Errorf("error codificando XML final: %v", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// AnticipoData estructura para un anticipo (pago adelantado) deducido en la factura
+type AnticipoData struct {
+	TipoComprobante string `json:"tipo_comprobante"`
+	Serie           string `json:"serie"`
+	Numero          string `json:"numero"`
+	RUCEmisor       string `json:"ruc_emisor"`
+	ValorVenta      string `json:"valor_venta"`
+	Total           string `json:"total"`
+}
+
+// anticipos contiene los elementos UBL generados para los anticipos deducidos
+type anticipos struct {
+	valorVenta float64 // suma de los valores de venta (sin IGV) de los anticipos
+	total      float64 // suma de los importes totales de los anticipos
+	references []ubl.DocumentReference
+	payments   []ubl.PrepaidPayment
+}
+
+// buildAnticipos valida los anticipos de la solicitud y construye los elementos
+// cac:AdditionalDocumentReference y cac:PrepaidPayment. Si no se indica el
+// valor de venta del anticipo se obtiene a partir de la tasa de IGV de la factura.
+func buildAnticipos(request *FacturaRequest) (*anticipos, error) {
+	if len(request.Anticipos) == 0 {
+		return nil, nil
+	}
+
+	tasaIGV := 0.18
+	if gravado, err := strconv.ParseFloat(request.Comprobante.TotalGravado, 64); err == nil && gravado > 0 {
+		if igv, err := strconv.ParseFloat(request.Comprobante.TotalIGV, 64); err == nil {
+			tasaIGV = igv / gravado
+		}
+	}
+
+	result := &anticipos{}
+	for i, data := range request.Anticipos {
+		// Catálogo 12: 02 factura emitida por anticipos, 03 boleta emitida por anticipos
+		if data.TipoComprobante != "02" && data.TipoComprobante != "03" {
+			return nil, fmt.Errorf("anticipo %d: tipo de documento inválido: %s", i+1, data.TipoComprobante)
+		}
+		if data.Serie == "" || data.Numero == "" {
+			return nil, fmt.Errorf("anticipo %d: serie y número son requeridos", i+1)
+		}
+
+		total, err := strconv.ParseFloat(data.Total, 64)
+		if err != nil || total <= 0 {
+			return nil, fmt.Errorf("anticipo %d: total inválido", i+1)
+		}
+		valorVenta := round2(total / (1 + tasaIGV))
+		if data.ValorVenta != "" {
+			valorVenta, err = strconv.ParseFloat(data.ValorVenta, 64)
+			if err != nil || valorVenta <= 0 || valorVenta > total {
+				return nil, fmt.Errorf("anticipo %d: valor de venta inválido", i+1)
+			}
+		}
+
+		rucEmisor := data.RUCEmisor
+		if rucEmisor == "" {
+			rucEmisor = request.Emisor.RUC
+		}
+		id := strconv.Itoa(i + 1)
+
+		result.valorVenta += valorVenta
+		result.total += total
+		result.references = append(result.references, ubl.DocumentReference{
+			ID:                 data.Serie + "-" + data.Numero,
+			DocumentTypeCode:   data.TipoComprobante,
+			DocumentStatusCode: id,
+			IssuerParty: &ubl.Party{
+				PartyIdentification: []ubl.PartyIdentification{{
+					ID: ubl.Identifier{Value: rucEmisor, SchemeID: "6"},
+				}},
+			},
+		})
+		result.payments = append(result.payments, ubl.PrepaidPayment{
+			ID:         ubl.Identifier{Value: id, SchemeName: "Anticipo", SchemeAgencyName: "PE:SUNAT"},
+			PaidAmount: ubl.MonetaryAmount{Value: total, CurrencyID: request.Comprobante.Moneda},
+		})
+	}
+	result.valorVenta = round2(result.valorVenta)
+	result.total = round2(result.total)
+
+	return result, nil
+}
+
+// allowanceCharge construye el descuento global por anticipos gravados (catálogo 53, código 04)
+func (a *anticipos) allowanceCharge(t *totales, moneda string) ubl.AllowanceCharge {
+	allowance := ubl.AllowanceCharge{
+		ChargeIndicator:           false,
+		AllowanceChargeReasonCode: "04",
+		Amount:                    ubl.MonetaryAmount{Value: a.valorVenta, CurrencyID: moneda},
+		BaseAmount:                &ubl.MonetaryAmount{Value: t.valorVenta, CurrencyID: moneda},
+	}
+	if t.valorVenta > 0 {
+		allowance.MultiplierFactorNumeric = roundFactor(a.valorVenta / t.valorVenta)
+	}
+	return allowance
+}
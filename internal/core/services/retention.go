@@ -0,0 +1,144 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/tracing"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// RetentionRequest estructura para la solicitud de comprobante de retención
+type RetentionRequest struct {
+	Emisor        EmisorData                 `json:"emisor"`
+	Receptor      ReceptorData               `json:"receptor"`
+	Comprobante   ComprobanteData            `json:"comprobante"`
+	Regimen       string                     `json:"regimen"`
+	Observaciones string                     `json:"observaciones"`
+	Documentos    []DocumentoRelacionadoData `json:"documentos"`
+}
+
+// UBLRetentionWithExtensions comprobante de retención con firma y extensiones
+type UBLRetentionWithExtensions struct {
+	XMLName    xml.Name            `xml:"Retention"`
+	Xmlns      string              `xml:"xmlns,attr"`
+	XmlnsExt   string              `xml:"xmlns:ext,attr"`
+	XmlnsCac   string              `xml:"xmlns:cac,attr"`
+	XmlnsCbc   string              `xml:"xmlns:cbc,attr"`
+	XmlnsSac   string              `xml:"xmlns:sac,attr"`
+	Extensions CustomUBLExtensions `xml:"ext:UBLExtensions"`
+	ubl.Retention
+}
+
+// ConvertToUBLRetention convierte una solicitud a un comprobante de retención firmado
+func ConvertToUBLRetention(ctx context.Context, request *RetentionRequest) (xmlContent string, err error) {
+	if request == nil {
+		return "", fmt.Errorf("request cannot be nil")
+	}
+	ctx, span := startConversion(ctx, "ConvertToUBLRetention", request.Emisor, "20", request.Comprobante)
+	defer func() { tracing.End(span, err) }()
+	if err := validateOtrosCPE(request.Emisor, request.Receptor, request.Comprobante, len(request.Documentos)); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(request.Comprobante.Serie, "R") {
+		return "", fmt.Errorf("la serie de un comprobante de retención debe iniciar con R")
+	}
+
+	regimen, ok := catalog.GetRegimenRetencion(request.Regimen)
+	if !ok {
+		return "", fmt.Errorf("régimen de retención inválido: %s", request.Regimen)
+	}
+
+	retention := ubl.Retention{
+		UBLVersionID:             "2.0",
+		CustomizationID:          "1.0",
+		Signature:                buildSignature(request.Emisor),
+		ID:                       fmt.Sprintf("%s-%s", request.Comprobante.Serie, request.Comprobante.Numero),
+		IssueDate:                request.Comprobante.FechaEmision,
+		IssueTime:                request.Comprobante.HoraEmision,
+		AgentParty:               buildAgentParty(request.Emisor.RUC, request.Emisor.RazonSocial),
+		ReceiverParty:            buildAgentParty(request.Receptor.RUC, request.Receptor.RazonSocial),
+		SUNATRetentionSystemCode: regimen.Codigo,
+		SUNATRetentionPercent:    regimen.Porcentaje,
+		Note:                     request.Observaciones,
+	}
+
+	// Agregar documentos relacionados; los importes retenidos siempre se expresan en soles
+	var totalRetenido, totalPagado float64
+	for i, doc := range request.Documentos {
+		montos, err := parseDocumentoRelacionado(i, doc)
+		if err != nil {
+			return "", err
+		}
+		retenido := round2(montos.pagoSoles * regimen.Porcentaje / 100)
+		neto := round2(montos.pagoSoles - retenido)
+		totalRetenido += retenido
+		totalPagado += neto
+
+		numeroPago := doc.NumeroPago
+		if numeroPago == "" {
+			numeroPago = "1"
+		}
+		moneda := doc.Moneda
+		if moneda == "" {
+			moneda = "PEN"
+		}
+
+		retention.DocumentReferences = append(retention.DocumentReferences, ubl.RetentionDocumentReference{
+			ID:                 ubl.Identifier{Value: doc.Serie + "-" + doc.Numero, SchemeID: doc.TipoComprobante},
+			IssueDate:          doc.FechaEmision,
+			TotalInvoiceAmount: ubl.MonetaryAmount{Value: montos.total, CurrencyID: moneda},
+			Payment: ubl.Payment{
+				ID:         numeroPago,
+				PaidAmount: ubl.MonetaryAmount{Value: montos.pago, CurrencyID: moneda},
+				PaidDate:   doc.FechaPago,
+			},
+			RetentionInformation: ubl.RetentionInformation{
+				SUNATRetentionAmount: ubl.MonetaryAmount{Value: retenido, CurrencyID: "PEN"},
+				SUNATRetentionDate:   doc.FechaPago,
+				SUNATNetTotalPaid:    ubl.MonetaryAmount{Value: neto, CurrencyID: "PEN"},
+				ExchangeRate:         montos.exchangeRate,
+			},
+		})
+	}
+	retention.TotalInvoiceAmount = ubl.MonetaryAmount{Value: round2(totalRetenido), CurrencyID: "PEN"}
+	retention.SUNATTotalPaid = ubl.MonetaryAmount{Value: round2(totalPagado), CurrencyID: "PEN"}
+
+	// Firmar el XML
+	signedXML, err := signDocument(ctx, retention)
+	if err != nil {
+		return "", err
+	}
+
+	// Envolver en UBL con extensiones
+	wrapped := UBLRetentionWithExtensions{
+		Xmlns:    RetentionNamespace,
+		XmlnsExt: extensionComponentNS,
+		XmlnsCac: aggregateComponentNS,
+		XmlnsCbc: basicComponentNS,
+		XmlnsSac: SunatAggregateNS,
+		Extensions: CustomUBLExtensions{
+			Extension: []CustomUBLExtension{{
+				ExtensionContent: CustomExtensionContent{
+					XML: signedXML,
+				},
+			}},
+		},
+		Retention: retention,
+	}
+
+	// Serializar XML final
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(wrapped); err != nil {
+		return "", fmt.Errorf("error codificando XML final: %v", err)
+	}
+
+	return buf.String(), nil
+}
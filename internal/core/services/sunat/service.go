@@ -3,35 +3,99 @@ package sunat
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
-	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
-
+	"strings"
+	"sync"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/cdr"
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/logging"
+	"ubl-converter/internal/pkg/oauth"
 	"ubl-converter/internal/pkg/soap"
+	"ubl-converter/internal/pkg/tracing"
 	"ubl-converter/internal/pkg/ziputil"
 )
 
-// Service interfaz para el servicio SUNAT
+// Service interfaz para el servicio SUNAT.
+//
+// Las operaciones con SUNAT reciben el contexto de la solicitud: de él se
+// toman la traza y el logger (con el request ID) que registra el tráfico.
 type Service interface {
-	SendInvoice(filename string) (string, error)
-	SendCreditNote(filename string) (string, error)
-	SendDebitNote(filename string) (string, error)
-	ConsultaCDR(ruc, tipo, serie, numero string) (string, error)
-	ConsultaEstado(ruc, tipo, serie, numero string) (string, error)
-	ConsultaTicket(ticket string) (string, error)
+	SendInvoice(ctx context.Context, filename string) (*EnvioResultado, error)
+	SendCreditNote(ctx context.Context, filename string) (*EnvioResultado, error)
+	SendDebitNote(ctx context.Context, filename string) (*EnvioResultado, error)
+	SendRetention(ctx context.Context, filename string) (*EnvioResultado, error)
+	SendPerception(ctx context.Context, filename string) (*EnvioResultado, error)
+	SendSummary(ctx context.Context, filename string) (string, error)
+	ConsultaCDR(ctx context.Context, ruc, tipo, serie, numero string) (*ConsultaResultado, error)
+	ConsultaEstado(ctx context.Context, ruc, tipo, serie, numero string) (*ConsultaResultado, error)
+	ConsultaTicket(ctx context.Context, ruc, ticket string) (*ConsultaResultado, error)
+	ConsultaTicketOtrosCPE(ctx context.Context, ruc, ticket string) (*ConsultaResultado, error)
+	SendDespatchAdvice(ctx context.Context, filename string) (string, error)
+	ConsultaTicketGRE(ctx context.Context, ruc, ticket string) (*soap.GREStatus, error)
+	ConsultaValidez(ctx context.Context, rucConsultante string, comprobante soap.ComprobanteConsulta) (*soap.ValidezResultado, error)
 	PrepareAndValidate(xmlContent, invoiceID string) (map[string]interface{}, error)
 }
 
 type service struct {
-	isProd     bool            // true para producción, false para pruebas
-	XMLPath    string          // ruta de los archivos XML
-	CertPath   string          // ruta de los certificados
-	TempPath   string          // ruta de archivos temporales
-	soapClient soap.SOAPClient // cliente SOAP para comunicación con SUNAT
+	isProd        bool                 // true para producción, false para pruebas
+	XMLPath       string               // ruta de los archivos XML
+	CertPath      string               // ruta de los certificados
+	TempPath      string               // ruta de archivos temporales
+	clients       *httpclient.Factory  // clientes HTTP de las llamadas a SUNAT
+	endpoints     config.SOAPEndpoints // URLs de los servicios SOAP
+	restClient    *soap.RESTClient     // cliente REST para guías de remisión
+	validezClient *soap.ValidezClient  // cliente de la consulta de validez de comprobantes
+}
+
+var (
+	greTokensOnce    sync.Once
+	greTokensManager *oauth.TokenManager
+)
+
+// greTokens retorna el gestor de tokens de la API de guías de remisión,
+// compartido por todos los servicios para reutilizar los tokens en caché
+func greTokens() *oauth.TokenManager {
+	greTokensOnce.Do(func() {
+		greTokensManager = oauth.NewTokenManager(config.GetGRECredentials("").TokenURL, soap.GREScope,
+			func(ruc string) (oauth.Credentials, error) {
+				creds := config.GetGRECredentials(ruc)
+				return oauth.Credentials{
+					ClientID:     creds.ClientID,
+					ClientSecret: creds.ClientSecret,
+					Username:     creds.Username,
+					Password:     creds.Password,
+				}, nil
+			})
+		greTokensManager.HTTPClient = httpFactory().Client(httpclient.OpToken)
+	})
+	return greTokensManager
+}
+
+var (
+	httpFactoryOnce sync.Once
+	httpFactoryInst *httpclient.Factory
+)
+
+// httpFactory retorna la fábrica de clientes HTTP compartida por todas las
+// llamadas a SUNAT, para reutilizar sus conexiones
+func httpFactory() *httpclient.Factory {
+	httpFactoryOnce.Do(func() {
+		factory, err := httpclient.NewFactory(config.GetHTTPClientConfig())
+		if err != nil {
+			panic(fmt.Sprintf("error configurando el cliente HTTP de SUNAT: %v", err))
+		}
+		httpFactoryInst = factory
+	})
+	return httpFactoryInst
 }
 
 // NewService crea una nueva instancia del servicio SUNAT
@@ -44,33 +108,81 @@ func NewService(isProd bool) Service {
 		}
 	}
 
+	clients := httpFactory()
+	restClient := soap.NewRESTClient(config.GetGRECredentials("").APIURL, greTokens())
+	restClient.HTTPClient = clients.Client(httpclient.OpGRE)
+	validezClient := soap.NewValidezClient(config.GetValidezCredentials("").APIURL, validezTokens())
+	validezClient.HTTPClient = clients.Client(httpclient.OpValidez)
+
 	return &service{
-		isProd:     isProd,
-		XMLPath:    "xml",
-		CertPath:   "certificados",
-		TempPath:   "temp",
-		soapClient: soap.NewSOAPClient(isProd),
+		isProd:        isProd,
+		XMLPath:       "xml",
+		CertPath:      "certificados",
+		TempPath:      "temp",
+		clients:       clients,
+		endpoints:     config.GetSOAPEndpoints(isProd),
+		restClient:    restClient,
+		validezClient: validezClient,
+	}
+}
+
+var (
+	exchangeArchive      soap.Recorder
+	exchangeArchiveMutex = &sync.RWMutex{}
+)
+
+// SetExchangeArchive configura el archivo en disco de los intercambios SOAP
+// para auditoría; con nil no se archivan
+func SetExchangeArchive(recorder soap.Recorder) {
+	exchangeArchiveMutex.Lock()
+	defer exchangeArchiveMutex.Unlock()
+	exchangeArchive = recorder
+}
+
+// recorder registra los intercambios SOAP en el log de la solicitud y, si
+// está configurado, en el archivo de intercambios
+func (s *service) recorder(ctx context.Context) soap.Recorder {
+	recorders := soap.Recorders{&soap.LogRecorder{Logger: logging.FromContext(ctx)}}
+
+	exchangeArchiveMutex.RLock()
+	defer exchangeArchiveMutex.RUnlock()
+	if exchangeArchive != nil {
+		recorders = append(recorders, exchangeArchive)
 	}
+	return recorders
 }
 
 // SendInvoice envía una factura a SUNAT
-func (s *service) SendInvoice(filename string) (string, error) {
-	return s.sendBill(filename)
+func (s *service) SendInvoice(ctx context.Context, filename string) (*EnvioResultado, error) {
+	return s.sendBill(ctx, s.getBillServiceEndpoint(), filename)
 }
 
 // SendCreditNote envía una nota de crédito a SUNAT
-func (s *service) SendCreditNote(filename string) (string, error) {
-	return s.sendBill(filename)
+func (s *service) SendCreditNote(ctx context.Context, filename string) (*EnvioResultado, error) {
+	return s.sendBill(ctx, s.getBillServiceEndpoint(), filename)
 }
 
 // SendDebitNote envía una nota de débito a SUNAT
-func (s *service) SendDebitNote(filename string) (string, error) {
-	return s.sendBill(filename)
+func (s *service) SendDebitNote(ctx context.Context, filename string) (*EnvioResultado, error) {
+	return s.sendBill(ctx, s.getBillServiceEndpoint(), filename)
 }
 
-func (s *service) sendBill(filename string) (string, error) {
+// SendRetention envía un comprobante de retención al servicio de otros CPE
+func (s *service) SendRetention(ctx context.Context, filename string) (*EnvioResultado, error) {
+	return s.sendBill(ctx, s.getOtrosCPEServiceEndpoint(), filename)
+}
+
+// SendPerception envía un comprobante de percepción al servicio de otros CPE
+func (s *service) SendPerception(ctx context.Context, filename string) (*EnvioResultado, error) {
+	return s.sendBill(ctx, s.getOtrosCPEServiceEndpoint(), filename)
+}
+
+// SendSummary envía un resumen diario (RC), una comunicación de baja (RA) o
+// una reversión de retenciones y percepciones (RR) y retorna el ticket para
+// consultar su estado. Las reversiones van al servicio de otros CPE.
+func (s *service) SendSummary(ctx context.Context, filename string) (string, error) {
 	zipFile := filepath.Join(s.TempPath, filepath.Base(filename)+".zip")
-	if err := ziputil.CreateZIP(filename, zipFile); err != nil {
+	if err := s.createZIP(ctx, filename, zipFile); err != nil {
 		return "", fmt.Errorf("error creando ZIP: %v", err)
 	}
 
@@ -79,120 +191,193 @@ func (s *service) sendBill(filename string) (string, error) {
 		return "", fmt.Errorf("error leyendo ZIP: %v", err)
 	}
 
-	encodedZip := base64.StdEncoding.EncodeToString(zipContent)
+	// SUNAT espera el ZIP nombrado como el resumen: RUC-TIPO-FECHA-CORRELATIVO.zip
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)) + ".zip"
+	partes := strings.SplitN(name, "-", 3)
+	endpoint := s.getBillServiceEndpoint()
+	if len(partes) > 1 && partes[1] == "RR" {
+		endpoint = s.getOtrosCPEServiceEndpoint()
+	}
+	ticket, err := s.soapClient(ctx, partes[0], httpclient.OpSendSummary).SendSummary(ctx, endpoint, name, zipContent)
+	if err != nil {
+		return "", fmt.Errorf("error enviando a SUNAT: %w", err)
+	}
+	return ticket, nil
+}
+
+// EnvioResultado resultado del envío síncrono de un comprobante
+type EnvioResultado struct {
+	CDRZip []byte
+	CDR    *cdr.CDR
+}
 
-	request := &struct {
-		XMLName     xml.Name `xml:"ser:sendBill"`
-		FileName    string   `xml:"fileName"`
-		ContentFile string   `xml:"contentFile"`
-	}{
-		FileName:    filepath.Base(zipFile),
-		ContentFile: encodedZip,
+func (s *service) sendBill(ctx context.Context, endpoint, filename string) (*EnvioResultado, error) {
+	zipFile := filepath.Join(s.TempPath, filepath.Base(filename)+".zip")
+	if err := s.createZIP(ctx, filename, zipFile); err != nil {
+		return nil, fmt.Errorf("error creando ZIP: %v", err)
 	}
 
-	response := &struct {
-		XMLName xml.Name `xml:"sendBillResponse"`
-		Ticket  string   `xml:"ticket"`
-	}{}
+	zipContent, err := ioutil.ReadFile(zipFile)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo ZIP: %v", err)
+	}
 
-	endpoint := s.getBillServiceEndpoint()
-	if err := s.soapClient.Call(endpoint, "urn:sendBill", request, response); err != nil {
-		return "", fmt.Errorf("error enviando a SUNAT: %v", err)
+	// SUNAT espera el ZIP nombrado como el documento: RUC-TIPO-SERIE-NUMERO.zip
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename)) + ".zip"
+	ruc := strings.SplitN(name, "-", 2)[0]
+	cdrZip, err := s.soapClient(ctx, ruc, httpclient.OpSendBill).SendBill(ctx, endpoint, name, zipContent)
+	if err != nil {
+		return nil, fmt.Errorf("error enviando a SUNAT: %w", err)
+	}
+
+	constancia, err := cdr.Parse(cdrZip)
+	if err != nil {
+		return nil, err
 	}
+	return &EnvioResultado{CDRZip: cdrZip, CDR: constancia}, nil
+}
 
-	return response.Ticket, nil
+// ConsultaResultado respuesta de getStatus (por comprobante o por ticket) y getStatusCdr
+type ConsultaResultado struct {
+	Codigo  string   `json:"codigo"`
+	Mensaje string   `json:"mensaje"`
+	CDRZip  []byte   `json:"-"`
+	CDR     *cdr.CDR `json:"cdr,omitempty"`
 }
 
-// ConsultaCDR consulta el CDR de un comprobante
-func (s *service) ConsultaCDR(ruc, tipo, serie, numero string) (string, error) {
-	endpoint := s.getConsultServiceEndpoint()
-	request := &struct {
-		XMLName   xml.Name `xml:"getStatus"`
-		RucEmisor string   `xml:"rucComprobante"`
-		TipoComp  string   `xml:"tipoComprobante"`
-		Serie     string   `xml:"serieComprobante"`
-		Numero    string   `xml:"numeroComprobante"`
-	}{
-		RucEmisor: ruc,
-		TipoComp:  tipo,
-		Serie:     serie,
-		Numero:    numero,
+// ConsultaCDR obtiene el CDR de un comprobante propio (getStatusCdr)
+func (s *service) ConsultaCDR(ctx context.Context, ruc, tipo, serie, numero string) (*ConsultaResultado, error) {
+	ref := soap.ComprobanteRef{RUC: ruc, Tipo: tipo, Serie: serie, Numero: numero}
+	status, err := s.soapClient(ctx, ruc, httpclient.OpGetStatusCdr).GetStatusCdr(ctx, s.getConsultServiceEndpoint(), ref)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando CDR: %w", err)
 	}
 
-	response := &struct {
-		XMLName xml.Name `xml:"getStatusResponse"`
-		Return  string   `xml:"statusResponse"`
-	}{}
+	return buildConsultaResultado(status)
+}
 
-	if err := s.soapClient.Call(endpoint, "urn:getStatus", request, response); err != nil {
-		return "", fmt.Errorf("error consultando CDR: %v", err)
+// ConsultaEstado consulta el estado de un comprobante propio (getStatus)
+func (s *service) ConsultaEstado(ctx context.Context, ruc, tipo, serie, numero string) (*ConsultaResultado, error) {
+	ref := soap.ComprobanteRef{RUC: ruc, Tipo: tipo, Serie: serie, Numero: numero}
+	status, err := s.soapClient(ctx, ruc, httpclient.OpGetStatus).GetStatusComprobante(ctx, s.getConsultServiceEndpoint(), ref)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando estado: %w", err)
 	}
 
-	return response.Return, nil
+	return buildConsultaResultado(status)
 }
 
-// ConsultaEstado consulta el estado de un comprobante
-func (s *service) ConsultaEstado(ruc, tipo, serie, numero string) (string, error) {
-	endpoint := s.getConsultServiceEndpoint()
-	request := &struct {
-		XMLName   xml.Name `xml:"getStatus"`
-		RucEmisor string   `xml:"rucComprobante"`
-		TipoComp  string   `xml:"tipoComprobante"`
-		Serie     string   `xml:"serieComprobante"`
-		Numero    string   `xml:"numeroComprobante"`
-	}{
-		RucEmisor: ruc,
-		TipoComp:  tipo,
-		Serie:     serie,
-		Numero:    numero,
+// buildConsultaResultado interpreta la respuesta y, si la hay, el CDR adjunto
+func buildConsultaResultado(status *soap.StatusResponse) (*ConsultaResultado, error) {
+	resultado := &ConsultaResultado{
+		Codigo:  strings.TrimSpace(status.StatusCode),
+		Mensaje: strings.TrimSpace(status.StatusMessage),
+	}
+	if resultado.Mensaje == "" {
+		resultado.Mensaje, _ = catalog.GetCodigoConsulta(resultado.Codigo)
 	}
 
-	response := &struct {
-		XMLName xml.Name `xml:"getStatusResponse"`
-		Return  string   `xml:"statusResponse"`
-	}{}
+	content := strings.TrimSpace(status.Content)
+	if content == "" {
+		return resultado, nil
+	}
+	zipContent, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("error decodificando CDR: %v", err)
+	}
+	constancia, err := cdr.Parse(zipContent)
+	if err != nil {
+		return nil, err
+	}
+	resultado.CDRZip = zipContent
+	resultado.CDR = constancia
+	return resultado, nil
+}
+
+// Códigos de respuesta de getStatus con ticket
+const (
+	TicketProcesado  = "0"  // procesó correctamente
+	TicketEnProceso  = "98" // en proceso
+	TicketConErrores = "99" // procesó con errores
+)
+
+// ConsultaTicket consulta el estado de un ticket del servicio de facturas
+// (resúmenes diarios y comunicaciones de baja). SUNAT solo responde al
+// emisor que envió el resumen, por lo que se usa el usuario SOL de ruc.
+func (s *service) ConsultaTicket(ctx context.Context, ruc, ticket string) (*ConsultaResultado, error) {
+	return s.consultaTicket(ctx, s.getBillServiceEndpoint(), ruc, ticket)
+}
+
+// ConsultaTicketOtrosCPE consulta el estado de un ticket del servicio de otros CPE
+// (retenciones y percepciones) con el usuario SOL del emisor ruc
+func (s *service) ConsultaTicketOtrosCPE(ctx context.Context, ruc, ticket string) (*ConsultaResultado, error) {
+	return s.consultaTicket(ctx, s.getOtrosCPEServiceEndpoint(), ruc, ticket)
+}
 
-	if err := s.soapClient.Call(endpoint, "urn:getStatus", request, response); err != nil {
-		return "", fmt.Errorf("error consultando estado: %v", err)
+func (s *service) consultaTicket(ctx context.Context, endpoint, ruc, ticket string) (*ConsultaResultado, error) {
+	status, err := s.soapClient(ctx, ruc, httpclient.OpGetStatus).GetStatus(ctx, endpoint, ticket)
+	if err != nil {
+		return nil, fmt.Errorf("error consultando ticket: %w", err)
 	}
 
-	return response.Return, nil
+	return buildConsultaResultado(status)
 }
 
-// ConsultaTicket consulta el estado de un ticket
-func (s *service) ConsultaTicket(ticket string) (string, error) {
-	endpoint := s.getConsultServiceEndpoint()
-	request := &struct {
-		XMLName xml.Name `xml:"getStatus"`
-		Ticket  string   `xml:"ticket"`
-	}{
-		Ticket: ticket,
+// SendDespatchAdvice envía una guía de remisión a la API REST de SUNAT y
+// retorna el ticket para consultar su estado
+func (s *service) SendDespatchAdvice(ctx context.Context, filename string) (string, error) {
+	zipFile := filepath.Join(s.TempPath, filepath.Base(filename)+".zip")
+	if err := s.createZIP(ctx, filename, zipFile); err != nil {
+		return "", fmt.Errorf("error creando ZIP: %v", err)
 	}
 
-	response := &struct {
-		XMLName xml.Name `xml:"getStatusResponse"`
-		Return  string   `xml:"statusResponse"`
-	}{}
+	zipContent, err := ioutil.ReadFile(zipFile)
+	if err != nil {
+		return "", fmt.Errorf("error leyendo ZIP: %v", err)
+	}
 
-	if err := s.soapClient.Call(endpoint, "urn:getStatus", request, response); err != nil {
-		return "", fmt.Errorf("error consultando ticket: %v", err)
+	// SUNAT espera el ZIP nombrado como el documento: RUC-TIPO-SERIE-NUMERO.zip
+	name := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	ruc := strings.SplitN(name, "-", 2)[0]
+	ticket, err := s.restClient.SendGRE(ctx, ruc, name+".zip", zipContent)
+	if err != nil {
+		return "", fmt.Errorf("error enviando a SUNAT: %w", err)
 	}
 
-	return response.Return, nil
+	return ticket.NumTicket, nil
+}
+
+// ConsultaTicketGRE consulta el estado del envío de una guía de remisión del emisor ruc
+func (s *service) ConsultaTicketGRE(ctx context.Context, ruc, ticket string) (*soap.GREStatus, error) {
+	return s.restClient.GREStatus(ctx, ruc, ticket)
+}
+
+// soapClient crea el cliente SOAP de la operación con el usuario SOL del emisor ruc
+func (s *service) soapClient(ctx context.Context, ruc, operacion string) *soap.Client {
+	sol := config.GetSOLCredentials(ruc)
+	client := soap.NewClient(sol.Username, sol.Password, s.clients.Client(operacion))
+	client.Recorder = s.recorder(ctx)
+	return client
+}
+
+// createZIP comprime el XML del documento en zipFile
+func (s *service) createZIP(ctx context.Context, filename, zipFile string) (err error) {
+	documento := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	_, span := tracing.Start(ctx, "CreateZIP", tracing.AttrDocumento.String(documento))
+	defer func() { tracing.End(span, err) }()
+	return ziputil.CreateZIP(filename, zipFile)
 }
 
 func (s *service) getConsultServiceEndpoint() string {
-	if s.isProd {
-		return "https://e-factura.sunat.gob.pe/ol-it-wsconscpegem/billConsultService"
-	}
-	return "https://e-beta.sunat.gob.pe/ol-it-wsconscpegem-beta/billConsultService"
+	return s.endpoints.ConsultService
+}
+
+func (s *service) getOtrosCPEServiceEndpoint() string {
+	return s.endpoints.OtrosCPEService
 }
 
 func (s *service) getBillServiceEndpoint() string {
-	if s.isProd {
-		return "https://e-factura.sunat.gob.pe/ol-ti-itcpfegem/billService"
-	}
-	return "https://e-beta.sunat.gob.pe/ol-ti-itcpfegem-beta/billService"
+	return s.endpoints.BillService
 }
 
 // PrepareAndValidate prepara y valida los archivos necesarios para el envío
@@ -0,0 +1,113 @@
+package sunat
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/oauth"
+	"ubl-converter/internal/pkg/soap"
+)
+
+// ValidezCacheTTL tiempo durante el cual se reutiliza el resultado de una
+// consulta de validez. Los comprobantes que aún no existen en SUNAT no se
+// guardan, pues pueden registrarse en cualquier momento.
+const ValidezCacheTTL = time.Hour
+
+// ValidezCacheMax cantidad máxima de consultas de validez en caché; al
+// llenarse se descartan las vencidas y, si no basta, las más próximas a vencer
+const ValidezCacheMax = 10000
+
+type validezEntry struct {
+	resultado soap.ValidezResultado
+	expira    time.Time
+}
+
+var (
+	validezCache    = make(map[string]validezEntry)
+	validezLimpieza time.Time // última vez que se descartaron las consultas vencidas
+	validezMutex    = &sync.RWMutex{}
+
+	validezTokensOnce    sync.Once
+	validezTokensManager *oauth.TokenManager
+)
+
+// validezTokens retorna el gestor de tokens de la consulta de validez
+func validezTokens() *oauth.TokenManager {
+	validezTokensOnce.Do(func() {
+		validezTokensManager = oauth.NewTokenManager(config.GetValidezCredentials("").TokenURL, soap.ValidezScope,
+			func(ruc string) (oauth.Credentials, error) {
+				creds := config.GetValidezCredentials(ruc)
+				return oauth.Credentials{
+					ClientID:     creds.ClientID,
+					ClientSecret: creds.ClientSecret,
+				}, nil
+			})
+		validezTokensManager.HTTPClient = httpFactory().Client(httpclient.OpToken)
+	})
+	return validezTokensManager
+}
+
+// ConsultaValidez consulta la validez de un comprobante emitido por un tercero
+func (s *service) ConsultaValidez(ctx context.Context, rucConsultante string, comprobante soap.ComprobanteConsulta) (*soap.ValidezResultado, error) {
+	key := strings.Join([]string{comprobante.NumRuc, comprobante.CodComp, comprobante.NumeroSerie,
+		comprobante.Numero, comprobante.FechaEmision, comprobante.Monto}, "|")
+
+	validezMutex.RLock()
+	entry, found := validezCache[key]
+	validezMutex.RUnlock()
+	if found && time.Now().Before(entry.expira) {
+		resultado := entry.resultado
+		return &resultado, nil
+	}
+	if found {
+		validezMutex.Lock()
+		if entry, ok := validezCache[key]; ok && !time.Now().Before(entry.expira) {
+			delete(validezCache, key)
+		}
+		validezMutex.Unlock()
+	}
+
+	resultado, err := s.validezClient.ValidarComprobante(ctx, rucConsultante, comprobante)
+	if err != nil {
+		return nil, err
+	}
+
+	if resultado.EstadoCp != catalog.EstadoComprobanteNoExiste {
+		guardarValidez(key, *resultado)
+	}
+	return resultado, nil
+}
+
+// guardarValidez agrega una consulta a la caché. Las vencidas se descartan
+// una vez por ValidezCacheTTL o cuando la caché está llena; si aun así no
+// hay espacio, se descarta la más próxima a vencer.
+func guardarValidez(key string, resultado soap.ValidezResultado) {
+	now := time.Now()
+
+	validezMutex.Lock()
+	defer validezMutex.Unlock()
+
+	if _, found := validezCache[key]; !found && (len(validezCache) >= ValidezCacheMax || now.Sub(validezLimpieza) >= ValidezCacheTTL) {
+		for k, entry := range validezCache {
+			if !now.Before(entry.expira) {
+				delete(validezCache, k)
+			}
+		}
+		validezLimpieza = now
+	}
+	if _, found := validezCache[key]; !found && len(validezCache) >= ValidezCacheMax {
+		var proxima string
+		for k, entry := range validezCache {
+			if proxima == "" || entry.expira.Before(validezCache[proxima].expira) {
+				proxima = k
+			}
+		}
+		delete(validezCache, proxima)
+	}
+	validezCache[key] = validezEntry{resultado: resultado, expira: now.Add(ValidezCacheTTL)}
+}
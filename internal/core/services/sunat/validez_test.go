@@ -0,0 +1,171 @@
+package sunat
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"ubl-converter/internal/pkg/oauth"
+	"ubl-converter/internal/pkg/soap"
+	"ubl-converter/internal/pkg/sunatfake"
+)
+
+const rucConsultante = "20123456789"
+
+// nuevoServicioValidez crea un servicio que consulta la validez contra el
+// servidor falso, con la caché vacía
+func nuevoServicioValidez(t *testing.T) (*sunatfake.ValidezServer, *service) {
+	t.Helper()
+	srv := sunatfake.NewValidezServer("cid", "csec")
+	t.Cleanup(srv.Close)
+
+	validezMutex.Lock()
+	validezCache = make(map[string]validezEntry)
+	validezLimpieza = time.Time{}
+	validezMutex.Unlock()
+
+	tokens := oauth.NewTokenManager(srv.TokenURL(), soap.ValidezScope, func(ruc string) (oauth.Credentials, error) {
+		return oauth.Credentials{ClientID: "cid", ClientSecret: "csec"}, nil
+	})
+	return srv, &service{
+		validezClient: soap.NewValidezClient(srv.APIURL(), tokens),
+	}
+}
+
+func consultaPrueba(numero string) soap.ComprobanteConsulta {
+	return soap.ComprobanteConsulta{
+		NumRuc:       "20987654321",
+		CodComp:      "01",
+		NumeroSerie:  "F001",
+		Numero:       numero,
+		FechaEmision: "01/02/2024",
+		Monto:        "118.00",
+	}
+}
+
+func TestConsultaValidezYCache(t *testing.T) {
+	srv, s := nuevoServicioValidez(t)
+	srv.Registrar("20987654321", "01", "F001", "1", sunatfake.ValidezEstado{
+		EstadoCp:      "1",
+		EstadoRuc:     "00",
+		CondDomiRuc:   "00",
+		Observaciones: []string{"comprobante con observaciones"},
+	})
+
+	want := soap.ValidezResultado{
+		EstadoCp:      "1",
+		EstadoRuc:     "00",
+		CondDomiRuc:   "00",
+		Observaciones: []string{"comprobante con observaciones"},
+	}
+	for i := 0; i < 2; i++ {
+		resultado, err := s.ConsultaValidez(context.Background(), rucConsultante, consultaPrueba("1"))
+		if err != nil {
+			t.Fatalf("consulta %d: %v", i+1, err)
+		}
+		if !reflect.DeepEqual(*resultado, want) {
+			t.Fatalf("consulta %d = %+v, se esperaba %+v", i+1, *resultado, want)
+		}
+	}
+	if n := srv.Consultas(); n != 1 {
+		t.Fatalf("SUNAT recibió %d consultas, la segunda debió salir de la caché", n)
+	}
+}
+
+func TestConsultaValidezNoGuardaInexistentes(t *testing.T) {
+	srv, s := nuevoServicioValidez(t)
+
+	for i := 0; i < 2; i++ {
+		resultado, err := s.ConsultaValidez(context.Background(), rucConsultante, consultaPrueba("99"))
+		if err != nil {
+			t.Fatalf("consulta %d: %v", i+1, err)
+		}
+		if resultado.EstadoCp != "0" {
+			t.Fatalf("estadoCp = %s, se esperaba 0 (no existe)", resultado.EstadoCp)
+		}
+	}
+	if n := srv.Consultas(); n != 2 {
+		t.Fatalf("SUNAT recibió %d consultas, se esperaban 2", n)
+	}
+}
+
+func TestConsultaValidezVencida(t *testing.T) {
+	srv, s := nuevoServicioValidez(t)
+	srv.Registrar("20987654321", "01", "F001", "1", sunatfake.ValidezEstado{EstadoCp: "1"})
+
+	if _, err := s.ConsultaValidez(context.Background(), rucConsultante, consultaPrueba("1")); err != nil {
+		t.Fatal(err)
+	}
+	validezMutex.Lock()
+	for k, entry := range validezCache {
+		entry.expira = time.Now().Add(-time.Second)
+		validezCache[k] = entry
+	}
+	validezMutex.Unlock()
+
+	if _, err := s.ConsultaValidez(context.Background(), rucConsultante, consultaPrueba("1")); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Consultas(); n != 2 {
+		t.Fatalf("SUNAT recibió %d consultas, la consulta vencida debió repetirse", n)
+	}
+}
+
+// llenarCacheValidez llena la caché hasta ValidezCacheMax con consultas que
+// vencen dentro de una hora
+func llenarCacheValidez() {
+	validezMutex.Lock()
+	defer validezMutex.Unlock()
+	expira := time.Now().Add(ValidezCacheTTL)
+	for i := 0; len(validezCache) < ValidezCacheMax; i++ {
+		validezCache[fmt.Sprintf("k%d", i)] = validezEntry{expira: expira}
+	}
+}
+
+func TestGuardarValidezDescartaVencidas(t *testing.T) {
+	nuevoServicioValidez(t)
+	llenarCacheValidez()
+	validezMutex.Lock()
+	validezCache["k0"] = validezEntry{expira: time.Now().Add(-time.Second)}
+	validezCache["k1"] = validezEntry{expira: time.Now().Add(-time.Second)}
+	validezLimpieza = time.Now()
+	validezMutex.Unlock()
+
+	guardarValidez("nueva", soap.ValidezResultado{EstadoCp: "1"})
+
+	validezMutex.RLock()
+	defer validezMutex.RUnlock()
+	if n := len(validezCache); n != ValidezCacheMax-1 {
+		t.Fatalf("la caché tiene %d consultas, se esperaban %d", n, ValidezCacheMax-1)
+	}
+	if _, found := validezCache["k0"]; found {
+		t.Fatal("la consulta vencida sigue en la caché")
+	}
+	if _, found := validezCache["nueva"]; !found {
+		t.Fatal("la consulta nueva no se guardó")
+	}
+}
+
+func TestGuardarValidezDescartaLaProximaAVencer(t *testing.T) {
+	nuevoServicioValidez(t)
+	llenarCacheValidez()
+	validezMutex.Lock()
+	validezCache["k5"] = validezEntry{expira: time.Now().Add(time.Minute)}
+	validezMutex.Unlock()
+
+	guardarValidez("nueva", soap.ValidezResultado{EstadoCp: "1"})
+
+	validezMutex.RLock()
+	defer validezMutex.RUnlock()
+	if n := len(validezCache); n != ValidezCacheMax {
+		t.Fatalf("la caché tiene %d consultas, se esperaban %d", n, ValidezCacheMax)
+	}
+	if _, found := validezCache["k5"]; found {
+		t.Fatal("no se descartó la consulta más próxima a vencer")
+	}
+	if _, found := validezCache["nueva"]; !found {
+		t.Fatal("la consulta nueva no se guardó")
+	}
+}
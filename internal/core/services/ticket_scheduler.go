@@ -0,0 +1,435 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ubl-converter/internal/core/services/sunat"
+	"ubl-converter/internal/pkg/cdr"
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/metrics"
+	"ubl-converter/internal/pkg/soap"
+)
+
+// Tipos de ticket según el servicio de SUNAT que los emite
+const (
+	TicketResumen  = "resumen"   // resumen diario de boletas (billService)
+	TicketBaja     = "baja"      // comunicación de baja (billService)
+	TicketOtrosCPE = "otros_cpe" // resúmenes y bajas de retenciones y percepciones
+	TicketGRE      = "gre"       // guía de remisión (API REST)
+)
+
+// Estados de un ticket
+const (
+	TicketPendiente = "en proceso"
+	TicketAceptado  = "aceptado"
+	TicketRechazado = "rechazado"
+	TicketError     = "error"
+)
+
+// ErrTicketAjeno indica que el ticket ya está registrado para otro RUC
+var ErrTicketAjeno = errors.New("el ticket ya está registrado para otro RUC")
+
+// TicketConsultor consulta el estado de los tickets en SUNAT
+type TicketConsultor interface {
+	ConsultaTicket(ctx context.Context, ruc, ticket string) (*sunat.ConsultaResultado, error)
+	ConsultaTicketOtrosCPE(ctx context.Context, ruc, ticket string) (*sunat.ConsultaResultado, error)
+	ConsultaTicketGRE(ctx context.Context, ruc, ticket string) (*soap.GREStatus, error)
+}
+
+// TicketData ticket de una operación asíncrona de SUNAT
+type TicketData struct {
+	Ticket          string    `json:"ticket"`
+	Tipo            string    `json:"tipo"`
+	RUC             string    `json:"ruc"`
+	DocumentID      string    `json:"document_id,omitempty"` // documento vinculado
+	Estado          string    `json:"estado"`
+	Codigo          string    `json:"codigo,omitempty"`
+	Mensaje         string    `json:"mensaje,omitempty"`
+	Intentos        int       `json:"intentos"`
+	ProximaConsulta time.Time `json:"proxima_consulta,omitempty"`
+	Registrado      time.Time `json:"registrado"`
+	Actualizado     time.Time `json:"actualizado"`
+	CDR             *cdr.CDR  `json:"cdr,omitempty"`
+}
+
+// TicketScheduler consulta periódicamente los tickets pendientes hasta que
+// SUNAT termina de procesarlos, con una espera que se duplica en cada intento
+type TicketScheduler struct {
+	consultor TicketConsultor
+	config    config.TicketPollingConfig
+
+	mu      sync.Mutex
+	tickets map[string]*TicketData
+	stop    chan struct{}
+	done    chan struct{}
+	now     func() time.Time
+}
+
+// NewTicketScheduler crea un planificador de consultas de tickets. Si
+// cfg.Archivo está configurado, los tickets pendientes se guardan en ese
+// archivo y se retoman al reiniciar; sin archivo se pierden con el proceso.
+// Los tickets terminados se descartan cfg.Retencion después de su última
+// actualización.
+func NewTicketScheduler(consultor TicketConsultor, cfg config.TicketPollingConfig) (*TicketScheduler, error) {
+	s := &TicketScheduler{
+		consultor: consultor,
+		config:    cfg,
+		tickets:   make(map[string]*TicketData),
+		now:       time.Now,
+	}
+	if err := s.cargar(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// cargar recupera los tickets guardados en el archivo y marca como en
+// proceso los documentos vinculados a los pendientes
+func (s *TicketScheduler) cargar() error {
+	if s.config.Archivo == "" {
+		return nil
+	}
+	content, err := os.ReadFile(s.config.Archivo)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error leyendo los tickets guardados: %v", err)
+	}
+	var tickets []TicketData
+	if err := json.Unmarshal(content, &tickets); err != nil {
+		return fmt.Errorf("error leyendo los tickets guardados en %s: %v", s.config.Archivo, err)
+	}
+
+	for i := range tickets {
+		data := tickets[i]
+		if data.Estado != TicketPendiente {
+			continue
+		}
+		s.tickets[data.Ticket] = &data
+		if data.DocumentID != "" {
+			SaveDocumentStatus(data.DocumentID, TicketPendiente)
+		}
+	}
+	s.publicarPendientes()
+	return nil
+}
+
+// guardar escribe los tickets pendientes en el archivo, reemplazándolo de
+// forma atómica; los terminados no se retoman al reiniciar y no se guardan.
+// Se llama con s.mu tomado.
+func (s *TicketScheduler) guardar() {
+	if s.config.Archivo == "" {
+		return
+	}
+	tickets := make([]TicketData, 0, len(s.tickets))
+	for _, data := range s.tickets {
+		if data.Estado == TicketPendiente {
+			tickets = append(tickets, *data)
+		}
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].Registrado.Before(tickets[j].Registrado) })
+
+	content, err := json.MarshalIndent(tickets, "", "  ")
+	if err == nil {
+		tmp := s.config.Archivo + ".tmp"
+		if err = os.WriteFile(tmp, content, 0600); err == nil {
+			err = os.Rename(tmp, s.config.Archivo)
+		}
+	}
+	if err != nil {
+		slog.Error("no se pudieron guardar los tickets", "archivo", s.config.Archivo, "error", err.Error())
+	}
+}
+
+var (
+	ticketScheduler      *TicketScheduler
+	ticketSchedulerMutex = &sync.RWMutex{}
+)
+
+// SetTicketScheduler define el planificador al que se envían los tickets registrados
+func SetTicketScheduler(scheduler *TicketScheduler) {
+	ticketSchedulerMutex.Lock()
+	defer ticketSchedulerMutex.Unlock()
+	ticketScheduler = scheduler
+}
+
+// GetTicketScheduler retorna el planificador configurado o nil
+func GetTicketScheduler() *TicketScheduler {
+	ticketSchedulerMutex.RLock()
+	defer ticketSchedulerMutex.RUnlock()
+	return ticketScheduler
+}
+
+// Registrar agrega un ticket para su consulta periódica y marca el documento
+// vinculado como en proceso. SUNAT solo informa el estado de un ticket al
+// emisor que lo generó, por lo que ruc es obligatorio; el documento vinculado
+// debe ser del mismo RUC y un ticket ya registrado solo puede volver a
+// registrarlo su emisor.
+func (s *TicketScheduler) Registrar(ticket, tipo, ruc, documentID string) (TicketData, error) {
+	if ticket == "" {
+		return TicketData{}, fmt.Errorf("ticket requerido")
+	}
+	switch tipo {
+	case TicketResumen, TicketBaja, TicketOtrosCPE, TicketGRE:
+	default:
+		return TicketData{}, fmt.Errorf("tipo de ticket inválido: %s", tipo)
+	}
+	if ruc == "" {
+		return TicketData{}, fmt.Errorf("el RUC del emisor es obligatorio para consultar el ticket")
+	}
+	if documentID != "" && !strings.HasPrefix(documentID, ruc+"-") {
+		return TicketData{}, fmt.Errorf("el documento %s no pertenece al RUC %s", documentID, ruc)
+	}
+
+	now := s.now()
+	data := &TicketData{
+		Ticket:          ticket,
+		Tipo:            tipo,
+		RUC:             ruc,
+		DocumentID:      documentID,
+		Estado:          TicketPendiente,
+		ProximaConsulta: now.Add(s.config.Intervalo),
+		Registrado:      now,
+		Actualizado:     now,
+	}
+
+	s.mu.Lock()
+	if actual, ok := s.tickets[ticket]; ok && actual.RUC != ruc {
+		s.mu.Unlock()
+		return TicketData{}, fmt.Errorf("%w: %s", ErrTicketAjeno, ticket)
+	}
+	s.tickets[ticket] = data
+	s.publicarPendientes()
+	s.guardar()
+	s.mu.Unlock()
+
+	if documentID != "" {
+		SaveDocumentStatus(documentID, TicketPendiente)
+	}
+	return *data, nil
+}
+
+// Get retorna el estado de un ticket registrado
+func (s *TicketScheduler) Get(ticket string) (TicketData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.tickets[ticket]
+	if !ok {
+		return TicketData{}, false
+	}
+	return *data, true
+}
+
+// Start inicia la consulta periódica en segundo plano
+func (s *TicketScheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	stop, done := s.stop, s.done
+	s.mu.Unlock()
+
+	// Se revisa con la frecuencia del intervalo mínimo; cada ticket tiene su
+	// propia hora de próxima consulta
+	tick := s.config.Intervalo
+	if tick > 5*time.Second {
+		tick = 5 * time.Second
+	}
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.Poll()
+			}
+		}
+	}()
+}
+
+// Stop detiene la consulta periódica y espera a que termine la ronda en curso
+func (s *TicketScheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// Poll consulta los tickets cuya próxima consulta ya venció y descarta los
+// terminados hace más de la retención configurada
+func (s *TicketScheduler) Poll() {
+	now := s.now()
+	var pendientes []TicketData
+	s.mu.Lock()
+	for ticket, data := range s.tickets {
+		switch {
+		case data.Estado == TicketPendiente:
+			if !now.Before(data.ProximaConsulta) {
+				pendientes = append(pendientes, *data)
+			}
+		case !now.Before(data.Actualizado.Add(s.config.Retencion)):
+			delete(s.tickets, ticket)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, data := range pendientes {
+		s.consultar(data)
+	}
+}
+
+// consultar consulta un ticket y actualiza su estado y el del documento
+// vinculado. Si mientras tanto el ticket se volvió a registrar, el resultado
+// se descarta para no pisar el registro nuevo.
+func (s *TicketScheduler) consultar(data TicketData) {
+	data.Intentos++
+	codigo, mensaje, zipContent, err := s.consultarSUNAT(data)
+	if err != nil {
+		slog.Warn("error consultando ticket", "ticket", data.Ticket, "tipo", data.Tipo, "intento", data.Intentos, "error", err.Error())
+		data.Mensaje = err.Error()
+	} else {
+		data.Codigo, data.Mensaje = codigo, mensaje
+	}
+
+	if err == nil && zipContent != nil {
+		constancia, parseErr := cdr.Parse(zipContent)
+		if parseErr != nil {
+			slog.Error("error leyendo CDR del ticket", "ticket", data.Ticket, "error", parseErr.Error())
+		} else {
+			data.CDR = constancia
+		}
+	}
+
+	switch {
+	case err == nil && codigo == sunat.TicketProcesado:
+		data.Estado = TicketAceptado
+		if data.CDR != nil && !data.CDR.Accepted() {
+			data.Estado = TicketRechazado
+		}
+	case err == nil && codigo == sunat.TicketConErrores:
+		data.Estado = TicketRechazado
+	case err != nil && !reintentable(err):
+		data.Estado = TicketError
+	case data.Intentos >= s.config.MaxIntentos:
+		data.Estado = TicketError
+		data.Mensaje = fmt.Sprintf("sin respuesta definitiva tras %d consultas: %s", data.Intentos, data.Mensaje)
+	default:
+		// En proceso (98) o error de comunicación: reintentar más tarde
+		data.ProximaConsulta = s.now().Add(s.espera(data.Intentos))
+	}
+	data.Actualizado = s.now()
+
+	s.mu.Lock()
+	actual, ok := s.tickets[data.Ticket]
+	if !ok || !actual.Registrado.Equal(data.Registrado) || actual.DocumentID != data.DocumentID {
+		s.mu.Unlock()
+		slog.Info("ticket registrado de nuevo durante la consulta; se descarta el resultado", "ticket", data.Ticket)
+		return
+	}
+	s.tickets[data.Ticket] = &data
+	s.publicarPendientes()
+	s.guardar()
+	s.mu.Unlock()
+
+	if data.DocumentID == "" {
+		return
+	}
+	switch {
+	case data.CDR != nil:
+		SaveCDR(data.DocumentID, data.CDR, zipContent)
+	case data.Estado != TicketPendiente:
+		SaveDocumentStatus(data.DocumentID, data.Estado)
+	}
+}
+
+// publicarPendientes actualiza la métrica de tickets en proceso por tipo;
+// se llama con s.mu tomado
+func (s *TicketScheduler) publicarPendientes() {
+	pendientes := map[string]int{TicketResumen: 0, TicketBaja: 0, TicketOtrosCPE: 0, TicketGRE: 0}
+	for _, data := range s.tickets {
+		if data.Estado == TicketPendiente {
+			pendientes[data.Tipo]++
+		}
+	}
+	for tipo, n := range pendientes {
+		metrics.SetTicketsPendientes(tipo, n)
+	}
+}
+
+// consultarSUNAT consulta el ticket en el servicio que corresponde a su tipo
+// y retorna el código de respuesta, el mensaje y el ZIP del CDR si lo hay
+func (s *TicketScheduler) consultarSUNAT(data TicketData) (string, string, []byte, error) {
+	// Las consultas en segundo plano no pertenecen a ninguna solicitud
+	ctx := context.Background()
+	if data.Tipo == TicketGRE {
+		status, err := s.consultor.ConsultaTicketGRE(ctx, data.RUC, data.Ticket)
+		if err != nil {
+			return "", "", nil, err
+		}
+		var mensaje string
+		if status.Error != nil {
+			mensaje = status.Error.NumError + " - " + status.Error.DesError
+		}
+		var zipContent []byte
+		if status.ArcCdr != "" {
+			zipContent, err = base64.StdEncoding.DecodeString(status.ArcCdr)
+			if err != nil {
+				return "", "", nil, fmt.Errorf("error decodificando CDR: %v", err)
+			}
+		}
+		return status.CodRespuesta, mensaje, zipContent, nil
+	}
+
+	var resultado *sunat.ConsultaResultado
+	var err error
+	if data.Tipo == TicketOtrosCPE {
+		resultado, err = s.consultor.ConsultaTicketOtrosCPE(ctx, data.RUC, data.Ticket)
+	} else {
+		resultado, err = s.consultor.ConsultaTicket(ctx, data.RUC, data.Ticket)
+	}
+	if err != nil {
+		return "", "", nil, err
+	}
+	return resultado.Codigo, resultado.Mensaje, resultado.CDRZip, nil
+}
+
+// reintentable indica si vale la pena volver a consultar tras un error; los
+// errores que no son de SUNAT (p. ej. de red) se reintentan
+func reintentable(err error) bool {
+	sunatErr, ok := soap.AsSunatError(err)
+	return !ok || sunatErr.Reintentable()
+}
+
+// espera calcula la espera antes del siguiente intento
+func (s *TicketScheduler) espera(intentos int) time.Duration {
+	espera := s.config.Intervalo
+	for i := 1; i < intentos && espera < s.config.IntervaloMax; i++ {
+		espera *= 2
+	}
+	if espera > s.config.IntervaloMax {
+		espera = s.config.IntervaloMax
+	}
+	return espera
+}
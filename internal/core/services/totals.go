@@ -0,0 +1,174 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/ubl"
+)
+
+// subtotalTributo acumula los importes de las líneas de un mismo tributo
+type subtotalTributo struct {
+	tributo   catalog.Tributo
+	categoria string
+	onerosa   bool
+	base      float64
+	impuesto  float64
+}
+
+// acumularSubtotal suma el valor de venta y el impuesto de una línea al subtotal de su tributo
+func acumularSubtotal(subtotales []subtotalTributo, afectacion catalog.AfectacionIGV, base, impuesto float64) []subtotalTributo {
+	for i := range subtotales {
+		if subtotales[i].tributo == afectacion.Tributo {
+			subtotales[i].base = round2(subtotales[i].base + base)
+			subtotales[i].impuesto = round2(subtotales[i].impuesto + impuesto)
+			return subtotales
+		}
+	}
+	return append(subtotales, subtotalTributo{
+		tributo:   afectacion.Tributo,
+		categoria: afectacion.Categoria,
+		onerosa:   afectacion.Onerosa,
+		base:      base,
+		impuesto:  impuesto,
+	})
+}
+
+// taxScheme construye el esquema de impuestos de un tributo del catálogo 05
+func taxScheme(t catalog.Tributo) ubl.TaxScheme {
+	return ubl.TaxScheme{
+		ID:          t.ID,
+		Name:        t.Nombre,
+		TaxTypeCode: t.Codigo,
+	}
+}
+
+// totales contiene los importes de cabecera de una factura
+type totales struct {
+	valorVenta  float64 // LineExtensionAmount: valor de venta de la operación
+	baseIGV     float64 // TaxableAmount del IGV
+	igv         float64 // TaxAmount del IGV
+	precioVenta float64 // TaxInclusiveAmount: precio de venta de la operación
+	descuentos  float64 // AllowanceTotalAmount: descuentos que no afectan la base
+	cargos      float64 // ChargeTotalAmount: cargos que no afectan la base
+	anticipos   float64 // PrepaidAmount: anticipos deducidos
+	importe     float64 // PayableAmount: importe total a pagar
+
+	// otrosTributos contiene los subtotales de las operaciones no gravadas con IGV
+	// (exportación, exoneradas, inafectas y gratuitas)
+	otrosTributos []subtotalTributo
+
+	// allowanceCharges contiene los cargos y descuentos globales de la solicitud
+	allowanceCharges []ubl.AllowanceCharge
+}
+
+// calcularTotales obtiene los totales de la factura a partir de los importes
+// de la solicitud y de los subtotales por tributo de las líneas. El total
+// gravado y el IGV pueden omitirse cuando no hay operaciones gravadas.
+// Los importes de la solicitud son previos a los cargos y descuentos
+// globales: los que afectan la base imponible recalculan la base y el IGV, y
+// los demás solo el importe a pagar. Los anticipos reducen la base, el IGV y
+// el importe a pagar.
+func calcularTotales(request *FacturaRequest, subtotales []subtotalTributo, anticipos *anticipos) (*totales, error) {
+	var otrosTributos []subtotalTributo
+	var valorNoGravado float64
+	for _, st := range subtotales {
+		if st.tributo == catalog.TributoIGV {
+			continue
+		}
+		otrosTributos = append(otrosTributos, st)
+		if st.onerosa {
+			valorNoGravado += st.base
+		}
+	}
+
+	totalGravado, err := parseImporteOpcional(request.Comprobante.TotalGravado, len(otrosTributos) > 0)
+	if err != nil {
+		return nil, fmt.Errorf("total gravado inválido: %v", err)
+	}
+	totalIGV, err := parseImporteOpcional(request.Comprobante.TotalIGV, len(otrosTributos) > 0)
+	if err != nil {
+		return nil, fmt.Errorf("total IGV inválido: %v", err)
+	}
+	total, err := strconv.ParseFloat(request.Comprobante.Total, 64)
+	if err != nil {
+		return nil, fmt.Errorf("total inválido: %v", err)
+	}
+
+	t := &totales{
+		valorVenta:    round2(totalGravado + valorNoGravado),
+		baseIGV:       totalGravado,
+		igv:           totalIGV,
+		precioVenta:   total,
+		otrosTributos: otrosTributos,
+	}
+
+	cargos, err := buildCargosGlobales(request, totalGravado)
+	if err != nil {
+		return nil, err
+	}
+	if cargos != nil {
+		t.baseIGV = round2(totalGravado - cargos.descuentosBase + cargos.cargosBase)
+		if totalGravado > 0 {
+			t.igv = round2(totalIGV * t.baseIGV / totalGravado)
+		}
+		t.precioVenta = round2(total + (t.baseIGV - totalGravado) + (t.igv - totalIGV))
+		t.descuentos = round2(cargos.descuentos)
+		t.cargos = round2(cargos.cargos)
+		t.allowanceCharges = cargos.allowanceCharges
+	}
+	t.importe = round2(t.precioVenta - t.descuentos + t.cargos)
+
+	if anticipos != nil {
+		if anticipos.total > t.importe {
+			return nil, fmt.Errorf("los anticipos (%.2f) superan el importe de la operación (%.2f)", anticipos.total, t.importe)
+		}
+		t.baseIGV = round2(t.baseIGV - anticipos.valorVenta)
+		t.igv = round2(t.igv - (anticipos.total - anticipos.valorVenta))
+		t.anticipos = anticipos.total
+		t.importe = round2(t.importe - anticipos.total)
+	}
+
+	return t, nil
+}
+
+// parseImporteOpcional interpreta un importe de la solicitud; si se permite, un importe vacío equivale a cero
+func parseImporteOpcional(s string, opcional bool) (float64, error) {
+	if s == "" && opcional {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// buildTaxTotal construye el total de impuestos de la factura con un subtotal
+// por tributo. El subtotal del IGV se omite cuando solo hay operaciones no
+// gravadas; el impuesto de las operaciones gratuitas es referencial y no
+// forma parte del total.
+func buildTaxTotal(t *totales, moneda string) []ubl.TaxTotal {
+	taxTotal := ubl.TaxTotal{
+		TaxAmount: ubl.MonetaryAmount{Value: t.igv, CurrencyID: moneda},
+	}
+	if t.baseIGV != 0 || len(t.otrosTributos) == 0 {
+		taxTotal.TaxSubtotal = append(taxTotal.TaxSubtotal, ubl.TaxSubtotal{
+			TaxableAmount: ubl.MonetaryAmount{Value: t.baseIGV, CurrencyID: moneda},
+			TaxAmount:     ubl.MonetaryAmount{Value: t.igv, CurrencyID: moneda},
+			TaxCategory: ubl.TaxCategory{
+				ID:        "S",
+				Percent:   18.0,
+				TaxScheme: taxScheme(catalog.TributoIGV),
+			},
+		})
+	}
+	for _, st := range t.otrosTributos {
+		taxTotal.TaxSubtotal = append(taxTotal.TaxSubtotal, ubl.TaxSubtotal{
+			TaxableAmount: ubl.MonetaryAmount{Value: st.base, CurrencyID: moneda},
+			TaxAmount:     ubl.MonetaryAmount{Value: st.impuesto, CurrencyID: moneda},
+			TaxCategory: ubl.TaxCategory{
+				ID:        st.categoria,
+				TaxScheme: taxScheme(st.tributo),
+			},
+		})
+	}
+	return []ubl.TaxTotal{taxTotal}
+}
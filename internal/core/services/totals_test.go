@@ -0,0 +1,168 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"ubl-converter/internal/pkg/catalog"
+)
+
+// lineaPrueba es el valor de venta y el impuesto de una línea con su tipo de afectación
+type lineaPrueba struct {
+	afectacion string
+	base       float64
+	impuesto   float64
+}
+
+// subtotalesPrueba acumula los subtotales por tributo de las líneas indicadas
+func subtotalesPrueba(t *testing.T, lineas ...lineaPrueba) []subtotalTributo {
+	t.Helper()
+	var subtotales []subtotalTributo
+	for _, l := range lineas {
+		afectacion, ok := catalog.GetAfectacionIGV(l.afectacion)
+		if !ok {
+			t.Fatalf("tipo de afectación inválido: %s", l.afectacion)
+		}
+		subtotales = acumularSubtotal(subtotales, afectacion, l.base, l.impuesto)
+	}
+	return subtotales
+}
+
+func TestCalcularTotales(t *testing.T) {
+	tests := []struct {
+		nombre    string
+		gravado   string
+		igv       string
+		total     string
+		cargos    []CargoDescuentoData
+		anticipos *anticipos
+		want      totales
+	}{
+		{
+			nombre:  "sin cargos ni descuentos",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			want: totales{valorVenta: 100, baseIGV: 100, igv: 18, precioVenta: 118, importe: 118},
+		},
+		{
+			nombre:  "descuento que afecta la base",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			cargos: []CargoDescuentoData{{Codigo: "02", Factor: "0.10"}},
+			want:   totales{valorVenta: 100, baseIGV: 90, igv: 16.2, precioVenta: 106.2, importe: 106.2},
+		},
+		{
+			nombre:  "descuento que no afecta la base",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			cargos: []CargoDescuentoData{{Codigo: "03", Monto: "10.00"}},
+			want:   totales{valorVenta: 100, baseIGV: 100, igv: 18, precioVenta: 118, descuentos: 10, importe: 108},
+		},
+		{
+			nombre:  "cargo que afecta la base",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			cargos: []CargoDescuentoData{{Codigo: "49", Monto: "10.00"}},
+			want:   totales{valorVenta: 100, baseIGV: 110, igv: 19.8, precioVenta: 129.8, importe: 129.8},
+		},
+		{
+			nombre:  "cargo que no afecta la base",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			cargos: []CargoDescuentoData{{Codigo: "50", Monto: "5.00"}},
+			want:   totales{valorVenta: 100, baseIGV: 100, igv: 18, precioVenta: 118, cargos: 5, importe: 123},
+		},
+		{
+			nombre:  "descuento y cargo combinados",
+			gravado: "200.00", igv: "36.00", total: "236.00",
+			cargos: []CargoDescuentoData{{Codigo: "02", Monto: "20.00"}, {Codigo: "50", Monto: "5.00"}},
+			want:   totales{valorVenta: 200, baseIGV: 180, igv: 32.4, precioVenta: 212.4, cargos: 5, importe: 217.4},
+		},
+		{
+			nombre:  "anticipo",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			anticipos: &anticipos{valorVenta: 50, total: 59},
+			want:      totales{valorVenta: 100, baseIGV: 50, igv: 9, precioVenta: 118, anticipos: 59, importe: 59},
+		},
+		{
+			nombre:  "anticipo por el total",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			anticipos: &anticipos{valorVenta: 100, total: 118},
+			want:      totales{valorVenta: 100, precioVenta: 118, anticipos: 118},
+		},
+		{
+			nombre:  "anticipo después de un descuento",
+			gravado: "100.00", igv: "18.00", total: "118.00",
+			cargos:    []CargoDescuentoData{{Codigo: "03", Monto: "8.00"}},
+			anticipos: &anticipos{valorVenta: 50, total: 59},
+			want:      totales{valorVenta: 100, baseIGV: 50, igv: 9, precioVenta: 118, descuentos: 8, anticipos: 59, importe: 51},
+		},
+	}
+	for _, tt := range tests {
+		request := &FacturaRequest{
+			Comprobante:      ComprobanteData{Moneda: "PEN", TotalGravado: tt.gravado, TotalIGV: tt.igv, Total: tt.total},
+			CargosDescuentos: tt.cargos,
+		}
+		got, err := calcularTotales(request, subtotalesPrueba(t, lineaPrueba{"10", 100, 18}), tt.anticipos)
+		if err != nil {
+			t.Errorf("%s: %v", tt.nombre, err)
+			continue
+		}
+		if len(got.allowanceCharges) != len(tt.cargos) {
+			t.Errorf("%s: %d cargos/descuentos, se esperaban %d", tt.nombre, len(got.allowanceCharges), len(tt.cargos))
+		}
+		importes := *got
+		importes.otrosTributos, importes.allowanceCharges = nil, nil
+		if !reflect.DeepEqual(importes, tt.want) {
+			t.Errorf("%s: totales = %+v, se esperaba %+v", tt.nombre, importes, tt.want)
+		}
+	}
+}
+
+func TestCalcularTotalesAnticiposSuperanImporte(t *testing.T) {
+	request := &FacturaRequest{
+		Comprobante:      ComprobanteData{TotalGravado: "100.00", TotalIGV: "18.00", Total: "118.00"},
+		CargosDescuentos: []CargoDescuentoData{{Codigo: "03", Monto: "10.00"}},
+	}
+	// Sin el descuento el anticipo sí cabría en el importe
+	if got, err := calcularTotales(request, subtotalesPrueba(t, lineaPrueba{"10", 100, 18}), &anticipos{valorVenta: 100, total: 118}); err == nil {
+		t.Fatalf("totales = %+v, se esperaba error", got)
+	}
+}
+
+func TestCalcularTotalesExportacion(t *testing.T) {
+	request := &FacturaRequest{
+		Comprobante: ComprobanteData{Moneda: "USD", Total: "500.00"},
+	}
+	got, err := calcularTotales(request, subtotalesPrueba(t, lineaPrueba{"40", 300, 0}, lineaPrueba{"40", 200, 0}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.valorVenta != 500 || got.baseIGV != 0 || got.igv != 0 || got.precioVenta != 500 || got.importe != 500 {
+		t.Fatalf("totales = %+v, se esperaba una exportación de 500 sin IGV", got)
+	}
+	if len(got.otrosTributos) != 1 || got.otrosTributos[0].tributo != catalog.TributoExportacion || got.otrosTributos[0].base != 500 {
+		t.Fatalf("otros tributos = %+v, se esperaba el subtotal de exportación", got.otrosTributos)
+	}
+}
+
+func TestCalcularTotalesOperacionMixta(t *testing.T) {
+	request := &FacturaRequest{
+		Comprobante: ComprobanteData{Moneda: "PEN", TotalGravado: "100.00", TotalIGV: "18.00", Total: "168.00"},
+	}
+	// Las operaciones gratuitas (21) no forman parte del valor de venta
+	got, err := calcularTotales(request, subtotalesPrueba(t, lineaPrueba{"10", 100, 18}, lineaPrueba{"20", 50, 0}, lineaPrueba{"21", 30, 0}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.valorVenta != 150 || got.baseIGV != 100 || got.igv != 18 || got.importe != 168 {
+		t.Fatalf("totales = %+v, se esperaba valor de venta 150 y base 100", got)
+	}
+	if len(got.otrosTributos) != 2 {
+		t.Fatalf("otros tributos = %+v, se esperaban exonerado y gratuito", got.otrosTributos)
+	}
+}
+
+func TestCalcularTotalesRequiereGravado(t *testing.T) {
+	request := &FacturaRequest{
+		Comprobante: ComprobanteData{Total: "118.00"},
+	}
+	if got, err := calcularTotales(request, subtotalesPrueba(t, lineaPrueba{"10", 100, 18}), nil); err == nil {
+		t.Fatalf("totales = %+v, se esperaba error por el total gravado vacío", got)
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/soap"
+)
+
+// MaxComprobantesValidez es la cantidad máxima de comprobantes por consulta
+const MaxComprobantesValidez = 100
+
+// consultasValidezSimultaneas limita las consultas concurrentes a SUNAT
+const consultasValidezSimultaneas = 5
+
+// tiposComprobanteValidez tipos que admite la consulta integrada de SUNAT
+var tiposComprobanteValidez = map[string]bool{
+	"01": true, // factura
+	"03": true, // boleta de venta
+	"04": true, // liquidación de compra
+	"07": true, // nota de crédito
+	"08": true, // nota de débito
+	"R1": true, // recibo por honorarios
+	"R7": true, // nota de crédito de recibo por honorarios
+}
+
+// ValidezConsultor consulta la validez de un comprobante en SUNAT
+type ValidezConsultor interface {
+	ConsultaValidez(ctx context.Context, rucConsultante string, comprobante soap.ComprobanteConsulta) (*soap.ValidezResultado, error)
+}
+
+// ValidezRequest estructura para la consulta de validez de comprobantes de terceros
+type ValidezRequest struct {
+	RUCConsultante string                   `json:"ruc_consultante" binding:"required"`
+	Comprobantes   []ComprobanteValidezData `json:"comprobantes" binding:"required"`
+}
+
+// ComprobanteValidezData estructura para un comprobante a validar
+type ComprobanteValidezData struct {
+	RUC             string `json:"ruc"`
+	TipoComprobante string `json:"tipo_comprobante"`
+	Serie           string `json:"serie"`
+	Numero          string `json:"numero"`
+	FechaEmision    string `json:"fecha_emision"`
+	Monto           string `json:"monto"`
+}
+
+// ValidezResultadoData resultado de la consulta de un comprobante
+type ValidezResultadoData struct {
+	ComprobanteValidezData
+	EstadoComprobante   string   `json:"estado_comprobante,omitempty"`
+	EstadoContribuyente string   `json:"estado_contribuyente,omitempty"`
+	CondicionDomicilio  string   `json:"condicion_domicilio,omitempty"`
+	Observaciones       []string `json:"observaciones,omitempty"`
+	CodigoEstado        string   `json:"codigo_estado,omitempty"`
+	CodigoEstadoRUC     string   `json:"codigo_estado_ruc,omitempty"`
+	CodigoCondicionRUC  string   `json:"codigo_condicion_ruc,omitempty"`
+	Error               string   `json:"error,omitempty"`
+}
+
+// ConsultarValidez valida un lote de comprobantes en SUNAT. Los errores de un
+// comprobante se informan en su resultado sin interrumpir el resto del lote.
+func ConsultarValidez(ctx context.Context, consultor ValidezConsultor, request *ValidezRequest) ([]ValidezResultadoData, error) {
+	if request == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if len(request.RUCConsultante) != 11 || !isDigits(request.RUCConsultante) {
+		return nil, fmt.Errorf("RUC consultante inválido: %s", request.RUCConsultante)
+	}
+	if len(request.Comprobantes) == 0 {
+		return nil, fmt.Errorf("debe indicar al menos un comprobante")
+	}
+	if len(request.Comprobantes) > MaxComprobantesValidez {
+		return nil, fmt.Errorf("se permiten como máximo %d comprobantes por consulta", MaxComprobantesValidez)
+	}
+
+	resultados := make([]ValidezResultadoData, len(request.Comprobantes))
+	sem := make(chan struct{}, consultasValidezSimultaneas)
+	var wg sync.WaitGroup
+	for i, comprobante := range request.Comprobantes {
+		wg.Add(1)
+		go func(i int, comprobante ComprobanteValidezData) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			resultados[i] = consultarComprobante(ctx, consultor, request.RUCConsultante, comprobante)
+		}(i, comprobante)
+	}
+	wg.Wait()
+
+	return resultados, nil
+}
+
+func consultarComprobante(ctx context.Context, consultor ValidezConsultor, rucConsultante string, c ComprobanteValidezData) ValidezResultadoData {
+	resultado := ValidezResultadoData{ComprobanteValidezData: c}
+
+	consulta, err := buildComprobanteConsulta(c)
+	if err != nil {
+		resultado.Error = err.Error()
+		return resultado
+	}
+
+	validez, err := consultor.ConsultaValidez(ctx, rucConsultante, consulta)
+	if err != nil {
+		resultado.Error = err.Error()
+		return resultado
+	}
+
+	resultado.CodigoEstado = validez.EstadoCp
+	resultado.CodigoEstadoRUC = validez.EstadoRuc
+	resultado.CodigoCondicionRUC = validez.CondDomiRuc
+	resultado.EstadoComprobante, _ = catalog.GetEstadoComprobante(validez.EstadoCp)
+	resultado.EstadoContribuyente, _ = catalog.GetEstadoContribuyente(validez.EstadoRuc)
+	resultado.CondicionDomicilio, _ = catalog.GetCondicionDomicilio(validez.CondDomiRuc)
+	resultado.Observaciones = validez.Observaciones
+	return resultado
+}
+
+// buildComprobanteConsulta valida los datos del comprobante y los convierte al
+// formato de la consulta de SUNAT
+func buildComprobanteConsulta(c ComprobanteValidezData) (soap.ComprobanteConsulta, error) {
+	if len(c.RUC) != 11 || !isDigits(c.RUC) {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("RUC emisor inválido: %s", c.RUC)
+	}
+	if !tiposComprobanteValidez[c.TipoComprobante] {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("tipo de comprobante no admitido en la consulta de validez: %s", c.TipoComprobante)
+	}
+	if len(c.Serie) != 4 {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("serie inválida: %s", c.Serie)
+	}
+	numero, err := strconv.Atoi(c.Numero)
+	if err != nil || numero <= 0 || numero > 99999999 {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("número inválido: %s", c.Numero)
+	}
+	fecha, err := time.Parse("2006-01-02", c.FechaEmision)
+	if err != nil {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("fecha de emisión inválida: %s", c.FechaEmision)
+	}
+
+	consulta := soap.ComprobanteConsulta{
+		NumRuc:       c.RUC,
+		CodComp:      c.TipoComprobante,
+		NumeroSerie:  c.Serie,
+		Numero:       strconv.Itoa(numero),
+		FechaEmision: fecha.Format("02/01/2006"),
+	}
+	// El monto es obligatorio para comprobantes electrónicos; las series de
+	// comprobantes físicos son numéricas
+	if c.Monto == "" && !isDigits(c.Serie) {
+		return soap.ComprobanteConsulta{}, fmt.Errorf("el monto es obligatorio para el comprobante electrónico %s-%s", c.Serie, c.Numero)
+	}
+	if c.Monto != "" {
+		monto, err := strconv.ParseFloat(c.Monto, 64)
+		if err != nil || monto < 0 {
+			return soap.ComprobanteConsulta{}, fmt.Errorf("monto inválido: %s", c.Monto)
+		}
+		consulta.Monto = strconv.FormatFloat(monto, 'f', 2, 64)
+	}
+	return consulta, nil
+}
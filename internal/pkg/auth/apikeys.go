@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// APIKey API key de un cliente. Solo se guarda el SHA-256 de la clave, que
+// se obtiene con: printf '%s' "$KEY" | sha256sum
+type APIKey struct {
+	Nombre   string   `json:"nombre"`
+	SHA256   string   `json:"sha256"`
+	RUCs     []string `json:"rucs"`
+	Permisos []string `json:"permisos"`
+}
+
+// KeyStore API keys configuradas
+type KeyStore struct {
+	keys []keyEntry
+}
+
+type keyEntry struct {
+	hash []byte
+	key  APIKey
+}
+
+// LoadKeyStore lee las API keys de un archivo JSON con una lista de APIKey
+func LoadKeyStore(path string) (*KeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo las API keys: %v", err)
+	}
+	var keys []APIKey
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error leyendo las API keys: %v", err)
+	}
+	return NewKeyStore(keys)
+}
+
+// NewKeyStore valida las API keys y crea el almacén
+func NewKeyStore(keys []APIKey) (*KeyStore, error) {
+	store := &KeyStore{}
+	for i, key := range keys {
+		if key.Nombre == "" {
+			return nil, fmt.Errorf("API key %d: el nombre es obligatorio", i+1)
+		}
+		hash, err := hex.DecodeString(strings.ToLower(key.SHA256))
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %s: sha256 inválido", key.Nombre)
+		}
+		if len(key.RUCs) == 0 {
+			return nil, fmt.Errorf("API key %s: debe autorizar al menos un RUC", key.Nombre)
+		}
+		if err := validatePermisos(key.Permisos); err != nil {
+			return nil, fmt.Errorf("API key %s: %v", key.Nombre, err)
+		}
+		store.keys = append(store.keys, keyEntry{hash: hash, key: key})
+	}
+	return store, nil
+}
+
+// Authenticate busca la API key y retorna su cliente
+func (s *KeyStore) Authenticate(key string) (*Principal, error) {
+	hash := sha256.Sum256([]byte(key))
+	for _, entry := range s.keys {
+		if subtle.ConstantTimeCompare(hash[:], entry.hash) == 1 {
+			return &Principal{
+				ID:       entry.key.Nombre,
+				Metodo:   "api_key",
+				RUCs:     entry.key.RUCs,
+				Permisos: entry.key.Permisos,
+			}, nil
+		}
+	}
+	return nil, ErrCredencialesInvalidas
+}
+
+func validatePermisos(permisos []string) error {
+	for _, permiso := range permisos {
+		switch permiso {
+		case PermisoConvert, PermisoSend, PermisoVoid, PermisoRead:
+		default:
+			return fmt.Errorf("permiso inválido: %s", permiso)
+		}
+	}
+	return nil
+}
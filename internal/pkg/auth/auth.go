@@ -0,0 +1,138 @@
+// Package auth autentica a los clientes de la API con API keys o JWT y
+// define qué RUC emisores y operaciones tiene permitidos cada uno.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"ubl-converter/internal/pkg/config"
+)
+
+// Permisos sobre los comprobantes
+const (
+	PermisoConvert = "convert" // convertir y firmar sin enviar
+	PermisoSend    = "send"    // enviar comprobantes a SUNAT
+	PermisoVoid    = "void"    // comunicar bajas
+	PermisoRead    = "read"    // consultar estados, CDR y archivos
+)
+
+// TodosLosRUC en la lista de RUC autoriza a cualquier emisor
+const TodosLosRUC = "*"
+
+// Cabecera con la API key
+const APIKeyHeader = "X-API-Key"
+
+// Errores de autenticación
+var (
+	ErrSinCredenciales       = errors.New("se requiere una API key o un token Bearer")
+	ErrCredencialesInvalidas = errors.New("credenciales inválidas")
+)
+
+// Principal cliente autenticado
+type Principal struct {
+	ID       string   // nombre de la API key o sub del JWT
+	Metodo   string   // api_key, jwt o ninguno
+	RUCs     []string // emisores autorizados; "*" autoriza a todos
+	Permisos []string
+}
+
+// Permite indica si el cliente tiene el permiso
+func (p *Principal) Permite(permiso string) bool {
+	return p != nil && slices.Contains(p.Permisos, permiso)
+}
+
+// AutorizaRUC indica si el cliente puede operar con el RUC emisor
+func (p *Principal) AutorizaRUC(ruc string) bool {
+	if p == nil || ruc == "" {
+		return false
+	}
+	return slices.Contains(p.RUCs, TodosLosRUC) || slices.Contains(p.RUCs, ruc)
+}
+
+type principalKey struct{}
+
+// WithPrincipal retorna una copia de ctx con el cliente autenticado
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext retorna el cliente autenticado de la solicitud o nil
+func FromContext(ctx context.Context) *Principal {
+	p, _ := ctx.Value(principalKey{}).(*Principal)
+	return p
+}
+
+// Authenticator valida las credenciales de una solicitud
+type Authenticator struct {
+	disabled bool
+	keys     *KeyStore
+	jwt      *JWTVerifier
+}
+
+// NewAuthenticator crea el autenticador con las API keys y la clave de los
+// JWT configuradas
+func NewAuthenticator(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{disabled: cfg.Disabled}
+	if cfg.APIKeysFile != "" {
+		keys, err := LoadKeyStore(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = keys
+	}
+	if cfg.JWTSecret != "" || cfg.JWTPublicKeyFile != "" {
+		var publicKey []byte
+		if cfg.JWTPublicKeyFile != "" {
+			var err error
+			if publicKey, err = os.ReadFile(cfg.JWTPublicKeyFile); err != nil {
+				return nil, fmt.Errorf("error leyendo la clave pública de los JWT: %v", err)
+			}
+		}
+		verifier, err := NewJWTVerifier([]byte(cfg.JWTSecret), publicKey, cfg.JWTIssuer, cfg.JWTAudience)
+		if err != nil {
+			return nil, err
+		}
+		a.jwt = verifier
+	}
+	return a, nil
+}
+
+// Configurado indica si hay alguna forma de autenticarse o si la
+// autenticación está desactivada
+func (a *Authenticator) Configurado() bool {
+	return a.disabled || a.keys != nil || a.jwt != nil
+}
+
+// Authenticate valida la API key de X-API-Key o el JWT de Authorization
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if a.disabled {
+		return &Principal{
+			ID:       "anonimo",
+			Metodo:   "ninguno",
+			RUCs:     []string{TodosLosRUC},
+			Permisos: []string{PermisoConvert, PermisoSend, PermisoVoid, PermisoRead},
+		}, nil
+	}
+
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		if a.keys == nil {
+			return nil, ErrCredencialesInvalidas
+		}
+		return a.keys.Authenticate(key)
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && token != "" {
+		if a.jwt == nil {
+			return nil, ErrCredencialesInvalidas
+		}
+		return a.jwt.Verify(token)
+	}
+	return nil, ErrSinCredenciales
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims claims de los JWT de la API: el sub identifica al cliente, rucs
+// los emisores autorizados y permisos las operaciones permitidas
+type Claims struct {
+	RUCs     []string `json:"rucs"`
+	Permisos []string `json:"permisos"`
+	jwt.RegisteredClaims
+}
+
+// JWTVerifier valida JWT firmados con HS256 o RS256
+type JWTVerifier struct {
+	secret    []byte
+	publicKey *rsa.PublicKey
+	parser    *jwt.Parser
+}
+
+// NewJWTVerifier crea el verificador con el secreto HS256, la clave pública
+// RS256 en PEM, o ambos; issuer y audience vacíos no se validan
+func NewJWTVerifier(secret, publicKeyPEM []byte, issuer, audience string) (*JWTVerifier, error) {
+	v := &JWTVerifier{secret: secret}
+	var methods []string
+	if len(secret) > 0 {
+		if len(secret) < 32 {
+			return nil, fmt.Errorf("el secreto de los JWT debe tener al menos 32 bytes")
+		}
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if len(publicKeyPEM) > 0 {
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("clave pública de los JWT inválida: %v", err)
+		}
+		v.publicKey = publicKey
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	v.parser = jwt.NewParser(options...)
+	return v, nil
+}
+
+// Verify valida la firma y la vigencia del token y retorna su cliente
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	claims := &Claims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() == jwt.SigningMethodRS256.Alg() {
+			return v.publicKey, nil
+		}
+		return v.secret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCredencialesInvalidas, err)
+	}
+	if claims.Subject == "" || len(claims.RUCs) == 0 {
+		return nil, fmt.Errorf("%w: el token debe incluir sub y rucs", ErrCredencialesInvalidas)
+	}
+	if err := validatePermisos(claims.Permisos); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCredencialesInvalidas, err)
+	}
+	return &Principal{
+		ID:       claims.Subject,
+		Metodo:   "jwt",
+		RUCs:     claims.RUCs,
+		Permisos: claims.Permisos,
+	}, nil
+}
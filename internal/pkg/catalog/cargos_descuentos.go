@@ -0,0 +1,46 @@
+package catalog
+
+// CargoDescuento representa una entrada del catálogo 53 de SUNAT
+// (códigos de cargos, descuentos y otras deducciones)
+type CargoDescuento struct {
+	Codigo      string
+	Descripcion string
+	// Cargo indica si el código corresponde a un cargo (ChargeIndicator=true)
+	Cargo bool
+	// AfectaBase indica si el cargo o descuento afecta la base imponible del IGV
+	AfectaBase bool
+	// Global indica si el código se usa a nivel de comprobante (false: a nivel de ítem)
+	Global bool
+	// Porcentaje por defecto, si el código tiene una tasa fija (percepciones)
+	Porcentaje float64
+}
+
+var cargosDescuentos = map[string]CargoDescuento{
+	"00": {"00", "Descuentos que afectan la base imponible del IGV/IVAP", false, true, false, 0},
+	"01": {"01", "Descuentos que no afectan la base imponible del IGV/IVAP", false, false, false, 0},
+	"02": {"02", "Descuentos globales que afectan la base imponible del IGV/IVAP", false, true, true, 0},
+	"03": {"03", "Descuentos globales que no afectan la base imponible del IGV/IVAP", false, false, true, 0},
+	"04": {"04", "Descuentos globales por anticipos gravados que afectan la base imponible del IGV/IVAP", false, true, true, 0},
+	"05": {"05", "Descuentos globales por anticipos exonerados", false, true, true, 0},
+	"06": {"06", "Descuentos globales por anticipos inafectos", false, true, true, 0},
+	"45": {"45", "FISE", true, false, true, 0},
+	"46": {"46", "Recargo al consumo y/o propinas", true, false, true, 0},
+	"47": {"47", "Cargos que afectan la base imponible del IGV/IVAP", true, true, false, 0},
+	"48": {"48", "Cargos que no afectan la base imponible del IGV/IVAP", true, false, false, 0},
+	"49": {"49", "Cargos globales que afectan la base imponible del IGV/IVAP", true, true, true, 0},
+	"50": {"50", "Cargos globales que no afectan la base imponible del IGV/IVAP", true, false, true, 0},
+	"51": {"51", "Percepción venta interna", true, false, true, 2},
+	"52": {"52", "Percepción a la adquisición de combustible", true, false, true, 1},
+	"53": {"53", "Percepción realizada al agente de percepción con tasa especial", true, false, true, 0.5},
+}
+
+// GetCargoDescuento busca un cargo o descuento por su código del catálogo 53
+func GetCargoDescuento(codigo string) (CargoDescuento, bool) {
+	c, ok := cargosDescuentos[codigo]
+	return c, ok
+}
+
+// EsPercepcion indica si el código del catálogo 53 corresponde a una percepción
+func EsPercepcion(codigo string) bool {
+	return codigo == "51" || codigo == "52" || codigo == "53"
+}
@@ -0,0 +1,31 @@
+package catalog
+
+// Códigos de respuesta de getStatus y getStatusCdr del servicio de consulta
+// de comprobantes (billConsultService)
+const (
+	ConsultaAceptado  = "0001"
+	ConsultaRechazado = "0002"
+	ConsultaBaja      = "0003"
+	ConsultaNoExiste  = "0011"
+)
+
+var codigosConsulta = map[string]string{
+	"0001": "El comprobante existe y está aceptado",
+	"0002": "El comprobante existe pero está rechazado",
+	"0003": "El comprobante existe pero está de baja",
+	"0004": "Formato de RUC no es válido",
+	"0005": "Formato del tipo de comprobante no es válido",
+	"0006": "Formato de serie inválido",
+	"0007": "El número de comprobante debe ser mayor que cero",
+	"0008": "El número de RUC no está inscrito en los registros de la SUNAT",
+	"0009": "El tipo de comprobante debe ser (01, 07 o 08)",
+	"0010": "Sólo se puede consultar facturas, notas de crédito y débito electrónicas cuya serie empieza con F",
+	"0011": "El comprobante de pago electrónico no existe",
+	"0012": "El comprobante de pago electrónico no le pertenece",
+}
+
+// GetCodigoConsulta retorna la descripción de un código de respuesta de la consulta
+func GetCodigoConsulta(codigo string) (string, bool) {
+	d, ok := codigosConsulta[codigo]
+	return d, ok
+}
@@ -0,0 +1,58 @@
+package catalog
+
+// BienServicioDetraccion representa una entrada del catálogo 54 de SUNAT
+// (códigos de bienes y servicios sujetos a detracción)
+type BienServicioDetraccion struct {
+	Codigo      string
+	Descripcion string
+	Porcentaje  float64
+}
+
+// MontoMinimoDetraccion es el importe de la operación (en soles) a partir del
+// cual se aplica el Sistema de Pago de Obligaciones Tributarias (SPOT)
+const MontoMinimoDetraccion = 700.00
+
+// CuentaBancoNacionMedioPago es el medio de pago por defecto (catálogo 59)
+// para el depósito de la detracción en el Banco de la Nación
+const CuentaBancoNacionMedioPago = "001"
+
+var detracciones = map[string]BienServicioDetraccion{
+	"001": {"001", "Azúcar y melaza de caña", 10},
+	"003": {"003", "Alcohol etílico", 10},
+	"004": {"004", "Recursos hidrobiológicos", 4},
+	"005": {"005", "Maíz amarillo duro", 4},
+	"007": {"007", "Caña de azúcar", 10},
+	"008": {"008", "Madera", 4},
+	"009": {"009", "Arena y piedra", 10},
+	"010": {"010", "Residuos, subproductos, desechos, recortes y desperdicios", 15},
+	"011": {"011", "Bienes gravados con el IGV, o renuncia a la exoneración", 10},
+	"012": {"012", "Intermediación laboral y tercerización", 12},
+	"014": {"014", "Carnes y despojos comestibles", 4},
+	"016": {"016", "Aceite de pescado", 10},
+	"017": {"017", "Harina, polvo y pellets de pescado, crustáceos, moluscos y demás invertebrados acuáticos", 4},
+	"019": {"019", "Arrendamiento de bienes muebles", 10},
+	"020": {"020", "Mantenimiento y reparación de bienes muebles", 12},
+	"021": {"021", "Movimiento de carga", 10},
+	"022": {"022", "Otros servicios empresariales", 12},
+	"024": {"024", "Comisión mercantil", 10},
+	"025": {"025", "Fabricación de bienes por encargo", 10},
+	"026": {"026", "Servicio de transporte de personas", 10},
+	"027": {"027", "Servicio de transporte de carga", 4},
+	"030": {"030", "Contratos de construcción", 4},
+	"031": {"031", "Oro gravado con el IGV", 10},
+	"032": {"032", "Páprika y otros frutos de los géneros capsicum o pimienta", 10},
+	"034": {"034", "Minerales metálicos no auríferos", 10},
+	"035": {"035", "Bienes exonerados del IGV", 1.5},
+	"036": {"036", "Oro y demás minerales metálicos exonerados del IGV", 1.5},
+	"037": {"037", "Demás servicios gravados con el IGV", 12},
+	"039": {"039", "Minerales no metálicos", 10},
+	"040": {"040", "Bien inmueble gravado con IGV", 4},
+	"041": {"041", "Plomo", 15},
+	"099": {"099", "Ley 30737", 4},
+}
+
+// GetDetraccion busca un bien o servicio sujeto a detracción por su código del catálogo 54
+func GetDetraccion(codigo string) (BienServicioDetraccion, bool) {
+	d, ok := detracciones[codigo]
+	return d, ok
+}
@@ -0,0 +1,39 @@
+package catalog
+
+// Tipos de documento de identidad (catálogo 06)
+const (
+	DocumentoNoDomiciliado       = "0"
+	DocumentoDNI                 = "1"
+	DocumentoCarnetExtranjeria   = "4"
+	DocumentoRUC                 = "6"
+	DocumentoPasaporte           = "7"
+	DocumentoCedulaDiplomatica   = "A"
+	DocumentoIdentidadExtranjero = "B"
+)
+
+var documentosIdentidad = map[string]string{
+	DocumentoNoDomiciliado:       "Doc. trib. no dom. sin RUC",
+	DocumentoDNI:                 "DNI",
+	DocumentoCarnetExtranjeria:   "Carnet de extranjería",
+	DocumentoRUC:                 "RUC",
+	DocumentoPasaporte:           "Pasaporte",
+	DocumentoCedulaDiplomatica:   "Cédula diplomática de identidad",
+	DocumentoIdentidadExtranjero: "Doc. identidad país residencia - no domiciliado",
+}
+
+// GetDocumentoIdentidad retorna la descripción de un tipo de documento de identidad del catálogo 06
+func GetDocumentoIdentidad(tipo string) (string, bool) {
+	d, ok := documentosIdentidad[tipo]
+	return d, ok
+}
+
+// Incoterms aceptados en las facturas de exportación
+var incoterms = map[string]bool{
+	"EXW": true, "FCA": true, "FAS": true, "FOB": true, "CFR": true, "CIF": true,
+	"CPT": true, "CIP": true, "DAP": true, "DPU": true, "DDP": true,
+}
+
+// IsIncoterm indica si el código corresponde a un Incoterm vigente
+func IsIncoterm(codigo string) bool {
+	return incoterms[codigo]
+}
@@ -0,0 +1,92 @@
+package catalog
+
+import "strconv"
+
+// Categorías de los códigos de error de SUNAT
+const (
+	ErrorAutenticacion = "auth"       // credenciales SOL o permisos del usuario
+	ErrorValidacion    = "validation" // el comprobante o el archivo no es válido
+	ErrorDuplicado     = "duplicate"  // el comprobante ya fue registrado
+	ErrorTransitorio   = "transient"  // falla temporal de SUNAT, se puede reintentar
+)
+
+// ErrorSUNAT código de la lista de errores publicada por SUNAT
+type ErrorSUNAT struct {
+	Codigo    string
+	Mensaje   string
+	Categoria string
+}
+
+var erroresSUNAT = map[string]ErrorSUNAT{
+	"0100": {"0100", "El sistema no puede responder su solicitud. Intente nuevamente o comuníquese con su Administrador", ErrorTransitorio},
+	"0101": {"0101", "El encabezado de seguridad es incorrecto", ErrorAutenticacion},
+	"0102": {"0102", "Usuario o contraseña incorrectos", ErrorAutenticacion},
+	"0103": {"0103", "El Usuario ingresado no existe", ErrorAutenticacion},
+	"0104": {"0104", "La Clave ingresada es incorrecta", ErrorAutenticacion},
+	"0105": {"0105", "El Usuario no está activo", ErrorAutenticacion},
+	"0106": {"0106", "El Usuario no es válido", ErrorAutenticacion},
+	"0109": {"0109", "El sistema no puede responder su solicitud. (El servicio de autenticación no está disponible)", ErrorTransitorio},
+	"0110": {"0110", "No se pudo obtener la información del tipo de usuario", ErrorTransitorio},
+	"0111": {"0111", "No tiene el perfil para enviar comprobantes electrónicos", ErrorAutenticacion},
+	"0112": {"0112", "El usuario debe ser secundario", ErrorAutenticacion},
+	"0113": {"0113", "El usuario no está afiliado a Factura Electrónica", ErrorAutenticacion},
+	"0125": {"0125", "No se pudo obtener la constancia", ErrorTransitorio},
+	"0126": {"0126", "El ticket no le pertenece al usuario", ErrorValidacion},
+	"0127": {"0127", "El ticket no existe", ErrorValidacion},
+	"0130": {"0130", "El sistema no puede responder su solicitud. (No se pudo obtener el ticket de proceso)", ErrorTransitorio},
+	"0131": {"0131", "El sistema no puede responder su solicitud. (No se pudo grabar el archivo en el directorio)", ErrorTransitorio},
+	"0132": {"0132", "El sistema no puede responder su solicitud. (No se pudo grabar escribir en el archivo zip)", ErrorTransitorio},
+	"0133": {"0133", "El sistema no puede responder su solicitud. (No se pudo grabar la entrada del log)", ErrorTransitorio},
+	"0134": {"0134", "El sistema no puede responder su solicitud. (No se pudo grabar en el storage)", ErrorTransitorio},
+	"0135": {"0135", "El sistema no puede responder su solicitud. (No se pudo encolar el pedido)", ErrorTransitorio},
+	"0136": {"0136", "El sistema no puede responder su solicitud. (No se pudo recibir una respuesta del batch)", ErrorTransitorio},
+	"0137": {"0137", "El sistema no puede responder su solicitud. (Se obtuvo una respuesta nula)", ErrorTransitorio},
+	"0138": {"0138", "El sistema no puede responder su solicitud. (Error en Base de Datos)", ErrorTransitorio},
+	"0151": {"0151", "El nombre del archivo ZIP es incorrecto", ErrorValidacion},
+	"0152": {"0152", "No se puede enviar por este método un archivo de resumen", ErrorValidacion},
+	"0153": {"0153", "No se puede enviar por este método un archivo por lotes", ErrorValidacion},
+	"0154": {"0154", "El RUC del archivo no corresponde al RUC del usuario", ErrorValidacion},
+	"0155": {"0155", "El archivo ZIP está vacío", ErrorValidacion},
+	"0156": {"0156", "El archivo ZIP está corrupto", ErrorValidacion},
+	"0157": {"0157", "El archivo ZIP no contiene comprobantes", ErrorValidacion},
+	"0158": {"0158", "El archivo ZIP contiene demasiados comprobantes para este tipo de envío", ErrorValidacion},
+	"0159": {"0159", "El nombre del archivo XML es incorrecto", ErrorValidacion},
+	"0160": {"0160", "El archivo XML está vacío", ErrorValidacion},
+	"0161": {"0161", "El nombre del archivo XML no coincide con el nombre del archivo ZIP", ErrorValidacion},
+	"0200": {"0200", "No se pudo procesar su solicitud. (Ocurrió un error en el batch)", ErrorTransitorio},
+	"0201": {"0201", "No se pudo procesar su solicitud. (Llegó un requerimiento nulo al batch)", ErrorTransitorio},
+	"0202": {"0202", "No se pudo procesar su solicitud. (No llegó información del archivo ZIP)", ErrorTransitorio},
+	"0203": {"0203", "No se pudo procesar su solicitud. (No se encontró archivo ZIP)", ErrorTransitorio},
+	"0204": {"0204", "No se pudo procesar su solicitud. (El archivo ZIP no contiene comprobantes)", ErrorValidacion},
+	"0250": {"0250", "No se puede leer (parsear) el archivo XML", ErrorValidacion},
+	"0300": {"0300", "No se encontró la raíz documento xml", ErrorValidacion},
+	"0301": {"0301", "Elemento raíz del xml no está definido", ErrorValidacion},
+	"0302": {"0302", "Código del tipo de comprobante no registrado", ErrorValidacion},
+	"0306": {"0306", "No se puede leer (parsear) el archivo XML", ErrorValidacion},
+	"1003": {"1003", "InvoiceTypeCode - El valor del tipo de documento es invalido o no coincide con el nombre del archivo", ErrorValidacion},
+	"1032": {"1032", "El comprobante fue informado previamente en una comunicación de baja", ErrorDuplicado},
+	"1033": {"1033", "El comprobante fue registrado previamente con otros datos", ErrorDuplicado},
+	"1034": {"1034", "Número de RUC del nombre del archivo no coincide con el consignado en el contenido del archivo XML", ErrorValidacion},
+	"1035": {"1035", "Número de serie del nombre del archivo no coincide con el consignado en el contenido del archivo XML", ErrorValidacion},
+	"1036": {"1036", "Número de documento en el nombre del archivo no coincide con el consignado en el contenido del XML", ErrorValidacion},
+	"2335": {"2335", "El documento electrónico ingresado ha sido alterado", ErrorValidacion},
+}
+
+// GetErrorSUNAT busca un código de error de SUNAT. Los códigos que no están
+// en la lista se clasifican por rango: del 0100 al 0999 son excepciones del
+// servicio y del 1000 en adelante son rechazos del comprobante.
+func GetErrorSUNAT(codigo string) (ErrorSUNAT, bool) {
+	if e, ok := erroresSUNAT[codigo]; ok {
+		return e, true
+	}
+
+	n, err := strconv.Atoi(codigo)
+	if err != nil {
+		return ErrorSUNAT{Codigo: codigo}, false
+	}
+	categoria := ErrorValidacion
+	if n >= 100 && n < 1000 {
+		categoria = ErrorTransitorio
+	}
+	return ErrorSUNAT{Codigo: codigo, Categoria: categoria}, false
+}
@@ -0,0 +1,36 @@
+package catalog
+
+// Tipos de guía de remisión electrónica (catálogo 01)
+const (
+	GuiaRemitente     = "09"
+	GuiaTransportista = "31"
+)
+
+// Modalidades de traslado (catálogo 18)
+const (
+	TransportePublico = "01"
+	TransportePrivado = "02"
+)
+
+var motivosTraslado = map[string]string{
+	"01": "Venta",
+	"02": "Compra",
+	"03": "Venta con entrega a terceros",
+	"04": "Traslado entre establecimientos de la misma empresa",
+	"05": "Consignación",
+	"06": "Devolución",
+	"07": "Recojo de bienes transformados",
+	"08": "Importación",
+	"09": "Exportación",
+	"13": "Otros",
+	"14": "Venta sujeta a confirmación del comprador",
+	"17": "Traslado de bienes para transformación",
+	"18": "Traslado emisor itinerante CP",
+	"19": "Traslado a zona primaria",
+}
+
+// GetMotivoTraslado retorna la descripción de un motivo de traslado del catálogo 20
+func GetMotivoTraslado(codigo string) (string, bool) {
+	d, ok := motivosTraslado[codigo]
+	return d, ok
+}
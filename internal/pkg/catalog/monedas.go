@@ -0,0 +1,180 @@
+package catalog
+
+// Moneda representa una moneda del catálogo 02 de SUNAT (ISO 4217)
+type Moneda struct {
+	Codigo string
+	// Nombre es la denominación en plural usada en la leyenda del importe en letras
+	Nombre string
+	// Simbolo es el símbolo usado en la representación impresa; vacío si se usa el código
+	Simbolo string
+}
+
+// Monedas de uso frecuente en los comprobantes
+const (
+	MonedaSoles   = "PEN"
+	MonedaDolares = "USD"
+	MonedaEuros   = "EUR"
+)
+
+var monedas = map[string]Moneda{
+	"AED": {"AED", "DIRHAMS DE LOS EMIRATOS ÁRABES UNIDOS", ""},
+	"AFN": {"AFN", "AFGANIS", ""},
+	"ALL": {"ALL", "LEKS", ""},
+	"AMD": {"AMD", "DRAMS ARMENIOS", ""},
+	"ANG": {"ANG", "FLORINES ANTILLANOS NEERLANDESES", ""},
+	"AOA": {"AOA", "KWANZAS", ""},
+	"ARS": {"ARS", "PESOS ARGENTINOS", ""},
+	"AUD": {"AUD", "DÓLARES AUSTRALIANOS", "A$"},
+	"AWG": {"AWG", "FLORINES ARUBEÑOS", ""},
+	"AZN": {"AZN", "MANATS AZERBAIYANOS", ""},
+	"BAM": {"BAM", "MARCOS CONVERTIBLES", ""},
+	"BBD": {"BBD", "DÓLARES DE BARBADOS", ""},
+	"BDT": {"BDT", "TAKAS", ""},
+	"BGN": {"BGN", "LEVS BÚLGAROS", ""},
+	"BHD": {"BHD", "DINARES BAREINÍES", ""},
+	"BIF": {"BIF", "FRANCOS DE BURUNDI", ""},
+	"BMD": {"BMD", "DÓLARES DE BERMUDAS", ""},
+	"BND": {"BND", "DÓLARES DE BRUNÉI", ""},
+	"BOB": {"BOB", "BOLIVIANOS", "Bs"},
+	"BRL": {"BRL", "REALES BRASILEÑOS", "R$"},
+	"BSD": {"BSD", "DÓLARES BAHAMEÑOS", ""},
+	"BTN": {"BTN", "NGULTRUMS", ""},
+	"BWP": {"BWP", "PULAS", ""},
+	"BYN": {"BYN", "RUBLOS BIELORRUSOS", ""},
+	"BZD": {"BZD", "DÓLARES BELICEÑOS", ""},
+	"CAD": {"CAD", "DÓLARES CANADIENSES", "C$"},
+	"CDF": {"CDF", "FRANCOS CONGOLEÑOS", ""},
+	"CHF": {"CHF", "FRANCOS SUIZOS", ""},
+	"CLP": {"CLP", "PESOS CHILENOS", ""},
+	"CNY": {"CNY", "YUANES CHINOS", ""},
+	"COP": {"COP", "PESOS COLOMBIANOS", ""},
+	"CRC": {"CRC", "COLONES COSTARRICENSES", ""},
+	"CUP": {"CUP", "PESOS CUBANOS", ""},
+	"CVE": {"CVE", "ESCUDOS CABOVERDIANOS", ""},
+	"CZK": {"CZK", "CORONAS CHECAS", ""},
+	"DJF": {"DJF", "FRANCOS YIBUTIANOS", ""},
+	"DKK": {"DKK", "CORONAS DANESAS", ""},
+	"DOP": {"DOP", "PESOS DOMINICANOS", ""},
+	"DZD": {"DZD", "DINARES ARGELINOS", ""},
+	"EGP": {"EGP", "LIBRAS EGIPCIAS", ""},
+	"ERN": {"ERN", "NAKFAS", ""},
+	"ETB": {"ETB", "BIRRS ETÍOPES", ""},
+	"EUR": {"EUR", "EUROS", "€"},
+	"FJD": {"FJD", "DÓLARES FIYIANOS", ""},
+	"FKP": {"FKP", "LIBRAS MALVINENSES", ""},
+	"GBP": {"GBP", "LIBRAS ESTERLINAS", "£"},
+	"GEL": {"GEL", "LARIS", ""},
+	"GHS": {"GHS", "CEDIS GHANESES", ""},
+	"GIP": {"GIP", "LIBRAS DE GIBRALTAR", ""},
+	"GMD": {"GMD", "DALASIS", ""},
+	"GNF": {"GNF", "FRANCOS GUINEANOS", ""},
+	"GTQ": {"GTQ", "QUETZALES", ""},
+	"GYD": {"GYD", "DÓLARES GUYANESES", ""},
+	"HKD": {"HKD", "DÓLARES DE HONG KONG", ""},
+	"HNL": {"HNL", "LEMPIRAS", ""},
+	"HTG": {"HTG", "GOURDES", ""},
+	"HUF": {"HUF", "FORINTS", ""},
+	"IDR": {"IDR", "RUPIAS INDONESIAS", ""},
+	"ILS": {"ILS", "NUEVOS SÉQUELES", ""},
+	"INR": {"INR", "RUPIAS INDIAS", ""},
+	"IQD": {"IQD", "DINARES IRAQUÍES", ""},
+	"IRR": {"IRR", "RIALES IRANÍES", ""},
+	"ISK": {"ISK", "CORONAS ISLANDESAS", ""},
+	"JMD": {"JMD", "DÓLARES JAMAIQUINOS", ""},
+	"JOD": {"JOD", "DINARES JORDANOS", ""},
+	"JPY": {"JPY", "YENES", "¥"},
+	"KES": {"KES", "CHELINES KENIANOS", ""},
+	"KGS": {"KGS", "SOMS", ""},
+	"KHR": {"KHR", "RIELES", ""},
+	"KMF": {"KMF", "FRANCOS COMORENSES", ""},
+	"KPW": {"KPW", "WONES NORCOREANOS", ""},
+	"KRW": {"KRW", "WONES SURCOREANOS", ""},
+	"KWD": {"KWD", "DINARES KUWAITÍES", ""},
+	"KYD": {"KYD", "DÓLARES DE LAS ISLAS CAIMÁN", ""},
+	"KZT": {"KZT", "TENGES", ""},
+	"LAK": {"LAK", "KIPS", ""},
+	"LBP": {"LBP", "LIBRAS LIBANESAS", ""},
+	"LKR": {"LKR", "RUPIAS DE SRI LANKA", ""},
+	"LRD": {"LRD", "DÓLARES LIBERIANOS", ""},
+	"LSL": {"LSL", "LOTIS", ""},
+	"LYD": {"LYD", "DINARES LIBIOS", ""},
+	"MAD": {"MAD", "DÍRHAMS MARROQUÍES", ""},
+	"MDL": {"MDL", "LEUS MOLDAVOS", ""},
+	"MGA": {"MGA", "ARIARIS", ""},
+	"MKD": {"MKD", "DENARES MACEDONIOS", ""},
+	"MMK": {"MMK", "KYATS", ""},
+	"MNT": {"MNT", "TUGRIKS", ""},
+	"MOP": {"MOP", "PATACAS", ""},
+	"MRU": {"MRU", "UGUIYAS", ""},
+	"MUR": {"MUR", "RUPIAS MAURICIANAS", ""},
+	"MVR": {"MVR", "RUFIYAAS", ""},
+	"MWK": {"MWK", "KWACHAS MALAUÍES", ""},
+	"MXN": {"MXN", "PESOS MEXICANOS", ""},
+	"MYR": {"MYR", "RINGGITS", ""},
+	"MZN": {"MZN", "METICALES", ""},
+	"NAD": {"NAD", "DÓLARES NAMIBIOS", ""},
+	"NGN": {"NGN", "NAIRAS", ""},
+	"NIO": {"NIO", "CÓRDOBAS", ""},
+	"NOK": {"NOK", "CORONAS NORUEGAS", ""},
+	"NPR": {"NPR", "RUPIAS NEPALÍES", ""},
+	"NZD": {"NZD", "DÓLARES NEOZELANDESES", ""},
+	"OMR": {"OMR", "RIALES OMANÍES", ""},
+	"PAB": {"PAB", "BALBOAS", ""},
+	"PEN": {"PEN", "SOLES", "S/"},
+	"PGK": {"PGK", "KINAS", ""},
+	"PHP": {"PHP", "PESOS FILIPINOS", ""},
+	"PKR": {"PKR", "RUPIAS PAKISTANÍES", ""},
+	"PLN": {"PLN", "ESLOTIS", ""},
+	"PYG": {"PYG", "GUARANÍES", ""},
+	"QAR": {"QAR", "RIALES CATARÍES", ""},
+	"RON": {"RON", "LEUS RUMANOS", ""},
+	"RSD": {"RSD", "DINARES SERBIOS", ""},
+	"RUB": {"RUB", "RUBLOS RUSOS", ""},
+	"RWF": {"RWF", "FRANCOS RUANDESES", ""},
+	"SAR": {"SAR", "RIALES SAUDÍES", ""},
+	"SBD": {"SBD", "DÓLARES DE LAS ISLAS SALOMÓN", ""},
+	"SCR": {"SCR", "RUPIAS DE SEYCHELLES", ""},
+	"SDG": {"SDG", "LIBRAS SUDANESAS", ""},
+	"SEK": {"SEK", "CORONAS SUECAS", ""},
+	"SGD": {"SGD", "DÓLARES DE SINGAPUR", ""},
+	"SHP": {"SHP", "LIBRAS DE SANTA ELENA", ""},
+	"SLE": {"SLE", "LEONES", ""},
+	"SOS": {"SOS", "CHELINES SOMALÍES", ""},
+	"SRD": {"SRD", "DÓLARES SURINAMESES", ""},
+	"SSP": {"SSP", "LIBRAS SURSUDANESAS", ""},
+	"STN": {"STN", "DOBRAS", ""},
+	"SYP": {"SYP", "LIBRAS SIRIAS", ""},
+	"SZL": {"SZL", "LILANGENIS", ""},
+	"THB": {"THB", "BATS", ""},
+	"TJS": {"TJS", "SOMONIS", ""},
+	"TMT": {"TMT", "MANATS TURCOMANOS", ""},
+	"TND": {"TND", "DINARES TUNECINOS", ""},
+	"TOP": {"TOP", "PAANGAS", ""},
+	"TRY": {"TRY", "LIRAS TURCAS", ""},
+	"TTD": {"TTD", "DÓLARES DE TRINIDAD Y TOBAGO", ""},
+	"TWD": {"TWD", "NUEVOS DÓLARES TAIWANESES", ""},
+	"TZS": {"TZS", "CHELINES TANZANOS", ""},
+	"UAH": {"UAH", "GRIVNAS", ""},
+	"UGX": {"UGX", "CHELINES UGANDESES", ""},
+	"USD": {"USD", "DÓLARES AMERICANOS", "US$"},
+	"UYU": {"UYU", "PESOS URUGUAYOS", ""},
+	"UZS": {"UZS", "SOMS UZBEKOS", ""},
+	"VES": {"VES", "BOLÍVARES", ""},
+	"VND": {"VND", "DONGS", ""},
+	"VUV": {"VUV", "VATUS", ""},
+	"WST": {"WST", "TALAS", ""},
+	"XAF": {"XAF", "FRANCOS CFA DE ÁFRICA CENTRAL", ""},
+	"XCD": {"XCD", "DÓLARES DEL CARIBE ORIENTAL", ""},
+	"XOF": {"XOF", "FRANCOS CFA DE ÁFRICA OCCIDENTAL", ""},
+	"XPF": {"XPF", "FRANCOS CFP", ""},
+	"YER": {"YER", "RIALES YEMENÍES", ""},
+	"ZAR": {"ZAR", "RANDS", ""},
+	"ZMW": {"ZMW", "KWACHAS ZAMBIANOS", ""},
+	"ZWL": {"ZWL", "DÓLARES ZIMBABUENSES", ""},
+}
+
+// GetMoneda busca una moneda por su código del catálogo 02
+func GetMoneda(codigo string) (Moneda, bool) {
+	m, ok := monedas[codigo]
+	return m, ok
+}
@@ -0,0 +1,61 @@
+package catalog
+
+// segmentosUNSPSC contiene los segmentos (dos primeros dígitos) de la
+// clasificación UNSPSC usada en el catálogo 25 de SUNAT
+var segmentosUNSPSC = map[string]bool{
+	"10": true, "11": true, "12": true, "13": true, "14": true, "15": true,
+	"20": true, "21": true, "22": true, "23": true, "24": true, "25": true,
+	"26": true, "27": true, "30": true, "31": true, "32": true, "39": true,
+	"40": true, "41": true, "42": true, "43": true, "44": true, "45": true,
+	"46": true, "47": true, "48": true, "49": true, "50": true, "51": true,
+	"52": true, "53": true, "54": true, "55": true, "56": true, "60": true,
+	"64": true, "70": true, "71": true, "72": true, "73": true, "76": true,
+	"77": true, "78": true, "80": true, "81": true, "82": true, "83": true,
+	"84": true, "85": true, "86": true, "90": true, "91": true, "92": true,
+	"93": true, "94": true, "95": true,
+}
+
+// IsFormatoCodigoProductoSUNAT indica si el código tiene el formato de un
+// código de producto del catálogo 25 (UNSPSC de 8 dígitos) con un segmento
+// válido. Solo se revisa el formato: el código no se busca en la tabla del
+// catálogo 25, por lo que un código inexistente con ese formato es aceptado
+// aquí y rechazado por SUNAT.
+func IsFormatoCodigoProductoSUNAT(codigo string) bool {
+	if len(codigo) != 8 {
+		return false
+	}
+	for _, r := range codigo {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return segmentosUNSPSC[codigo[:2]]
+}
+
+// conceptosTributarios contiene los códigos de identificación del concepto
+// tributario (catálogo 55) usados en las propiedades adicionales de los ítems
+var conceptosTributarios = map[string]string{
+	"3000": "Detracciones: Código de bienes y servicios sujetos a detracción",
+	"3001": "Detracciones: Número de cuenta en el Banco de la Nación",
+	"4000": "Beneficio hospedajes: Código país de emisión del pasaporte",
+	"4001": "Beneficio hospedajes: Código país de residencia del sujeto no domiciliado",
+	"4002": "Beneficio hospedajes: Fecha de ingreso al país",
+	"4003": "Beneficio hospedajes: Fecha de ingreso al establecimiento",
+	"4004": "Beneficio hospedajes: Fecha de salida del establecimiento",
+	"4005": "Beneficio hospedajes: Número de días de permanencia",
+	"4006": "Beneficio hospedajes: Fecha de consumo",
+	"4007": "Beneficio hospedajes: Paquete turístico - Nombres y apellidos del huésped",
+	"4008": "Beneficio hospedajes: Paquete turístico - Tipo documento identidad del huésped",
+	"4009": "Beneficio hospedajes: Paquete turístico - Número de documento identidad del huésped",
+	"5000": "Proveedores Estado: Número de expediente",
+	"5001": "Proveedores Estado: Código de unidad ejecutora",
+	"5002": "Proveedores Estado: Número de proceso de selección",
+	"5003": "Proveedores Estado: Número de contrato",
+	"7000": "Gastos art. 37 Renta: Número de placa",
+}
+
+// GetConceptoTributario retorna la descripción de un código del catálogo 55
+func GetConceptoTributario(codigo string) (string, bool) {
+	d, ok := conceptosTributarios[codigo]
+	return d, ok
+}
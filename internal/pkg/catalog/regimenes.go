@@ -0,0 +1,31 @@
+package catalog
+
+// Regimen representa un régimen de retención (catálogo 23) o de percepción (catálogo 22)
+type Regimen struct {
+	Codigo      string
+	Descripcion string
+	Porcentaje  float64
+}
+
+var regimenesRetencion = map[string]Regimen{
+	"01": {"01", "Tasa 3%", 3},
+	"02": {"02", "Tasa 6%", 6},
+}
+
+var regimenesPercepcion = map[string]Regimen{
+	"01": {"01", "Percepción venta interna", 2},
+	"02": {"02", "Percepción a la adquisición de combustible", 1},
+	"03": {"03", "Percepción realizada al agente de percepción con tasa especial", 0.5},
+}
+
+// GetRegimenRetencion busca un régimen de retención por su código del catálogo 23
+func GetRegimenRetencion(codigo string) (Regimen, bool) {
+	r, ok := regimenesRetencion[codigo]
+	return r, ok
+}
+
+// GetRegimenPercepcion busca un régimen de percepción por su código del catálogo 22
+func GetRegimenPercepcion(codigo string) (Regimen, bool) {
+	r, ok := regimenesPercepcion[codigo]
+	return r, ok
+}
@@ -0,0 +1,57 @@
+package catalog
+
+// Tributo representa un tributo del catálogo 05 de SUNAT
+type Tributo struct {
+	ID     string // código del tributo (cbc:ID del TaxScheme)
+	Nombre string // nombre del tributo (cbc:Name)
+	Codigo string // código internacional (cbc:TaxTypeCode)
+}
+
+// Tributos del catálogo 05 usados en las facturas
+var (
+	TributoIGV         = Tributo{"1000", "IGV", "VAT"}
+	TributoExportacion = Tributo{"9995", "EXP", "FRE"}
+	TributoGratuito    = Tributo{"9996", "GRA", "FRE"}
+	TributoExonerado   = Tributo{"9997", "EXO", "VAT"}
+	TributoInafecto    = Tributo{"9998", "INA", "FRE"}
+)
+
+// AfectacionIGV representa un tipo de afectación del IGV (catálogo 07)
+type AfectacionIGV struct {
+	Codigo      string
+	Descripcion string
+	// Tributo bajo el cual se informa la línea
+	Tributo Tributo
+	// Categoria es el código de categoría de impuestos (UN/ECE 5305)
+	Categoria string
+	// Onerosa indica si la operación es onerosa (false: transferencia gratuita)
+	Onerosa bool
+}
+
+var afectacionesIGV = map[string]AfectacionIGV{
+	"10": {"10", "Gravado - Operación Onerosa", TributoIGV, "S", true},
+	"11": {"11", "Gravado - Retiro por premio", TributoGratuito, "Z", false},
+	"12": {"12", "Gravado - Retiro por donación", TributoGratuito, "Z", false},
+	"13": {"13", "Gravado - Retiro", TributoGratuito, "Z", false},
+	"14": {"14", "Gravado - Retiro por publicidad", TributoGratuito, "Z", false},
+	"15": {"15", "Gravado - Bonificaciones", TributoGratuito, "Z", false},
+	"16": {"16", "Gravado - Retiro por entrega a trabajadores", TributoGratuito, "Z", false},
+	"17": {"17", "Gravado - IVAP", TributoIGV, "S", true},
+	"20": {"20", "Exonerado - Operación Onerosa", TributoExonerado, "E", true},
+	"21": {"21", "Exonerado - Transferencia gratuita", TributoGratuito, "Z", false},
+	"30": {"30", "Inafecto - Operación Onerosa", TributoInafecto, "O", true},
+	"31": {"31", "Inafecto - Retiro por Bonificación", TributoGratuito, "Z", false},
+	"32": {"32", "Inafecto - Retiro", TributoGratuito, "Z", false},
+	"33": {"33", "Inafecto - Retiro por Muestras Médicas", TributoGratuito, "Z", false},
+	"34": {"34", "Inafecto - Retiro por Convenio Colectivo", TributoGratuito, "Z", false},
+	"35": {"35", "Inafecto - Retiro por premio", TributoGratuito, "Z", false},
+	"36": {"36", "Inafecto - Retiro por publicidad", TributoGratuito, "Z", false},
+	"37": {"37", "Inafecto - Transferencia gratuita", TributoGratuito, "Z", false},
+	"40": {"40", "Exportación de Bienes o Servicios", TributoExportacion, "G", true},
+}
+
+// GetAfectacionIGV busca un tipo de afectación del IGV por su código del catálogo 07
+func GetAfectacionIGV(codigo string) (AfectacionIGV, bool) {
+	a, ok := afectacionesIGV[codigo]
+	return a, ok
+}
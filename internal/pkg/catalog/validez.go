@@ -0,0 +1,50 @@
+package catalog
+
+// Estados de la consulta integrada de validez de comprobantes de SUNAT
+
+// EstadoComprobanteNoExiste indica que SUNAT no tiene registrado el comprobante
+const EstadoComprobanteNoExiste = "0"
+
+var estadosComprobante = map[string]string{
+	"0": "NO EXISTE",
+	"1": "ACEPTADO",
+	"2": "ANULADO",
+	"3": "AUTORIZADO",
+	"4": "NO AUTORIZADO",
+}
+
+var estadosContribuyente = map[string]string{
+	"00": "ACTIVO",
+	"01": "BAJA PROVISIONAL",
+	"02": "BAJA PROVISIONAL POR OFICIO",
+	"03": "SUSPENSIÓN TEMPORAL",
+	"10": "BAJA DEFINITIVA",
+	"11": "BAJA DE OFICIO",
+	"22": "INHABILITADO-VENTA ÚNICA",
+}
+
+var condicionesDomicilio = map[string]string{
+	"00": "HABIDO",
+	"09": "PENDIENTE",
+	"11": "POR VERIFICAR",
+	"12": "NO HABIDO",
+	"20": "NO HALLADO",
+}
+
+// GetEstadoComprobante retorna la descripción del estado de un comprobante
+func GetEstadoComprobante(codigo string) (string, bool) {
+	d, ok := estadosComprobante[codigo]
+	return d, ok
+}
+
+// GetEstadoContribuyente retorna la descripción del estado del RUC emisor
+func GetEstadoContribuyente(codigo string) (string, bool) {
+	d, ok := estadosContribuyente[codigo]
+	return d, ok
+}
+
+// GetCondicionDomicilio retorna la descripción de la condición de domicilio del RUC emisor
+func GetCondicionDomicilio(codigo string) (string, bool) {
+	d, ok := condicionesDomicilio[codigo]
+	return d, ok
+}
@@ -0,0 +1,116 @@
+// Package cdr interpreta la constancia de recepción (CDR) que SUNAT devuelve
+// como un ZIP con un ApplicationResponse UBL.
+package cdr
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CDR constancia de recepción de un comprobante
+type CDR struct {
+	ID           string   `json:"id"`
+	IssueDate    string   `json:"fecha_emision"`
+	ResponseDate string   `json:"fecha_respuesta"`
+	ResponseTime string   `json:"hora_respuesta"`
+	ReferenceID  string   `json:"comprobante"`
+	ResponseCode string   `json:"codigo"`
+	Description  string   `json:"descripcion"`
+	Notes        []string `json:"observaciones,omitempty"`
+}
+
+type applicationResponse struct {
+	XMLName      xml.Name `xml:"ApplicationResponse"`
+	ID           string   `xml:"ID"`
+	IssueDate    string   `xml:"IssueDate"`
+	ResponseDate string   `xml:"ResponseDate"`
+	ResponseTime string   `xml:"ResponseTime"`
+	Notes        []string `xml:"Note"`
+	Response     struct {
+		ReferenceID  string `xml:"ReferenceID"`
+		ResponseCode string `xml:"ResponseCode"`
+		Description  string `xml:"Description"`
+	} `xml:"DocumentResponse>Response"`
+	DocumentReferenceID string `xml:"DocumentResponse>DocumentReference>ID"`
+}
+
+// Parse lee el CDR contenido en un ZIP
+func Parse(zipContent []byte) (*CDR, error) {
+	reader, err := zip.NewReader(bytes.NewReader(zipContent), int64(len(zipContent)))
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo ZIP del CDR: %v", err)
+	}
+
+	for _, file := range reader.File {
+		if !strings.HasSuffix(strings.ToLower(file.Name), ".xml") {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("error abriendo %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo %s: %v", file.Name, err)
+		}
+		return ParseXML(data)
+	}
+
+	return nil, fmt.Errorf("el ZIP del CDR no contiene un XML")
+}
+
+// ParseXML lee el ApplicationResponse de un CDR
+func ParseXML(data []byte) (*CDR, error) {
+	var ar applicationResponse
+	if err := xml.Unmarshal(data, &ar); err != nil {
+		return nil, fmt.Errorf("error leyendo CDR: %v", err)
+	}
+
+	c := &CDR{
+		ID:           strings.TrimSpace(ar.ID),
+		IssueDate:    strings.TrimSpace(ar.IssueDate),
+		ResponseDate: strings.TrimSpace(ar.ResponseDate),
+		ResponseTime: strings.TrimSpace(ar.ResponseTime),
+		ReferenceID:  strings.TrimSpace(ar.Response.ReferenceID),
+		ResponseCode: strings.TrimSpace(ar.Response.ResponseCode),
+		Description:  strings.TrimSpace(ar.Response.Description),
+	}
+	if c.ReferenceID == "" {
+		c.ReferenceID = strings.TrimSpace(ar.DocumentReferenceID)
+	}
+	for _, note := range ar.Notes {
+		if note = strings.TrimSpace(note); note != "" {
+			c.Notes = append(c.Notes, note)
+		}
+	}
+	return c, nil
+}
+
+// Accepted indica si SUNAT aceptó el comprobante. El código 0 es una
+// aceptación y los códigos desde 4000 son observaciones que no lo invalidan;
+// del 2000 al 3999 son rechazos.
+func (c *CDR) Accepted() bool {
+	code, err := strconv.Atoi(c.ResponseCode)
+	if err != nil {
+		return false
+	}
+	return code == 0 || code >= 4000
+}
+
+// Estado retorna el estado del comprobante según el CDR
+func (c *CDR) Estado() string {
+	switch {
+	case !c.Accepted():
+		return "rechazado"
+	case len(c.Notes) > 0 || c.ResponseCode != "0":
+		return "aceptado con observaciones"
+	default:
+		return "aceptado"
+	}
+}
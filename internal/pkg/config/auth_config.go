@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// AuthConfig contiene la configuración de autenticación de la API
+type AuthConfig struct {
+	Disabled         bool   // sin autenticación; solo para desarrollo local
+	APIKeysFile      string // JSON con las API keys (hash SHA-256), sus RUC y permisos
+	JWTSecret        string // secreto HS256 de los JWT
+	JWTPublicKeyFile string // PEM con la clave pública RS256 de los JWT
+	JWTIssuer        string // iss esperado; vacío no se valida
+	JWTAudience      string // aud esperado; vacío no se valida
+}
+
+// GetAuthConfig retorna la configuración de autenticación, ajustable con
+// API_KEYS_FILE, JWT_SECRET, JWT_PUBLIC_KEY_FILE, JWT_ISSUER, JWT_AUDIENCE y
+// AUTH_DISABLED=true
+func GetAuthConfig() AuthConfig {
+	disabled := strings.ToLower(os.Getenv("AUTH_DISABLED"))
+	return AuthConfig{
+		Disabled:         disabled == "true" || disabled == "1",
+		APIKeysFile:      os.Getenv("API_KEYS_FILE"),
+		JWTSecret:        os.Getenv("JWT_SECRET"),
+		JWTPublicKeyFile: os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DownloadConfig contiene la configuración de los enlaces de descarga firmados
+type DownloadConfig struct {
+	Secret  string        // secreto HMAC, compartido por todas las réplicas
+	TTL     time.Duration // vigencia de un enlace
+	BaseURL string        // URL pública de la API, p. ej. https://api.example.com
+}
+
+// GetDownloadConfig retorna la configuración de los enlaces de descarga,
+// ajustable con DOWNLOAD_URL_SECRET, DOWNLOAD_URL_TTL y PUBLIC_BASE_URL
+func GetDownloadConfig() DownloadConfig {
+	cfg := DownloadConfig{
+		Secret:  os.Getenv("DOWNLOAD_URL_SECRET"),
+		TTL:     15 * time.Minute,
+		BaseURL: strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"),
+	}
+	if d, err := time.ParseDuration(os.Getenv("DOWNLOAD_URL_TTL")); err == nil && d > 0 {
+		cfg.TTL = d
+	}
+	return cfg
+}
+
+// Validate verifica que el secreto y la URL pública estén configurados
+func (c DownloadConfig) Validate() error {
+	if c.Secret == "" {
+		return fmt.Errorf("DOWNLOAD_URL_SECRET es obligatorio")
+	}
+	u, err := url.Parse(c.BaseURL)
+	if c.BaseURL == "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("PUBLIC_BASE_URL debe ser la URL pública de la API (http o https)")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPClientConfig contiene la configuración del cliente HTTP de las llamadas a SUNAT
+type HTTPClientConfig struct {
+	CABundle         string                   // PEM con CA adicionales a las del sistema
+	ProxyURL         string                   // proxy de salida; vacío usa HTTPS_PROXY/HTTP_PROXY
+	Timeout          time.Duration            // tiempo máximo por defecto de una operación
+	Timeouts         map[string]time.Duration // tiempo máximo por operación
+	MaxIdleConns     int                      // conexiones inactivas por host que se mantienen abiertas
+	IdleConnTimeout  time.Duration
+	MaxRequestBytes  int64         // tamaño máximo del cuerpo enviado
+	MaxResponseBytes int64         // tamaño máximo del cuerpo recibido
+	RateLimit        float64       // llamadas por segundo a SUNAT por RUC emisor; 0 sin límite
+	RateBurst        int           // ráfaga de llamadas permitida
+	RateMaxWait      time.Duration // espera máxima por un turno antes de fallar
+}
+
+// Operaciones con tiempo máximo propio
+var operacionesHTTP = []string{"sendBill", "sendSummary", "sendPack", "getStatus", "getStatusCdr", "token", "gre", "validez"}
+
+// GetHTTPClientConfig retorna la configuración del cliente HTTP de SUNAT,
+// ajustable con SUNAT_CA_BUNDLE, SUNAT_HTTP_PROXY, SUNAT_HTTP_TIMEOUT,
+// SUNAT_HTTP_TIMEOUT_<OPERACION> (por ejemplo SUNAT_HTTP_TIMEOUT_SENDBILL),
+// SUNAT_HTTP_MAX_IDLE_CONNS, SUNAT_HTTP_MAX_REQUEST_BYTES,
+// SUNAT_HTTP_MAX_RESPONSE_BYTES, SUNAT_HTTP_RATE_LIMIT (llamadas por
+// segundo), SUNAT_HTTP_RATE_BURST y SUNAT_HTTP_RATE_MAX_WAIT
+func GetHTTPClientConfig() HTTPClientConfig {
+	cfg := HTTPClientConfig{
+		CABundle: os.Getenv("SUNAT_CA_BUNDLE"),
+		ProxyURL: os.Getenv("SUNAT_HTTP_PROXY"),
+		Timeout:  60 * time.Second,
+		Timeouts: map[string]time.Duration{
+			"getStatus":    30 * time.Second,
+			"getStatusCdr": 30 * time.Second,
+			"token":        15 * time.Second,
+			"validez":      15 * time.Second,
+		},
+		MaxIdleConns:     10,
+		IdleConnTimeout:  90 * time.Second,
+		MaxRequestBytes:  10 << 20,
+		MaxResponseBytes: 10 << 20,
+		RateLimit:        5,
+		RateBurst:        10,
+		RateMaxWait:      5 * time.Second,
+	}
+	if d, err := time.ParseDuration(os.Getenv("SUNAT_HTTP_TIMEOUT")); err == nil && d > 0 {
+		cfg.Timeout = d
+	}
+	for _, operacion := range operacionesHTTP {
+		if d, err := time.ParseDuration(os.Getenv("SUNAT_HTTP_TIMEOUT_" + strings.ToUpper(operacion))); err == nil && d > 0 {
+			cfg.Timeouts[operacion] = d
+		}
+	}
+	if n, err := strconv.Atoi(os.Getenv("SUNAT_HTTP_MAX_IDLE_CONNS")); err == nil && n > 0 {
+		cfg.MaxIdleConns = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("SUNAT_HTTP_MAX_REQUEST_BYTES"), 10, 64); err == nil && n > 0 {
+		cfg.MaxRequestBytes = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("SUNAT_HTTP_MAX_RESPONSE_BYTES"), 10, 64); err == nil && n > 0 {
+		cfg.MaxResponseBytes = n
+	}
+	if rate, err := strconv.ParseFloat(os.Getenv("SUNAT_HTTP_RATE_LIMIT"), 64); err == nil && rate >= 0 {
+		cfg.RateLimit = rate
+	}
+	if n, err := strconv.Atoi(os.Getenv("SUNAT_HTTP_RATE_BURST")); err == nil && n > 0 {
+		cfg.RateBurst = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SUNAT_HTTP_RATE_MAX_WAIT")); err == nil && d >= 0 {
+		cfg.RateMaxWait = d
+	}
+	return cfg
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// APILimitsConfig contiene los límites de las solicitudes a la API
+type APILimitsConfig struct {
+	IPPorMinuto      float64 // solicitudes por minuto por IP, antes de autenticar; 0 sin límite
+	IPRafaga         int
+	ClientePorMinuto float64 // solicitudes por minuto por API key o JWT; 0 sin límite
+	ClienteRafaga    int
+	EmisorPorMinuto  float64 // comprobantes por minuto por RUC emisor; 0 sin límite
+	EmisorRafaga     int
+	MaxBodyBytes     int64 // tamaño máximo del cuerpo de una solicitud
+	MaxLineas        int   // líneas (ítems o documentos relacionados) por comprobante
+
+	// ProxiesConfiables son los proxies cuyo X-Forwarded-For identifica la
+	// IP del cliente; sin proxies se usa la IP de la conexión
+	ProxiesConfiables []string
+}
+
+// GetAPILimitsConfig retorna los límites de la API, ajustables con
+// RATE_LIMIT_IP, RATE_LIMIT_CLIENTE y RATE_LIMIT_EMISOR (por minuto),
+// RATE_LIMIT_IP_RAFAGA, RATE_LIMIT_CLIENTE_RAFAGA, RATE_LIMIT_EMISOR_RAFAGA,
+// API_MAX_BODY_BYTES, API_MAX_LINEAS y TRUSTED_PROXIES (separados por comas)
+func GetAPILimitsConfig() APILimitsConfig {
+	cfg := APILimitsConfig{
+		IPPorMinuto:      300,
+		IPRafaga:         30,
+		ClientePorMinuto: 120,
+		ClienteRafaga:    20,
+		EmisorPorMinuto:  60,
+		EmisorRafaga:     10,
+		MaxBodyBytes:     1 << 20,
+		MaxLineas:        500,
+	}
+	if n, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_IP"), 64); err == nil && n >= 0 {
+		cfg.IPPorMinuto = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("RATE_LIMIT_IP_RAFAGA")); err == nil && n > 0 {
+		cfg.IPRafaga = n
+	}
+	if n, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_CLIENTE"), 64); err == nil && n >= 0 {
+		cfg.ClientePorMinuto = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("RATE_LIMIT_CLIENTE_RAFAGA")); err == nil && n > 0 {
+		cfg.ClienteRafaga = n
+	}
+	if n, err := strconv.ParseFloat(os.Getenv("RATE_LIMIT_EMISOR"), 64); err == nil && n >= 0 {
+		cfg.EmisorPorMinuto = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("RATE_LIMIT_EMISOR_RAFAGA")); err == nil && n > 0 {
+		cfg.EmisorRafaga = n
+	}
+	if n, err := strconv.ParseInt(os.Getenv("API_MAX_BODY_BYTES"), 10, 64); err == nil && n > 0 {
+		cfg.MaxBodyBytes = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("API_MAX_LINEAS")); err == nil && n > 0 {
+		cfg.MaxLineas = n
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			cfg.ProxiesConfiables = append(cfg.ProxiesConfiables, proxy)
+		}
+	}
+	return cfg
+}
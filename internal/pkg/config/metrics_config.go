@@ -0,0 +1,23 @@
+package config
+
+import "os"
+
+// MetricsConfig contiene la configuración del listener de métricas
+type MetricsConfig struct {
+	Addr string // dirección del listener interno de /metrics; vacía lo desactiva
+}
+
+// GetMetricsConfig retorna la configuración de las métricas, ajustable con
+// METRICS_ADDR (127.0.0.1:9090 por defecto; "off" desactiva el listener).
+// La dirección no debe publicarse junto con la API.
+func GetMetricsConfig() MetricsConfig {
+	cfg := MetricsConfig{Addr: "127.0.0.1:9090"}
+	switch addr := os.Getenv("METRICS_ADDR"); addr {
+	case "":
+	case "off":
+		cfg.Addr = ""
+	default:
+		cfg.Addr = addr
+	}
+	return cfg
+}
@@ -1,5 +1,11 @@
 package config
 
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
 // SUNATCredentials contiene las credenciales para el servicio de SUNAT
 type SUNATCredentials struct {
 	RUC      string
@@ -19,3 +25,177 @@ func GetSUNATCredentials() SUNATCredentials {
 		URLProd:  "https://e-factura.sunat.gob.pe/ol-ti-itcpfegem/billService",
 	}
 }
+
+// GetSUNATProduccion indica si se usan los servicios de producción de SUNAT
+// (SUNAT_PRODUCCION=true); por defecto se usa beta
+func GetSUNATProduccion() bool {
+	prod, _ := strconv.ParseBool(os.Getenv("SUNAT_PRODUCCION"))
+	return prod
+}
+
+// SOLCredentials usuario SOL de los servicios SOAP (WS-Security)
+type SOLCredentials struct {
+	Username string // RUC seguido del usuario SOL
+	Password string
+}
+
+// GetSOLCredentials retorna el usuario SOL con el que el emisor ruc firma los
+// envíos SOAP; SUNAT_SOL_USUARIO_<RUC> tiene prioridad sobre SUNAT_SOL_USUARIO.
+// Sin RUC se usa el usuario de pruebas configurado.
+func GetSOLCredentials(ruc string) SOLCredentials {
+	sol := GetSUNATCredentials()
+	username := sol.RUC
+	if ruc != "" {
+		username = ruc + envPorRUC("SUNAT_SOL_USUARIO", ruc, sol.Username)
+	}
+	return SOLCredentials{
+		Username: username,
+		Password: envPorRUC("SUNAT_SOL_CLAVE", ruc, sol.Password),
+	}
+}
+
+// SOAPEndpoints URLs de los servicios SOAP de SUNAT
+type SOAPEndpoints struct {
+	BillService     string // facturas, notas, resúmenes y bajas
+	ConsultService  string // consulta de estado y CDR de comprobantes
+	OtrosCPEService string // retenciones y percepciones
+}
+
+// GetSOAPEndpoints retorna las URLs de los servicios SOAP de producción o de
+// beta. SUNAT_BILL_SERVICE_URL, SUNAT_CONSULT_SERVICE_URL y
+// SUNAT_OTROS_CPE_SERVICE_URL las reemplazan, por ejemplo para usar el
+// simulador local (cmd/sunat-sim).
+func GetSOAPEndpoints(isProd bool) SOAPEndpoints {
+	endpoints := SOAPEndpoints{
+		BillService:     "https://e-beta.sunat.gob.pe/ol-ti-itcpfegem-beta/billService",
+		ConsultService:  "https://e-beta.sunat.gob.pe/ol-it-wsconscpegem-beta/billConsultService",
+		OtrosCPEService: "https://e-beta.sunat.gob.pe/ol-ti-itemision-otroscpe-gem-beta/billService",
+	}
+	if isProd {
+		endpoints = SOAPEndpoints{
+			BillService:     "https://e-factura.sunat.gob.pe/ol-ti-itcpfegem/billService",
+			ConsultService:  "https://e-factura.sunat.gob.pe/ol-it-wsconscpegem/billConsultService",
+			OtrosCPEService: "https://e-factura.sunat.gob.pe/ol-ti-itemision-otroscpe-gem/billService",
+		}
+	}
+	if v := os.Getenv("SUNAT_BILL_SERVICE_URL"); v != "" {
+		endpoints.BillService = v
+	}
+	if v := os.Getenv("SUNAT_CONSULT_SERVICE_URL"); v != "" {
+		endpoints.ConsultService = v
+	}
+	if v := os.Getenv("SUNAT_OTROS_CPE_SERVICE_URL"); v != "" {
+		endpoints.OtrosCPEService = v
+	}
+	return endpoints
+}
+
+// GRECredentials contiene las credenciales de la API REST de guías de remisión
+type GRECredentials struct {
+	ClientID     string
+	ClientSecret string
+	Username     string // RUC seguido del usuario SOL
+	Password     string
+	TokenURL     string
+	APIURL       string
+}
+
+// GetGRECredentials retorna las credenciales del emisor para la API de guías
+// de remisión. El client_id y client_secret se generan en el menú SOL de cada
+// emisor; SUNAT_GRE_CLIENT_ID_<RUC> tiene prioridad sobre SUNAT_GRE_CLIENT_ID.
+func GetGRECredentials(ruc string) GRECredentials {
+	sol := GetSUNATCredentials()
+	creds := GRECredentials{
+		ClientID:     envPorRUC("SUNAT_GRE_CLIENT_ID", ruc, ""),
+		ClientSecret: envPorRUC("SUNAT_GRE_CLIENT_SECRET", ruc, ""),
+		Username:     ruc + envPorRUC("SUNAT_SOL_USUARIO", ruc, sol.Username),
+		Password:     envPorRUC("SUNAT_SOL_CLAVE", ruc, sol.Password),
+		TokenURL:     "https://api-seguridad.sunat.gob.pe/v1/clientessol",
+		APIURL:       "https://api-cpe.sunat.gob.pe/v1/contribuyente/gem",
+	}
+	if v := os.Getenv("SUNAT_GRE_TOKEN_URL"); v != "" {
+		creds.TokenURL = v
+	}
+	if v := os.Getenv("SUNAT_GRE_API_URL"); v != "" {
+		creds.APIURL = v
+	}
+	return creds
+}
+
+// ValidezCredentials contiene las credenciales de la consulta integrada de
+// validez de comprobantes (flujo client_credentials)
+type ValidezCredentials struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	APIURL       string
+}
+
+// GetValidezCredentials retorna las credenciales del RUC consultante para la
+// consulta de validez; SUNAT_VALIDEZ_CLIENT_ID_<RUC> tiene prioridad sobre
+// SUNAT_VALIDEZ_CLIENT_ID.
+func GetValidezCredentials(ruc string) ValidezCredentials {
+	creds := ValidezCredentials{
+		ClientID:     envPorRUC("SUNAT_VALIDEZ_CLIENT_ID", ruc, ""),
+		ClientSecret: envPorRUC("SUNAT_VALIDEZ_CLIENT_SECRET", ruc, ""),
+		TokenURL:     "https://api-seguridad.sunat.gob.pe/v1/clientesextranet",
+		APIURL:       "https://api.sunat.gob.pe/v1/contribuyente/contribuyentes",
+	}
+	if v := os.Getenv("SUNAT_VALIDEZ_TOKEN_URL"); v != "" {
+		creds.TokenURL = v
+	}
+	if v := os.Getenv("SUNAT_VALIDEZ_API_URL"); v != "" {
+		creds.APIURL = v
+	}
+	return creds
+}
+
+// envPorRUC lee la variable name_<RUC>, luego name y por último el valor por defecto
+func envPorRUC(name, ruc, fallback string) string {
+	if v := os.Getenv(name + "_" + ruc); v != "" {
+		return v
+	}
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// TicketPollingConfig contiene la configuración de la consulta periódica de tickets
+type TicketPollingConfig struct {
+	Intervalo    time.Duration // espera antes de la primera consulta
+	IntervaloMax time.Duration // espera máxima entre consultas
+	MaxIntentos  int           // consultas antes de abandonar un ticket
+	Archivo      string        // archivo JSON donde se guardan los tickets pendientes; vacío solo en memoria
+	Retencion    time.Duration // tiempo que un ticket terminado sigue disponible para consulta
+}
+
+// GetTicketPollingConfig retorna la configuración de la consulta de tickets,
+// ajustable con SUNAT_TICKET_INTERVALO, SUNAT_TICKET_INTERVALO_MAX (duraciones
+// como "30s" o "5m"), SUNAT_TICKET_MAX_INTENTOS, SUNAT_TICKETS_FILE y
+// SUNAT_TICKET_RETENCION
+func GetTicketPollingConfig() TicketPollingConfig {
+	cfg := TicketPollingConfig{
+		Intervalo:    30 * time.Second,
+		IntervaloMax: 10 * time.Minute,
+		MaxIntentos:  30,
+		Archivo:      "temp/tickets.json",
+		Retencion:    24 * time.Hour,
+	}
+	if path := os.Getenv("SUNAT_TICKETS_FILE"); path != "" {
+		cfg.Archivo = path
+	}
+	if d, err := time.ParseDuration(os.Getenv("SUNAT_TICKET_INTERVALO")); err == nil && d > 0 {
+		cfg.Intervalo = d
+	}
+	if d, err := time.ParseDuration(os.Getenv("SUNAT_TICKET_INTERVALO_MAX")); err == nil && d > 0 {
+		cfg.IntervaloMax = d
+	}
+	if n, err := strconv.Atoi(os.Getenv("SUNAT_TICKET_MAX_INTENTOS")); err == nil && n > 0 {
+		cfg.MaxIntentos = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("SUNAT_TICKET_RETENCION")); err == nil && d > 0 {
+		cfg.Retencion = d
+	}
+	return cfg
+}
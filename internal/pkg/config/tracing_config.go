@@ -0,0 +1,44 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Exportadores de trazas
+const (
+	TracingNone   = "none"
+	TracingOTLP   = "otlp"
+	TracingStdout = "stdout"
+)
+
+// TracingConfig contiene la configuración de las trazas de OpenTelemetry
+type TracingConfig struct {
+	Exporter    string  // none, otlp o stdout
+	ServiceName string  // service.name de las trazas
+	SampleRatio float64 // proporción de solicitudes trazadas (0 a 1)
+}
+
+// GetTracingConfig retorna la configuración de trazas, ajustable con
+// OTEL_TRACES_EXPORTER (none por defecto), OTEL_SERVICE_NAME y
+// OTEL_TRACES_SAMPLER_ARG. El destino del exportador OTLP se configura con
+// las variables estándar OTEL_EXPORTER_OTLP_ENDPOINT y
+// OTEL_EXPORTER_OTLP_HEADERS.
+func GetTracingConfig() TracingConfig {
+	cfg := TracingConfig{
+		Exporter:    TracingNone,
+		ServiceName: "ubl-converter",
+		SampleRatio: 1,
+	}
+	if exporter := strings.ToLower(os.Getenv("OTEL_TRACES_EXPORTER")); exporter != "" {
+		cfg.Exporter = exporter
+	}
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		cfg.ServiceName = name
+	}
+	if ratio, err := strconv.ParseFloat(os.Getenv("OTEL_TRACES_SAMPLER_ARG"), 64); err == nil && ratio >= 0 && ratio <= 1 {
+		cfg.SampleRatio = ratio
+	}
+	return cfg
+}
@@ -0,0 +1,24 @@
+// Package exchange obtiene el tipo de cambio de una moneda extranjera a soles
+// para la fecha de emisión de un comprobante.
+package exchange
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRateNotFound indica que no hay tipo de cambio publicado para la moneda y fecha
+var ErrRateNotFound = errors.New("tipo de cambio no encontrado")
+
+// Rate representa el tipo de cambio de una moneda a soles en una fecha
+type Rate struct {
+	Fecha  time.Time
+	Moneda string
+	Compra float64
+	Venta  float64
+}
+
+// RateProvider obtiene el tipo de cambio vigente de una moneda en una fecha
+type RateProvider interface {
+	Rate(moneda string, fecha time.Time) (Rate, error)
+}
@@ -0,0 +1,97 @@
+package exchange
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxDiasSinPublicacion es la cantidad de días hacia atrás en los que se busca
+// la última publicación cuando la SBS no publica tipo de cambio en la fecha
+// (feriados y fines de semana)
+const MaxDiasSinPublicacion = 10
+
+// SBSFileProvider obtiene el tipo de cambio de una tabla de la SBS guardada
+// en un archivo CSV con las columnas fecha (YYYY-MM-DD), moneda, compra y
+// venta. Las líneas vacías y las que empiezan con # se ignoran, así como una
+// cabecera cuya primera columna sea "fecha".
+type SBSFileProvider struct {
+	// rates contiene las publicaciones por moneda, ordenadas por fecha
+	rates map[string][]Rate
+}
+
+// NewSBSFileProvider carga la tabla de tipos de cambio desde un archivo
+func NewSBSFileProvider(path string) (*SBSFileProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo tabla de tipo de cambio: %v", err)
+	}
+	defer f.Close()
+	return ParseSBSRates(f)
+}
+
+// ParseSBSRates lee una tabla de tipos de cambio de la SBS en formato CSV
+func ParseSBSRates(r io.Reader) (*SBSFileProvider, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	p := &SBSFileProvider{rates: make(map[string][]Rate)}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo tabla de tipo de cambio: %v", err)
+		}
+		if strings.EqualFold(record[0], "fecha") {
+			continue
+		}
+
+		fecha, err := time.Parse("2006-01-02", record[0])
+		if err != nil {
+			return nil, fmt.Errorf("fecha inválida en tabla de tipo de cambio: %s", record[0])
+		}
+		compra, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("tipo de cambio compra inválido: %s", record[2])
+		}
+		venta, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("tipo de cambio venta inválido: %s", record[3])
+		}
+
+		moneda := strings.ToUpper(record[1])
+		p.rates[moneda] = append(p.rates[moneda], Rate{Fecha: fecha, Moneda: moneda, Compra: compra, Venta: venta})
+	}
+
+	for _, rates := range p.rates {
+		sort.Slice(rates, func(i, j int) bool { return rates[i].Fecha.Before(rates[j].Fecha) })
+	}
+	return p, nil
+}
+
+// Rate retorna el tipo de cambio publicado en la fecha o, si no hubo
+// publicación ese día, el último publicado dentro de MaxDiasSinPublicacion
+func (p *SBSFileProvider) Rate(moneda string, fecha time.Time) (Rate, error) {
+	rates := p.rates[moneda]
+	dia := time.Date(fecha.Year(), fecha.Month(), fecha.Day(), 0, 0, 0, 0, time.UTC)
+
+	// Primera publicación posterior a la fecha
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Fecha.After(dia) })
+	if i == 0 {
+		return Rate{}, ErrRateNotFound
+	}
+	rate := rates[i-1]
+	if dia.Sub(rate.Fecha) > MaxDiasSinPublicacion*24*time.Hour {
+		return Rate{}, ErrRateNotFound
+	}
+	return rate, nil
+}
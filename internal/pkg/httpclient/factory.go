@@ -0,0 +1,171 @@
+// Package httpclient crea los clientes HTTP de las llamadas a SUNAT: todos
+// comparten un transporte con verificación TLS, pool de conexiones, proxy,
+// límites de tamaño y de frecuencia por RUC emisor, y cada operación tiene
+// su propio tiempo máximo.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"ubl-converter/internal/pkg/config"
+	"ubl-converter/internal/pkg/ratelimit"
+)
+
+// Operaciones de SUNAT con tiempo máximo configurable
+const (
+	OpSendBill     = "sendBill"
+	OpSendSummary  = "sendSummary"
+	OpSendPack     = "sendPack"
+	OpGetStatus    = "getStatus"
+	OpGetStatusCdr = "getStatusCdr"
+	OpToken        = "token"
+	OpGRE          = "gre"
+	OpValidez      = "validez"
+)
+
+// Errores de los límites de tamaño
+var (
+	ErrRequestTooLarge  = errors.New("el cuerpo de la solicitud excede el tamaño máximo")
+	ErrResponseTooLarge = errors.New("la respuesta excede el tamaño máximo")
+)
+
+// Factory crea clientes HTTP que comparten el mismo transporte
+type Factory struct {
+	cfg       config.HTTPClientConfig
+	transport http.RoundTripper
+}
+
+// NewFactory crea el transporte compartido con la configuración indicada
+func NewFactory(cfg config.HTTPClientConfig) (*Factory, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		pemData, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("error leyendo el bundle de CA: %v", err)
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("el bundle de CA %s no contiene certificados", cfg.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("URL de proxy inválida: %v", err)
+		}
+		proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport := &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          cfg.MaxIdleConns * 4,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConns,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	return &Factory{
+		cfg: cfg,
+		transport: &limitedTransport{
+			base: &rateLimitedTransport{
+				base:      transport,
+				limiter:   ratelimit.New(cfg.RateLimit, cfg.RateBurst),
+				maxEspera: cfg.RateMaxWait,
+			},
+			maxRequestBytes:  cfg.MaxRequestBytes,
+			maxResponseBytes: cfg.MaxResponseBytes,
+		},
+	}, nil
+}
+
+// Client retorna un cliente con el tiempo máximo de la operación
+func (f *Factory) Client(operacion string) *http.Client {
+	return &http.Client{
+		Timeout:   f.Timeout(operacion),
+		Transport: f.transport,
+	}
+}
+
+// Timeout retorna el tiempo máximo configurado para la operación
+func (f *Factory) Timeout(operacion string) time.Duration {
+	if d, ok := f.cfg.Timeouts[operacion]; ok && d > 0 {
+		return d
+	}
+	return f.cfg.Timeout
+}
+
+// limitedTransport rechaza las solicitudes y respuestas que exceden el tamaño máximo
+type limitedTransport struct {
+	base             http.RoundTripper
+	maxRequestBytes  int64
+	maxResponseBytes int64
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.maxRequestBytes > 0 && req.Body != nil {
+		if req.ContentLength > t.maxRequestBytes {
+			req.Body.Close()
+			return nil, ErrRequestTooLarge
+		}
+		if req.ContentLength < 0 {
+			req = req.Clone(req.Context())
+			req.Body = &limitedBody{ReadCloser: req.Body, remaining: t.maxRequestBytes, err: ErrRequestTooLarge}
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || t.maxResponseBytes <= 0 {
+		return resp, err
+	}
+	if resp.ContentLength > t.maxResponseBytes {
+		resp.Body.Close()
+		return nil, ErrResponseTooLarge
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxResponseBytes, err: ErrResponseTooLarge}
+	return resp, nil
+}
+
+// limitedBody retorna err cuando se leen más de remaining bytes
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	// Se lee un byte más del límite para distinguir un cuerpo que lo excede
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+	return n, err
+}
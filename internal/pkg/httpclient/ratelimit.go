@@ -0,0 +1,65 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ubl-converter/internal/pkg/ratelimit"
+)
+
+// RateLimitError la llamada excede el límite de llamadas a SUNAT del emisor
+type RateLimitError struct {
+	RUC        string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("se excedió el límite de llamadas a SUNAT del RUC %s; reintente en %s", e.RUC, e.RetryAfter.Round(time.Second))
+}
+
+type rucKey struct{}
+
+// WithRUC retorna una copia de ctx con el RUC emisor de la llamada, que
+// determina el límite de frecuencia que se le aplica
+func WithRUC(ctx context.Context, ruc string) context.Context {
+	return context.WithValue(ctx, rucKey{}, ruc)
+}
+
+func rucFromContext(ctx context.Context) string {
+	ruc, _ := ctx.Value(rucKey{}).(string)
+	return ruc
+}
+
+// rateLimitedTransport espera un turno del RUC emisor antes de cada llamada
+// y falla con *RateLimitError si la espera excede maxEspera
+type rateLimitedTransport struct {
+	base      http.RoundTripper
+	limiter   *ratelimit.Limiter
+	maxEspera time.Duration
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ruc := rucFromContext(req.Context())
+	espera, ok := t.limiter.Reserve(ruc, t.maxEspera)
+	if !ok {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, &RateLimitError{RUC: ruc, RetryAfter: espera}
+	}
+	if espera > 0 {
+		timer := time.NewTimer(espera)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			if req.Body != nil {
+				req.Body.Close()
+			}
+			return nil, req.Context().Err()
+		}
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,126 @@
+// Package metrics expone las métricas de Prometheus de la emisión de
+// comprobantes: documentos por resultado, latencias de conversión, firma y
+// SUNAT, excepciones de SUNAT, tickets pendientes y vigencia del certificado.
+package metrics
+
+import (
+	"crypto/x509"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Resultados de una operación
+const (
+	ResultadoOK    = "ok"
+	ResultadoFault = "fault" // SUNAT respondió con una excepción
+	ResultadoError = "error" // error propio o de comunicación
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	documentos = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ubl_documentos_total",
+		Help: "Comprobantes enviados por tipo, RUC emisor y resultado (estado del CDR o error).",
+	}, []string{"tipo", "ruc", "resultado"})
+
+	conversion = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ubl_conversion_duracion_segundos",
+		Help:    "Tiempo de conversión a UBL (incluye la firma) por tipo de comprobante.",
+		Buckets: prometheus.ExponentialBuckets(0.005, 2, 10),
+	}, []string{"tipo", "resultado"})
+
+	firma = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ubl_firma_duracion_segundos",
+		Help:    "Tiempo de firma digital de un XML.",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 10),
+	}, []string{"resultado"})
+
+	sunatDuracion = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sunat_solicitud_duracion_segundos",
+		Help:    "Duración de las llamadas SOAP a SUNAT por operación y resultado.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 20, 30, 60},
+	}, []string{"operacion", "resultado"})
+
+	sunatErrores = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "sunat_errores_total",
+		Help: "Errores de SUNAT por operación, código y categoría.",
+	}, []string{"operacion", "codigo", "categoria"})
+
+	ticketsPendientes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sunat_tickets_pendientes",
+		Help: "Tickets en proceso en la cola de consulta, por tipo.",
+	}, []string{"tipo"})
+
+	certificadoExpira = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ubl_certificado_expiracion_timestamp_segundos",
+		Help: "Fecha de vencimiento (Unix) del certificado de firma.",
+	}, []string{"sujeto", "serie"})
+)
+
+func init() {
+	registry.MustRegister(
+		documentos, conversion, firma, sunatDuracion, sunatErrores, ticketsPendientes, certificadoExpira,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler retorna el handler HTTP de /metrics. Debe servirse en un listener
+// interno: las métricas no pasan por la autenticación de la API.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegistrarDocumento cuenta un comprobante enviado con su resultado. Los
+// emisores son los configurados en las API keys, por lo que la cantidad de
+// series está acotada.
+func RegistrarDocumento(tipo, ruc, resultado string) {
+	documentos.WithLabelValues(tipo, ruc, resultado).Inc()
+}
+
+// ObservarConversion registra la duración de una conversión a UBL
+func ObservarConversion(tipo string, inicio time.Time, err error) {
+	conversion.WithLabelValues(tipo, resultado(err)).Observe(time.Since(inicio).Seconds())
+}
+
+// ObservarFirma registra la duración de una firma digital
+func ObservarFirma(inicio time.Time, err error) {
+	firma.WithLabelValues(resultado(err)).Observe(time.Since(inicio).Seconds())
+}
+
+// ObservarSUNAT registra la duración de una llamada a SUNAT; resultado es
+// ResultadoOK, ResultadoFault o ResultadoError
+func ObservarSUNAT(operacion, resultado string, duracion time.Duration) {
+	sunatDuracion.WithLabelValues(operacion, resultado).Observe(duracion.Seconds())
+}
+
+// RegistrarErrorSUNAT cuenta un error de SUNAT
+func RegistrarErrorSUNAT(operacion, codigo, categoria string) {
+	sunatErrores.WithLabelValues(operacion, codigo, categoria).Inc()
+}
+
+// SetTicketsPendientes actualiza la cantidad de tickets en proceso de un tipo
+func SetTicketsPendientes(tipo string, n int) {
+	ticketsPendientes.WithLabelValues(tipo).Set(float64(n))
+}
+
+// SetCertificado publica la fecha de vencimiento del certificado de firma
+func SetCertificado(cert *x509.Certificate) {
+	if cert == nil {
+		return
+	}
+	certificadoExpira.WithLabelValues(cert.Subject.CommonName, cert.SerialNumber.String()).
+		Set(float64(cert.NotAfter.Unix()))
+}
+
+func resultado(err error) string {
+	if err != nil {
+		return ResultadoError
+	}
+	return ResultadoOK
+}
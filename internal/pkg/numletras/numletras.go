@@ -0,0 +1,126 @@
+// Package numletras convierte importes a su representación en letras en
+// español, según el formato de la leyenda 1000 de SUNAT
+// ("CIENTO DIECIOCHO CON 00/100 SOLES").
+package numletras
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Maximo es el mayor número entero que se puede convertir a letras
+const Maximo = 999999999999999
+
+var unidades = []string{
+	"", "UNO", "DOS", "TRES", "CUATRO", "CINCO", "SEIS", "SIETE", "OCHO", "NUEVE",
+	"DIEZ", "ONCE", "DOCE", "TRECE", "CATORCE", "QUINCE", "DIECISÉIS", "DIECISIETE", "DIECIOCHO", "DIECINUEVE",
+	"VEINTE", "VEINTIUNO", "VEINTIDÓS", "VEINTITRÉS", "VEINTICUATRO", "VEINTICINCO", "VEINTISÉIS", "VEINTISIETE", "VEINTIOCHO", "VEINTINUEVE",
+}
+
+var decenas = []string{
+	"", "", "", "TREINTA", "CUARENTA", "CINCUENTA", "SESENTA", "SETENTA", "OCHENTA", "NOVENTA",
+}
+
+var centenas = []string{
+	"", "CIENTO", "DOSCIENTOS", "TRESCIENTOS", "CUATROCIENTOS", "QUINIENTOS", "SEISCIENTOS", "SETECIENTOS", "OCHOCIENTOS", "NOVECIENTOS",
+}
+
+// Convertir retorna un número entero en letras ("MIL CIENTO OCHENTA")
+func Convertir(n int64) (string, error) {
+	if n < 0 || n > Maximo {
+		return "", fmt.Errorf("número fuera de rango: %d", n)
+	}
+	if n == 0 {
+		return "CERO", nil
+	}
+
+	var partes []string
+	billones := n / 1000000000000
+	millones := n / 1000000 % 1000000
+	resto := n % 1000000
+
+	switch {
+	case billones == 1:
+		partes = append(partes, "UN BILLÓN")
+	case billones > 1:
+		partes = append(partes, miles(billones, true)+" BILLONES")
+	}
+	switch {
+	case millones == 1:
+		partes = append(partes, "UN MILLÓN")
+	case millones > 1:
+		partes = append(partes, miles(millones, true)+" MILLONES")
+	}
+	if resto > 0 {
+		partes = append(partes, miles(resto, false))
+	}
+	return strings.Join(partes, " "), nil
+}
+
+// Leyenda retorna un importe en letras con sus céntimos y el nombre de la
+// moneda, por ejemplo "CIENTO DIECIOCHO CON 00/100 SOLES"
+func Leyenda(importe float64, moneda string) (string, error) {
+	if importe < 0 {
+		return "", fmt.Errorf("importe negativo: %.2f", importe)
+	}
+	centimos := int64(math.Round(importe * 100))
+	letras, err := Convertir(centimos / 100)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s CON %02d/100 %s", letras, centimos%100, moneda), nil
+}
+
+// miles convierte un número menor a un millón; apocope indica que el número
+// precede a un sustantivo ("VEINTIÚN MILLONES", "UN MIL" no se usa)
+func miles(n int64, apocope bool) string {
+	m, r := n/1000, n%1000
+	var partes []string
+	switch {
+	case m == 1:
+		partes = append(partes, "MIL")
+	case m > 1:
+		partes = append(partes, cientos(m, true)+" MIL")
+	}
+	if r > 0 {
+		partes = append(partes, cientos(r, apocope))
+	}
+	return strings.Join(partes, " ")
+}
+
+// cientos convierte un número entre 1 y 999
+func cientos(n int64, apocope bool) string {
+	if n == 100 {
+		return "CIEN"
+	}
+
+	var partes []string
+	if c := n / 100; c > 0 {
+		partes = append(partes, centenas[c])
+	}
+
+	d := n % 100
+	switch {
+	case d == 0:
+	case d < 30:
+		partes = append(partes, unidades[d])
+	default:
+		texto := decenas[d/10]
+		if u := d % 10; u > 0 {
+			texto += " Y " + unidades[u]
+		}
+		partes = append(partes, texto)
+	}
+
+	texto := strings.Join(partes, " ")
+	if apocope {
+		switch {
+		case strings.HasSuffix(texto, "VEINTIUNO"):
+			texto = strings.TrimSuffix(texto, "VEINTIUNO") + "VEINTIÚN"
+		case strings.HasSuffix(texto, "UNO"):
+			texto = strings.TrimSuffix(texto, "UNO") + "UN"
+		}
+	}
+	return texto
+}
@@ -0,0 +1,92 @@
+package numletras
+
+import "testing"
+
+func TestConvertir(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "CERO"},
+		{1, "UNO"},
+		{15, "QUINCE"},
+		{21, "VEINTIUNO"},
+		{31, "TREINTA Y UNO"},
+		{100, "CIEN"},
+		{101, "CIENTO UNO"},
+		{118, "CIENTO DIECIOCHO"},
+		{500, "QUINIENTOS"},
+		{1000, "MIL"},
+		{1001, "MIL UNO"},
+		{1180, "MIL CIENTO OCHENTA"},
+		{21000, "VEINTIÚN MIL"},
+		{31000, "TREINTA Y UN MIL"},
+		{100000, "CIEN MIL"},
+		{101000, "CIENTO UN MIL"},
+		{1000000, "UN MILLÓN"},
+		{1000001, "UN MILLÓN UNO"},
+		{2000000, "DOS MILLONES"},
+		{21000000, "VEINTIÚN MILLONES"},
+		{100000000, "CIEN MILLONES"},
+		{1001000000, "MIL UN MILLONES"},
+		{2000000000, "DOS MIL MILLONES"},
+		{1000000000000, "UN BILLÓN"},
+		{1000001000000, "UN BILLÓN UN MILLÓN"},
+		{21000000000000, "VEINTIÚN BILLONES"},
+		{Maximo, "NOVECIENTOS NOVENTA Y NUEVE BILLONES " +
+			"NOVECIENTOS NOVENTA Y NUEVE MIL NOVECIENTOS NOVENTA Y NUEVE MILLONES " +
+			"NOVECIENTOS NOVENTA Y NUEVE MIL NOVECIENTOS NOVENTA Y NUEVE"},
+	}
+	for _, tt := range tests {
+		got, err := Convertir(tt.n)
+		if err != nil {
+			t.Errorf("Convertir(%d): %v", tt.n, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Convertir(%d) = %q, se esperaba %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestConvertirFueraDeRango(t *testing.T) {
+	for _, n := range []int64{-1, Maximo + 1} {
+		if _, err := Convertir(n); err == nil {
+			t.Errorf("Convertir(%d) no retornó error", n)
+		}
+	}
+}
+
+func TestLeyenda(t *testing.T) {
+	tests := []struct {
+		importe float64
+		moneda  string
+		want    string
+	}{
+		{0, "SOLES", "CERO CON 00/100 SOLES"},
+		{0.005, "SOLES", "CERO CON 01/100 SOLES"},
+		{0.994, "SOLES", "CERO CON 99/100 SOLES"},
+		{1, "DÓLARES AMERICANOS", "UNO CON 00/100 DÓLARES AMERICANOS"},
+		{118, "SOLES", "CIENTO DIECIOCHO CON 00/100 SOLES"},
+		{118.5, "SOLES", "CIENTO DIECIOCHO CON 50/100 SOLES"},
+		{118.999, "SOLES", "CIENTO DIECINUEVE CON 00/100 SOLES"},
+		{1180.07, "SOLES", "MIL CIENTO OCHENTA CON 07/100 SOLES"},
+		{21000.1, "SOLES", "VEINTIÚN MIL CON 10/100 SOLES"},
+	}
+	for _, tt := range tests {
+		got, err := Leyenda(tt.importe, tt.moneda)
+		if err != nil {
+			t.Errorf("Leyenda(%v): %v", tt.importe, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Leyenda(%v) = %q, se esperaba %q", tt.importe, got, tt.want)
+		}
+	}
+}
+
+func TestLeyendaNegativa(t *testing.T) {
+	if _, err := Leyenda(-0.01, "SOLES"); err == nil {
+		t.Error("Leyenda de un importe negativo no retornó error")
+	}
+}
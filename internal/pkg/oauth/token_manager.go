@@ -0,0 +1,272 @@
+// Package oauth gestiona los tokens OAuth2 de las APIs REST de SUNAT
+// (guías de remisión, consulta de validez de comprobantes).
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"ubl-converter/internal/pkg/httpclient"
+)
+
+// Credentials credenciales de un emisor generadas en el menú SOL.
+// Si Username está vacío se usa el flujo client_credentials; de lo contrario
+// el flujo password con el usuario SOL (RUC seguido del usuario).
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+}
+
+// CredentialsFunc retorna las credenciales del emisor con el RUC indicado
+type CredentialsFunc func(ruc string) (Credentials, error)
+
+// TokenError error devuelto por el servicio de seguridad de SUNAT
+type TokenError struct {
+	StatusCode  int
+	Code        string
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	return fmt.Sprintf("error obteniendo token (HTTP %d): %s - %s", e.StatusCode, e.Code, e.Description)
+}
+
+// TokenManager obtiene y guarda en caché un token por RUC. Los tokens se
+// renuevan antes de expirar y las solicitudes concurrentes de un mismo RUC
+// esperan a una sola llamada al servicio de seguridad.
+type TokenManager struct {
+	TokenURL      string // URL base, p. ej. https://api-seguridad.sunat.gob.pe/v1/clientessol
+	Scope         string
+	Credentials   CredentialsFunc
+	HTTPClient    *http.Client
+	RefreshMargin time.Duration // anticipación con la que se renueva un token
+
+	mu     sync.Mutex // protege tokens y sus entradas
+	tokens map[string]*cachedToken
+	now    func() time.Time
+}
+
+type cachedToken struct {
+	value  string
+	expiry time.Time
+
+	// cargando no es nil mientras se solicita un token para el RUC; se cierra
+	// al terminar la solicitud
+	cargando chan struct{}
+}
+
+// NewTokenManager crea un gestor de tokens para el servicio de seguridad y
+// el alcance indicados
+func NewTokenManager(tokenURL, scope string, credentials CredentialsFunc) *TokenManager {
+	return &TokenManager{
+		TokenURL:      tokenURL,
+		Scope:         scope,
+		Credentials:   credentials,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+		RefreshMargin: time.Minute,
+		tokens:        make(map[string]*cachedToken),
+		now:           time.Now,
+	}
+}
+
+// Token retorna un token vigente para el RUC, solicitando uno nuevo si no hay
+// uno en caché o está por expirar. La solicitud del token usa ctx y cuenta
+// para el límite de frecuencia del RUC. Si otra llamada ya está solicitando
+// el token se espera su resultado mientras ctx siga vigente.
+func (m *TokenManager) Token(ctx context.Context, ruc string) (string, error) {
+	for {
+		m.mu.Lock()
+		entry := m.entry(ruc)
+		if entry.value != "" && m.now().Add(m.RefreshMargin).Before(entry.expiry) {
+			value := entry.value
+			m.mu.Unlock()
+			return value, nil
+		}
+		if cargando := entry.cargando; cargando != nil {
+			m.mu.Unlock()
+			// Si la otra solicitud falla se reintenta con el contexto propio
+			select {
+			case <-cargando:
+				continue
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		cargando := make(chan struct{})
+		entry.cargando = cargando
+		m.mu.Unlock()
+
+		value, expiresIn, err := m.fetch(ctx, ruc)
+
+		m.mu.Lock()
+		entry.cargando = nil
+		if err == nil {
+			entry.value = value
+			entry.expiry = m.now().Add(expiresIn)
+		}
+		m.mu.Unlock()
+		close(cargando)
+
+		if err != nil {
+			return "", err
+		}
+		return value, nil
+	}
+}
+
+// Invalidate descarta el token en caché del RUC si todavía es el indicado
+// (p. ej. el que recibió un HTTP 401). Un token obtenido después por otra
+// solicitud se conserva.
+func (m *TokenManager) Invalidate(ruc, token string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if entry := m.entry(ruc); entry.value == token {
+		entry.value = ""
+	}
+}
+
+// entry retorna la entrada en caché del RUC; debe llamarse con m.mu tomado
+func (m *TokenManager) entry(ruc string) *cachedToken {
+	if m.tokens == nil {
+		m.tokens = make(map[string]*cachedToken)
+	}
+	entry, ok := m.tokens[ruc]
+	if !ok {
+		entry = &cachedToken{}
+		m.tokens[ruc] = entry
+	}
+	return entry
+}
+
+// fetch solicita un token al servicio de seguridad
+func (m *TokenManager) fetch(ctx context.Context, ruc string) (string, time.Duration, error) {
+	if m.Credentials == nil {
+		return "", 0, fmt.Errorf("no hay credenciales configuradas")
+	}
+	creds, err := m.Credentials(ruc)
+	if err != nil {
+		return "", 0, fmt.Errorf("credenciales del RUC %s: %v", ruc, err)
+	}
+	if creds.ClientID == "" || creds.ClientSecret == "" {
+		return "", 0, fmt.Errorf("el RUC %s no tiene client_id y client_secret configurados", ruc)
+	}
+
+	form := url.Values{
+		"scope":         {m.Scope},
+		"client_id":     {creds.ClientID},
+		"client_secret": {creds.ClientSecret},
+	}
+	if creds.Username != "" {
+		form.Set("grant_type", "password")
+		form.Set("username", creds.Username)
+		form.Set("password", creds.Password)
+	} else {
+		form.Set("grant_type", "client_credentials")
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/oauth2/token/", strings.TrimRight(m.TokenURL, "/"), url.PathEscape(creds.ClientID))
+	req, err := http.NewRequestWithContext(httpclient.WithRUC(ctx, ruc), http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("error creando solicitud de token: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := m.HTTPClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("error solicitando token: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("error leyendo token: %v", err)
+	}
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil && resp.StatusCode == http.StatusOK {
+		return "", 0, fmt.Errorf("error decodificando token: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{StatusCode: resp.StatusCode, Code: body.Error, Description: body.ErrorDescription}
+		if tokenErr.Code == "" && tokenErr.Description == "" {
+			tokenErr.Description = strings.TrimSpace(string(data))
+		}
+		return "", 0, tokenErr
+	}
+	if body.AccessToken == "" {
+		return "", 0, fmt.Errorf("error obteniendo token: respuesta sin access_token")
+	}
+
+	return body.AccessToken, time.Duration(body.ExpiresIn) * time.Second, nil
+}
+
+// Transport retorna un http.RoundTripper que autentica las solicitudes con el
+// token del RUC. Si SUNAT rechaza el token se renueva y se reintenta una vez.
+func (m *TokenManager) Transport(ruc string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{manager: m, ruc: ruc, base: base}
+}
+
+type transport struct {
+	manager *TokenManager
+	ruc     string
+	base    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, token, err := t.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// Solo se reintenta si el cuerpo puede volver a leerse
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+	t.manager.Invalidate(t.ruc, token)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	resp, _, err = t.send(retry)
+	return resp, err
+}
+
+// send envía la solicitud con el token vigente del RUC y retorna el token usado
+func (t *transport) send(req *http.Request) (*http.Response, string, error) {
+	token, err := t.manager.Token(req.Context(), t.ruc)
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, "", err
+	}
+
+	// El RoundTripper no debe modificar la solicitud original
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "Bearer "+token)
+	resp, err := t.base.RoundTrip(authReq)
+	return resp, token, err
+}
@@ -0,0 +1,274 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// servidorPrueba emite tokens numerados y solo acepta en la API el último
+// emitido, para simular que SUNAT revocó los anteriores
+type servidorPrueba struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	tokens  int
+	rucs    []string
+	llamada []string // Authorization y cuerpo de cada llamada a la API
+}
+
+func nuevoServidorPrueba(t *testing.T) *servidorPrueba {
+	t.Helper()
+	s := &servidorPrueba{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/seguridad/cid/oauth2/token/", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		s.mu.Lock()
+		s.tokens++
+		s.rucs = append(s.rucs, strings.TrimSuffix(r.PostForm.Get("username"), "MODDATOS"))
+		n := s.tokens
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"access_token":"t%d","expires_in":3600}`, n)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		s.mu.Lock()
+		vigente := fmt.Sprintf("Bearer t%d", s.tokens)
+		s.llamada = append(s.llamada, r.Header.Get("Authorization")+" "+string(body))
+		s.mu.Unlock()
+		if r.Header.Get("Authorization") != vigente {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+// revocar emite un token nuevo para que el token en caché deje de valer
+func (s *servidorPrueba) revocar() {
+	s.mu.Lock()
+	s.tokens++
+	s.mu.Unlock()
+}
+
+func (s *servidorPrueba) llamadas() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.llamada...)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func nuevoManagerPrueba(s *servidorPrueba) *TokenManager {
+	return NewTokenManager(s.URL+"/seguridad", "scope", func(ruc string) (Credentials, error) {
+		return Credentials{ClientID: "cid", ClientSecret: "csec", Username: ruc + "MODDATOS", Password: "clave"}, nil
+	})
+}
+
+func TestTokenEnCachePorRUC(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	ctx := context.Background()
+
+	a1, err := m.Token(ctx, "20123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, _ := m.Token(ctx, "20123456789")
+	b, _ := m.Token(ctx, "20987654321")
+	if a1 != a2 {
+		t.Fatalf("el token del mismo RUC cambió: %s, %s", a1, a2)
+	}
+	if a1 == b {
+		t.Fatalf("dos RUC comparten el token %s", a1)
+	}
+	if got := strings.Join(s.rucs, ","); got != "20123456789,20987654321" {
+		t.Fatalf("tokens solicitados para %s", got)
+	}
+}
+
+func TestTokenRenuevaAntesDeExpirar(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	ahora := time.Now()
+	m.now = func() time.Time { return ahora }
+
+	if _, err := m.Token(context.Background(), "20123456789"); err != nil {
+		t.Fatal(err)
+	}
+	ahora = ahora.Add(time.Hour - m.RefreshMargin + time.Second)
+	token, err := m.Token(context.Background(), "20123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "t2" {
+		t.Fatalf("token = %s, se esperaba uno renovado", token)
+	}
+}
+
+func TestTransportReintentaUnaVezTras401(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	client := &http.Client{Transport: m.Transport("20123456789", nil)}
+
+	if _, err := m.Token(context.Background(), "20123456789"); err != nil {
+		t.Fatal(err)
+	}
+	s.revocar()
+
+	// http.NewRequest define GetBody para strings.Reader
+	req, _ := http.NewRequest(http.MethodPost, s.URL+"/api", strings.NewReader("cuerpo"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200 tras renovar el token", resp.StatusCode)
+	}
+
+	llamadas := s.llamadas()
+	want := []string{"Bearer t1 cuerpo", "Bearer t3 cuerpo"}
+	if strings.Join(llamadas, "|") != strings.Join(want, "|") {
+		t.Fatalf("llamadas = %q, se esperaba %q", llamadas, want)
+	}
+}
+
+func TestTransportNoReintentaDosVeces(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	// Cada token se revoca antes de usarlo, así que la API rechaza todos: el
+	// transporte no debe quedar en un ciclo
+	revocaAntes := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		s.revocar()
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := &http.Client{Transport: m.Transport("20123456789", revocaAntes)}
+
+	resp, err := client.Get(s.URL + "/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, se esperaba 401", resp.StatusCode)
+	}
+	if n := len(s.llamadas()); n != 2 {
+		t.Fatalf("se hicieron %d llamadas, se esperaban 2", n)
+	}
+}
+
+func TestTransportSinGetBodyNoReintenta(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	client := &http.Client{Transport: m.Transport("20123456789", nil)}
+
+	if _, err := m.Token(context.Background(), "20123456789"); err != nil {
+		t.Fatal(err)
+	}
+	s.revocar()
+
+	req, _ := http.NewRequest(http.MethodPost, s.URL+"/api", io.NopCloser(strings.NewReader("cuerpo")))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status = %d, se esperaba el 401 original", resp.StatusCode)
+	}
+	if n := len(s.llamadas()); n != 1 {
+		t.Fatalf("se hicieron %d llamadas, se esperaba 1", n)
+	}
+}
+
+func TestTokenRespetaContexto(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.Token(ctx, "20123456789"); err == nil {
+		t.Fatal("se obtuvo un token con el contexto cancelado")
+	}
+	if s.tokens != 0 {
+		t.Fatalf("se solicitaron %d tokens con el contexto cancelado", s.tokens)
+	}
+}
+
+func TestTokenEnEsperaRespetaContexto(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	solicitado := make(chan struct{})
+	liberar := make(chan struct{})
+	m.HTTPClient = &http.Client{Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		close(solicitado)
+		<-liberar
+		return http.DefaultTransport.RoundTrip(req)
+	})}
+
+	primero := make(chan string)
+	go func() {
+		token, _ := m.Token(context.Background(), "20123456789")
+		primero <- token
+	}()
+	<-solicitado
+
+	// La segunda llamada espera a la primera, pero no más allá de su contexto
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := m.Token(ctx, "20123456789"); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, se esperaba %v", err, context.DeadlineExceeded)
+	}
+
+	close(liberar)
+	if token := <-primero; token != "t1" {
+		t.Fatalf("token = %s, se esperaba t1", token)
+	}
+	token, err := m.Token(context.Background(), "20123456789")
+	if err != nil || token != "t1" {
+		t.Fatalf("token = %s, err = %v, se esperaba t1 en caché", token, err)
+	}
+}
+
+func TestInvalidateConservaTokenNuevo(t *testing.T) {
+	s := nuevoServidorPrueba(t)
+	m := nuevoManagerPrueba(s)
+	ctx := context.Background()
+
+	viejo, err := m.Token(ctx, "20123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Invalidate("20123456789", viejo)
+	nuevo, err := m.Token(ctx, "20123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Un 401 tardío con el token anterior no descarta el renovado
+	m.Invalidate("20123456789", viejo)
+	token, err := m.Token(ctx, "20123456789")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != nuevo {
+		t.Fatalf("token = %s, se esperaba conservar %s", token, nuevo)
+	}
+	if s.tokens != 2 {
+		t.Fatalf("se solicitaron %d tokens, se esperaban 2", s.tokens)
+	}
+}
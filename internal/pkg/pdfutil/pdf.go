@@ -2,10 +2,16 @@ package pdfutil
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/tracing"
 
 	"github.com/jung-kurt/gofpdf"
 	"github.com/skip2/go-qrcode"
@@ -23,6 +29,79 @@ type InvoiceLine struct {
 	Quantity    string `xml:"InvoicedQuantity"`
 	Price       string `xml:"Price>PriceAmount"`
 	LineTotal   string `xml:"LineExtensionAmount"`
+
+	// Código del producto y código de producto SUNAT (catálogo 25)
+	Code          string `xml:"Item>SellersItemIdentification>ID"`
+	SunatCode     string `xml:"Item>CommodityClassification>ItemClassificationCode"`
+	Properties    []ItemProperty `xml:"Item>AdditionalItemProperty"`
+}
+
+// ItemProperty representa una propiedad adicional del ítem (catálogo 55)
+type ItemProperty struct {
+	Name  string `xml:"Name"`
+	Value string `xml:"Value"`
+}
+
+// PaymentTerm representa un elemento cac:PaymentTerms (forma de pago, cuota o detracción)
+type PaymentTerm struct {
+	ID             string `xml:"ID"`
+	PaymentMeansID string `xml:"PaymentMeansID"`
+	PaymentPercent string `xml:"PaymentPercent"`
+	Amount         string `xml:"Amount"`
+	PaymentDueDate string `xml:"PaymentDueDate"`
+}
+
+// PaymentMeans representa un elemento cac:PaymentMeans (cuenta de detracción)
+type PaymentMeans struct {
+	ID      string `xml:"ID"`
+	Code    string `xml:"PaymentMeansCode"`
+	Account string `xml:"PayeeFinancialAccount>ID"`
+}
+
+// AllowanceCharge representa un elemento cac:AllowanceCharge (catálogo 53)
+type AllowanceCharge struct {
+	ChargeIndicator string `xml:"ChargeIndicator"`
+	ReasonCode      string `xml:"AllowanceChargeReasonCode"`
+	Factor          string `xml:"MultiplierFactorNumeric"`
+	Amount          string `xml:"Amount"`
+	BaseAmount      string `xml:"BaseAmount"`
+}
+
+// Note representa una leyenda <cbc:Note languageLocaleID="...">
+type Note struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"languageLocaleID,attr"`
+}
+
+// PartyInfo contiene el documento, nombre, dirección y correo de un emisor o receptor
+type PartyInfo struct {
+	Document struct {
+		Value    string `xml:",chardata"`
+		SchemeID string `xml:"schemeID,attr"`
+	} `xml:"Party>PartyIdentification>ID"`
+	Name    string `xml:"Party>PartyLegalEntity>RegistrationName"`
+	Address struct {
+		Line             string `xml:"AddressLine>Line"`
+		District         string `xml:"District"`
+		CityName         string `xml:"CityName"`
+		CountrySubentity string `xml:"CountrySubentity"`
+		Country          string `xml:"Country>IdentificationCode"`
+	} `xml:"Party>PartyLegalEntity>RegistrationAddress"`
+	Email string `xml:"Party>Contact>ElectronicMail"`
+}
+
+// FullAddress retorna la dirección con distrito, provincia y departamento
+func (p PartyInfo) FullAddress() string {
+	partes := []string{}
+	for _, parte := range []string{p.Address.Line, p.Address.District, p.Address.CityName, p.Address.CountrySubentity} {
+		if parte != "" {
+			partes = append(partes, parte)
+		}
+	}
+	if p.Address.Country != "" && p.Address.Country != "PE" {
+		partes = append(partes, p.Address.Country)
+	}
+	return strings.Join(partes, " - ")
 }
 
 type BasicInvoiceFields struct {
@@ -37,12 +116,29 @@ type BasicInvoiceFields struct {
 	// Fecha de emisión: <cbc:IssueDate>2025-07-18</cbc:IssueDate>
 	IssueDate string `xml:"IssueDate"`
 
+	// Leyendas: <cbc:Note languageLocaleID="2006">
+	Notes []Note `xml:"Note"`
+
+	// Moneda: <cbc:DocumentCurrencyCode>PEN</cbc:DocumentCurrencyCode>
+	DocumentCurrencyCode string `xml:"DocumentCurrencyCode"`
+
+	// Tipo de cambio: <cac:PaymentExchangeRate><cbc:CalculationRate>
+	PaymentExchangeRate struct {
+		CalculationRate string `xml:"CalculationRate"`
+	} `xml:"PaymentExchangeRate"`
+
 	// Totales dentro de <cac:LegalMonetaryTotal>
     LegalMonetaryTotal struct {
-        LineExtensionAmount string `xml:"LineExtensionAmount"`
+        LineExtensionAmount  string `xml:"LineExtensionAmount"`
+        AllowanceTotalAmount string `xml:"AllowanceTotalAmount"`
+        ChargeTotalAmount    string `xml:"ChargeTotalAmount"`
+        PrepaidAmount        string `xml:"PrepaidAmount"`
         PayableAmount       string `xml:"PayableAmount"`
     } `xml:"LegalMonetaryTotal"`
 
+	// Cargos, descuentos, anticipos y percepción: <cac:AllowanceCharge>
+	AllowanceCharges []AllowanceCharge `xml:"AllowanceCharge"`
+
 	// IGV total
 	TaxTotal struct {
 		Amount string `xml:"TaxAmount"`
@@ -51,15 +147,28 @@ type BasicInvoiceFields struct {
 	// RUC Emisor: <cac:AccountingSupplierParty><cbc:CustomerAssignedAccountID>20123456789</cbc:CustomerAssignedAccountID></cac:AccountingSupplierParty>
 	SupplierParty struct {
 		RUC string `xml:"CustomerAssignedAccountID"`
+		PartyInfo
 	} `xml:"AccountingSupplierParty"`
 
+	// Receptor: <cac:AccountingCustomerParty>
+	CustomerParty PartyInfo `xml:"AccountingCustomerParty"`
+
+	// Medios de pago: <cac:PaymentMeans>
+	PaymentMeans []PaymentMeans `xml:"PaymentMeans"`
+
+	// Forma de pago, cuotas y detracción: <cac:PaymentTerms>
+	PaymentTerms []PaymentTerm `xml:"PaymentTerms"`
+
 	InvoiceLines []InvoiceLine `xml:"InvoiceLine"`
 }
 
 // GenerateInvoicePDF genera un PDF con un QR usando los datos del XML.
 // xmlPath: ruta del Invoice UBL
 // pdfPath: ruta destino del PDF a crear.
-func GenerateInvoicePDF(xmlPath, pdfPath string) error {
+func GenerateInvoicePDF(ctx context.Context, xmlPath, pdfPath string) (err error) {
+	_, span := tracing.Start(ctx, "GenerateInvoicePDF", tracing.AttrDocumento.String(strings.TrimSuffix(filepath.Base(xmlPath), ".xml")))
+	defer func() { tracing.End(span, err) }()
+
 	data, err := ioutil.ReadFile(xmlPath)
 	if err != nil {
 		return fmt.Errorf("leer xml: %w", err)
@@ -99,6 +208,12 @@ func GenerateInvoicePDF(xmlPath, pdfPath string) error {
 	pdf.AddPage()
 	pdf.SetTitle(docLabel+" "+invoice.ID, false)
 
+	// Importes con el símbolo de la moneda del comprobante
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	money := func(amount string) string {
+		return tr(FormatAmount(invoice.DocumentCurrencyCode, amount))
+	}
+
     // Registrar imagen
 	opt := gofpdf.ImageOptions{ImageType: "PNG"}
 	pdf.RegisterImageOptionsReader("qr.png", opt, bytes.NewReader(pngBytes))
@@ -109,12 +224,29 @@ func GenerateInvoicePDF(xmlPath, pdfPath string) error {
 	pdf.Ln(12)
 
 	pdf.SetFont("Arial", "", 12)
+	if invoice.SupplierParty.Name != "" {
+		pdf.Cell(40, 8, tr(invoice.SupplierParty.Name))
+		pdf.Ln(8)
+	}
 	pdf.Cell(40, 8, "RUC Emisor: "+invoice.SupplierParty.RUC)
 	pdf.Ln(8)
+	renderPartyDetails(pdf, invoice.SupplierParty.PartyInfo, tr)
+	if invoice.CustomerParty.Name != "" {
+		pdf.Cell(40, 8, tr("Cliente: "+invoice.CustomerParty.Name))
+		pdf.Ln(8)
+		pdf.Cell(40, 8, tr(documentLabel(invoice.CustomerParty.Document.SchemeID)+": "+invoice.CustomerParty.Document.Value))
+		pdf.Ln(8)
+		renderPartyDetails(pdf, invoice.CustomerParty, tr)
+	}
 	pdf.Cell(40, 8, "Fecha Emision: "+invoice.IssueDate)
 	pdf.Ln(8)
-	pdf.Cell(40, 8, "Total: "+invoice.LegalMonetaryTotal.PayableAmount)
-	pdf.Ln(12)
+	pdf.Cell(40, 8, "Total: "+money(invoice.LegalMonetaryTotal.PayableAmount))
+	pdf.Ln(8)
+	if invoice.PaymentExchangeRate.CalculationRate != "" {
+		pdf.Cell(40, 8, "Tipo de cambio: "+invoice.PaymentExchangeRate.CalculationRate)
+		pdf.Ln(8)
+	}
+	pdf.Ln(4)
 
 	// ---- Tabla de Ítems ----
 	pdf.SetFont("Arial", "B", 11)
@@ -127,26 +259,55 @@ func GenerateInvoicePDF(xmlPath, pdfPath string) error {
 
 	pdf.SetFont("Arial", "", 10)
 	for _, line := range invoice.InvoiceLines {
+		description := line.Description
+		if line.Code != "" {
+			description = line.Code + " - " + description
+		}
 		pdf.CellFormat(10, 8, line.ID, "1", 0, "C", false, 0, "")
-		pdf.CellFormat(80, 8, line.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(80, 8, tr(description), "1", 0, "L", false, 0, "")
 		pdf.CellFormat(20, 8, line.Quantity, "1", 0, "C", false, 0, "")
-		pdf.CellFormat(30, 8, line.Price, "1", 0, "R", false, 0, "")
-		pdf.CellFormat(30, 8, line.LineTotal, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, money(line.Price), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, money(line.LineTotal), "1", 0, "R", false, 0, "")
 		pdf.Ln(-1)
+		renderItemDetails(pdf, line, tr)
 	}
 
 	// Totales
 	pdf.SetFont("Arial", "B", 11)
 	pdf.Cell(140, 8, "Op. Gravada")
-	pdf.CellFormat(30, 8, invoice.LegalMonetaryTotal.LineExtensionAmount, "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, money(invoice.LegalMonetaryTotal.LineExtensionAmount), "1", 0, "R", false, 0, "")
 	pdf.Ln(-1)
 	pdf.Cell(140, 8, "IGV")
-	pdf.CellFormat(30, 8, invoice.TaxTotal.Amount, "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, money(invoice.TaxTotal.Amount), "1", 0, "R", false, 0, "")
 	pdf.Ln(-1)
+	if invoice.LegalMonetaryTotal.AllowanceTotalAmount != "" {
+		pdf.Cell(140, 8, "Descuentos")
+		pdf.CellFormat(30, 8, "-"+money(invoice.LegalMonetaryTotal.AllowanceTotalAmount), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	if invoice.LegalMonetaryTotal.ChargeTotalAmount != "" {
+		pdf.Cell(140, 8, "Otros cargos")
+		pdf.CellFormat(30, 8, money(invoice.LegalMonetaryTotal.ChargeTotalAmount), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	if invoice.LegalMonetaryTotal.PrepaidAmount != "" {
+		pdf.Cell(140, 8, "Anticipos")
+		pdf.CellFormat(30, 8, "-"+money(invoice.LegalMonetaryTotal.PrepaidAmount), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
 	pdf.Cell(140, 8, "Total")
-	pdf.CellFormat(30, 8, invoice.LegalMonetaryTotal.PayableAmount, "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, money(invoice.LegalMonetaryTotal.PayableAmount), "1", 0, "R", false, 0, "")
+	pdf.Ln(-1)
+	renderPercepcion(pdf, invoice.AllowanceCharges, invoice.LegalMonetaryTotal.PayableAmount, money)
 	pdf.Ln(12)
 
+	// Forma de pago y cronograma de cuotas
+	renderPaymentTerms(pdf, invoice.PaymentTerms, money)
+
+	// Detracción y leyendas
+	renderDetraccion(pdf, invoice.PaymentMeans, invoice.PaymentTerms)
+	renderNotes(pdf, invoice.Notes)
+
 	// Poner QR en esquina superior derecha
 	pdf.ImageOptions("qr.png", 160, 10, 40, 40, false, opt, 0, "")
 
@@ -158,6 +319,176 @@ func GenerateInvoicePDF(xmlPath, pdfPath string) error {
 	return nil
 }
 
+// renderPartyDetails imprime la dirección y el correo de un emisor o receptor, si los tiene
+func renderPartyDetails(pdf *gofpdf.Fpdf, party PartyInfo, tr func(string) string) {
+	pdf.SetFont("Arial", "", 10)
+	if address := party.FullAddress(); address != "" {
+		pdf.Cell(40, 6, tr(address))
+		pdf.Ln(6)
+	}
+	if party.Email != "" {
+		pdf.Cell(40, 6, tr("Correo: "+party.Email))
+		pdf.Ln(6)
+	}
+	pdf.SetFont("Arial", "", 12)
+}
+
+// renderItemDetails imprime bajo el ítem su código de producto SUNAT y sus propiedades adicionales
+func renderItemDetails(pdf *gofpdf.Fpdf, line InvoiceLine, tr func(string) string) {
+	if line.SunatCode == "" && len(line.Properties) == 0 {
+		return
+	}
+	pdf.SetFont("Arial", "I", 8)
+	if line.SunatCode != "" {
+		pdf.CellFormat(10, 5, "", "", 0, "", false, 0, "")
+		pdf.CellFormat(160, 5, "Cod. SUNAT: "+line.SunatCode, "", 1, "L", false, 0, "")
+	}
+	for _, p := range line.Properties {
+		pdf.CellFormat(10, 5, "", "", 0, "", false, 0, "")
+		pdf.CellFormat(160, 5, tr(p.Name+": "+p.Value), "", 1, "L", false, 0, "")
+	}
+	pdf.SetFont("Arial", "", 10)
+}
+
+// documentLabel retorna la etiqueta del tipo de documento de identidad (catálogo 06)
+func documentLabel(tipo string) string {
+	if tipo == "" {
+		return "RUC"
+	}
+	if label, ok := catalog.GetDocumentoIdentidad(tipo); ok {
+		return label
+	}
+	return "Documento"
+}
+
+// renderPaymentTerms imprime la forma de pago y, para ventas al crédito, la tabla de cuotas
+func renderPaymentTerms(pdf *gofpdf.Fpdf, terms []PaymentTerm, money func(string) string) {
+	var cuotas []PaymentTerm
+	for _, term := range terms {
+		if term.ID != "FormaPago" {
+			continue
+		}
+		switch term.PaymentMeansID {
+		case "Contado":
+			pdf.SetFont("Arial", "", 11)
+			pdf.Cell(40, 8, "Forma de pago: Contado")
+			pdf.Ln(10)
+		case "Credito":
+			pdf.SetFont("Arial", "", 11)
+			pdf.Cell(40, 8, "Forma de pago: Credito - Monto neto pendiente de pago: "+money(term.Amount))
+			pdf.Ln(10)
+		default:
+			cuotas = append(cuotas, term)
+		}
+	}
+
+	if len(cuotas) == 0 {
+		return
+	}
+
+	pdf.SetFont("Arial", "B", 11)
+	pdf.CellFormat(30, 8, "Cuota", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(40, 8, "Fecha Venc.", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(30, 8, "Monto", "1", 0, "C", false, 0, "")
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 10)
+	for _, cuota := range cuotas {
+		pdf.CellFormat(30, 8, cuota.PaymentMeansID, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(40, 8, cuota.PaymentDueDate, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(30, 8, money(cuota.Amount), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+}
+
+// renderDetraccion imprime los datos de la detracción (código, porcentaje, monto y cuenta)
+func renderDetraccion(pdf *gofpdf.Fpdf, means []PaymentMeans, terms []PaymentTerm) {
+	for _, term := range terms {
+		if term.ID != "Detraccion" {
+			continue
+		}
+		cuenta := ""
+		for _, m := range means {
+			if m.ID == "Detraccion" {
+				cuenta = m.Account
+			}
+		}
+		pdf.SetFont("Arial", "B", 10)
+		pdf.Cell(40, 6, "Detraccion")
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "", 10)
+		pdf.Cell(40, 6, "Bien/Servicio: "+term.PaymentMeansID+" - Porcentaje: "+term.PaymentPercent+"% - Monto: "+FormatAmount(catalog.MonedaSoles, term.Amount))
+		pdf.Ln(6)
+		pdf.Cell(40, 6, "Cta. Banco de la Nacion: "+cuenta)
+		pdf.Ln(8)
+	}
+}
+
+// renderPercepcion imprime la percepción cobrada (catálogo 53, códigos 51 a 53) y el total a cobrar
+func renderPercepcion(pdf *gofpdf.Fpdf, charges []AllowanceCharge, payable string, money func(string) string) {
+	for _, charge := range charges {
+		if charge.ReasonCode != "51" && charge.ReasonCode != "52" && charge.ReasonCode != "53" {
+			continue
+		}
+		monto, _ := strconv.ParseFloat(charge.Amount, 64)
+		total, _ := strconv.ParseFloat(payable, 64)
+
+		pdf.Cell(140, 8, "Percepcion")
+		pdf.CellFormat(30, 8, money(charge.Amount), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+		pdf.Cell(140, 8, "Total a cobrar")
+		pdf.CellFormat(30, 8, money(strconv.FormatFloat(total+monto, 'f', 2, 64)), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+}
+
+// renderNotes imprime las leyendas del comprobante
+func renderNotes(pdf *gofpdf.Fpdf, notes []Note) {
+	if len(notes) == 0 {
+		return
+	}
+	// Las leyendas vienen en UTF-8 (tildes, ñ); las fuentes base usan cp1252
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetFont("Arial", "I", 10)
+	for _, note := range notes {
+		texto := note.Value
+		if note.Code == "1000" {
+			texto = "SON: " + texto
+		}
+		pdf.MultiCell(0, 6, tr(texto), "", "L", false)
+	}
+	pdf.Ln(4)
+}
+
+// FormatAmount formatea un importe con el símbolo (o el código) de la moneda y
+// separador de miles, por ejemplo "S/ 1,180.00"
+func FormatAmount(moneda, amount string) string {
+	value, err := strconv.ParseFloat(amount, 64)
+	if err != nil {
+		return amount
+	}
+	simbolo := moneda
+	if m, ok := catalog.GetMoneda(moneda); ok && m.Simbolo != "" {
+		simbolo = m.Simbolo
+	}
+
+	texto := strconv.FormatFloat(value, 'f', 2, 64)
+	signo := ""
+	if strings.HasPrefix(texto, "-") {
+		signo, texto = "-", texto[1:]
+	}
+	entero, decimales := texto[:len(texto)-3], texto[len(texto)-3:]
+	var b strings.Builder
+	for i, r := range entero {
+		if i > 0 && (len(entero)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimSpace(simbolo + " " + signo + b.String() + decimales)
+}
+
 // BuildPDFPath devuelve ruta destino en carpeta temp con extensión .pdf
 func BuildPDFPath(invoiceID string) string {
 	return filepath.Join("temp", fmt.Sprintf("%s.pdf", invoiceID))
@@ -0,0 +1,98 @@
+// Package ratelimit limita la frecuencia de solicitudes con un token bucket
+// por clave (API key, RUC emisor).
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// inactividad tras la cual se descarta el bucket de una clave
+const inactividad = 10 * time.Minute
+
+// Limiter mantiene un token bucket por clave. Un Limiter nil o con tasa 0 no
+// limita.
+type Limiter struct {
+	tasa   rate.Limit
+	rafaga int
+
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	limpiado time.Time
+	now      func() time.Time
+}
+
+type bucket struct {
+	limiter *rate.Limiter
+	usado   time.Time
+}
+
+// New crea un limitador de porSegundo solicitudes por segundo por clave, con
+// ráfagas de hasta rafaga solicitudes
+func New(porSegundo float64, rafaga int) *Limiter {
+	if rafaga < 1 {
+		rafaga = 1
+	}
+	return &Limiter{
+		tasa:    rate.Limit(porSegundo),
+		rafaga:  rafaga,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow consume un token de la clave. Si no hay tokens retorna false y el
+// tiempo hasta que haya uno disponible.
+func (l *Limiter) Allow(clave string) (bool, time.Duration) {
+	espera, ok := l.Reserve(clave, 0)
+	return ok, espera
+}
+
+// Reserve reserva un token de la clave si estará disponible dentro de
+// maxEspera: retorna true y cuánto esperar antes de usarlo. Si la espera
+// sería mayor no reserva y retorna false y el tiempo hasta que haya uno.
+func (l *Limiter) Reserve(clave string, maxEspera time.Duration) (time.Duration, bool) {
+	if l == nil || l.tasa <= 0 {
+		return 0, true
+	}
+
+	l.mu.Lock()
+	now := l.now()
+	b, ok := l.buckets[clave]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.tasa, l.rafaga)}
+		l.buckets[clave] = b
+	}
+	b.usado = now
+	l.limpiar(now)
+	l.mu.Unlock()
+
+	reserva := b.limiter.ReserveN(now, 1)
+	espera := reserva.DelayFrom(now)
+	if espera > maxEspera {
+		reserva.CancelAt(now)
+		return espera, false
+	}
+	return espera, true
+}
+
+// limpiar descarta los buckets sin uso reciente; se llama con l.mu tomado
+func (l *Limiter) limpiar(now time.Time) {
+	if now.Sub(l.limpiado) < inactividad {
+		return
+	}
+	for clave, b := range l.buckets {
+		if now.Sub(b.usado) > inactividad {
+			delete(l.buckets, clave)
+		}
+	}
+	l.limpiado = now
+}
+
+// RetryAfter retorna el valor en segundos de la cabecera Retry-After
+func RetryAfter(espera time.Duration) int {
+	return int(math.Max(1, math.Ceil(espera.Seconds())))
+}
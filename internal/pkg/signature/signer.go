@@ -2,6 +2,7 @@ package signature
 
 import (
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
@@ -13,6 +14,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/metrics"
+	"ubl-converter/internal/pkg/tracing"
 
 	"github.com/beevik/etree"
 
@@ -42,6 +47,7 @@ func LoadCertificate() (*CertificateInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	metrics.SetCertificado(cert)
 
 	return &CertificateInfo{
 		CertPath:    pemFile,
@@ -111,7 +117,13 @@ func LoadKeyPairFromPEM(pemPath string) (*x509.Certificate, *rsa.PrivateKey, err
 }
 
 // SignXMLAsElement firma el XML y retorna el elemento de firma como string
-func SignXMLAsElement(xmlString string, certInfo *CertificateInfo) (string, error) {
+func SignXMLAsElement(ctx context.Context, xmlString string, certInfo *CertificateInfo) (signed string, err error) {
+	_, span := tracing.Start(ctx, "SignXMLAsElement")
+	defer func(inicio time.Time) {
+		metrics.ObservarFirma(inicio, err)
+		tracing.End(span, err)
+	}(time.Now())
+
 	// Parsear el XML
 	doc := etree.NewDocument()
 	if err := doc.ReadFromString(xmlString); err != nil {
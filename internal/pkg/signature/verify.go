@@ -0,0 +1,133 @@
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beevik/etree"
+)
+
+// VerifyXML verifica la firma ds:Signature de un comprobante firmado con
+// SignXMLAsElement y retorna el certificado del firmante.
+//
+// SignXMLAsElement calcula el DigestValue sobre el documento antes de
+// envolverlo, sin la declaración XML, sin las declaraciones de espacios de
+// nombres de la raíz y sin ext:UBLExtensions; aquí se reconstruye ese mismo
+// documento para compararlo. También se verifica el SignatureValue sobre el
+// SignedInfo con el certificado incluido en KeyInfo y su vigencia.
+func VerifyXML(xmlString string) (*x509.Certificate, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromString(xmlString); err != nil {
+		return nil, fmt.Errorf("error parseando XML: %v", err)
+	}
+
+	sig := doc.FindElement("//Signature")
+	if sig == nil {
+		return nil, fmt.Errorf("el XML no contiene la firma digital")
+	}
+	signedInfo := sig.SelectElement("SignedInfo")
+	signatureValue := sig.SelectElement("SignatureValue")
+	certElement := sig.FindElement("KeyInfo/X509Data/X509Certificate")
+	if signedInfo == nil || signatureValue == nil || certElement == nil {
+		return nil, fmt.Errorf("la firma digital está incompleta")
+	}
+	if method := signedInfo.SelectElement("SignatureMethod"); method == nil ||
+		method.SelectAttrValue("Algorithm", "") != "http://www.w3.org/2000/09/xmldsig#rsa-sha1" {
+		return nil, fmt.Errorf("algoritmo de firma no soportado")
+	}
+
+	certDER, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(certElement.Text()), ""))
+	if err != nil {
+		return nil, fmt.Errorf("certificado no está en base64: %v", err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return nil, fmt.Errorf("error parseando certificado: %v", err)
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, fmt.Errorf("el certificado no está vigente")
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("la clave pública del certificado no es RSA")
+	}
+
+	signed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(signatureValue.Text()), ""))
+	if err != nil {
+		return nil, fmt.Errorf("SignatureValue no está en base64: %v", err)
+	}
+
+	digestValue := signedInfo.FindElement("Reference/DigestValue")
+	if digestValue == nil {
+		return nil, fmt.Errorf("la firma digital no tiene DigestValue")
+	}
+	digest, err := signedContentDigest(doc)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(digestValue.Text()) != base64.StdEncoding.EncodeToString(digest) {
+		return nil, fmt.Errorf("el documento fue modificado después de firmarlo")
+	}
+
+	// Serializar el SignedInfo igual que al firmar
+	var buf bytes.Buffer
+	signedInfoDoc := etree.NewDocument()
+	signedInfoDoc.SetRoot(signedInfo.Copy())
+	if _, err := signedInfoDoc.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("error serializando SignedInfo: %v", err)
+	}
+	signedInfoDigest := sha1.Sum(buf.Bytes())
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA1, signedInfoDigest[:], signed); err != nil {
+		return nil, fmt.Errorf("la firma digital no es válida")
+	}
+
+	return cert, nil
+}
+
+// signedContentDigest calcula el SHA-1 del documento tal como lo firmó
+// SignXMLAsElement: sin la firma y sus extensiones ni las declaraciones de
+// espacios de nombres de la raíz
+func signedContentDigest(doc *etree.Document) ([]byte, error) {
+	signed := doc.Copy()
+	root := signed.Root()
+	signed.Child = []etree.Token{root}
+
+	attrs := root.Attr[:0]
+	for _, attr := range root.Attr {
+		if attr.Space != "xmlns" && attr.Key != "xmlns" {
+			attrs = append(attrs, attr)
+		}
+	}
+	root.Attr = attrs
+
+	// La firma va dentro de ext:UBLExtensions, que se quita junto con la
+	// indentación que la precede
+	enveloped := root.SelectElement("UBLExtensions")
+	if enveloped == nil {
+		enveloped = signed.FindElement("//Signature")
+	}
+	if enveloped == nil || enveloped.Parent() == nil {
+		return nil, fmt.Errorf("no se encontró la firma digital")
+	}
+	parent := enveloped.Parent()
+	index := enveloped.Index()
+	parent.RemoveChildAt(index)
+	if index > 0 {
+		if text, ok := parent.Child[index-1].(*etree.CharData); ok && strings.TrimSpace(text.Data) == "" {
+			parent.RemoveChildAt(index - 1)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := signed.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("error serializando el documento firmado: %v", err)
+	}
+	return calculateSHA1(buf.String()), nil
+}
@@ -0,0 +1,81 @@
+// Package signedurl firma con HMAC-SHA256 los enlaces de descarga de los
+// documentos para que puedan usarse sin credenciales hasta su vencimiento.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Parámetros de la consulta de un enlace firmado
+const (
+	ParamExpira = "expira"
+	ParamFirma  = "firma"
+)
+
+// Errores de verificación
+var (
+	ErrFirmaInvalida = errors.New("enlace de descarga inválido")
+	ErrVencido       = errors.New("el enlace de descarga venció")
+)
+
+// Signer firma y verifica enlaces de descarga
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// MinSecretLen longitud mínima del secreto HMAC
+const MinSecretLen = 32
+
+// New crea un firmador con el secreto indicado; los enlaces vencen ttl
+// después de firmados
+func New(secret []byte, ttl time.Duration) (*Signer, error) {
+	if len(secret) < MinSecretLen {
+		return nil, fmt.Errorf("el secreto de los enlaces de descarga debe tener al menos %d bytes", MinSecretLen)
+	}
+	return &Signer{secret: secret, ttl: ttl, now: time.Now}, nil
+}
+
+// Sign retorna path con los parámetros de vencimiento y firma
+func (s *Signer) Sign(path string) string {
+	expira := strconv.FormatInt(s.now().Add(s.ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set(ParamExpira, expira)
+	query.Set(ParamFirma, s.firma(path, expira))
+	return path + "?" + query.Encode()
+}
+
+// Verify valida la firma y el vencimiento de un enlace
+func (s *Signer) Verify(path string, query url.Values) error {
+	expira := query.Get(ParamExpira)
+	firma, err := base64.RawURLEncoding.DecodeString(query.Get(ParamFirma))
+	if err != nil || expira == "" {
+		return ErrFirmaInvalida
+	}
+	esperada, _ := base64.RawURLEncoding.DecodeString(s.firma(path, expira))
+	if !hmac.Equal(firma, esperada) {
+		return ErrFirmaInvalida
+	}
+	vencimiento, err := strconv.ParseInt(expira, 10, 64)
+	if err != nil {
+		return ErrFirmaInvalida
+	}
+	if s.now().Unix() > vencimiento {
+		return ErrVencido
+	}
+	return nil
+}
+
+func (s *Signer) firma(path, expira string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(path + "\n" + expira))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -2,139 +2,388 @@ package soap
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
+	"encoding/base64"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/tracing"
+)
+
+// Espacios de nombres del sobre SOAP y de los servicios de SUNAT
+const (
+	EnvelopeNamespace = "http://schemas.xmlsoap.org/soap/envelope/"
+	ServiceNamespace  = "http://service.sunat.gob.pe"
+	WSSENamespace     = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
 )
 
-// SOAPClient interface para el cliente SOAP
-type SOAPClient interface {
-	Call(endpoint, soapAction string, request interface{}, response interface{}) error
+// Client cliente SOAP 1.1 con autenticación WS-Security (usuario SOL) para los
+// servicios billService, billConsultService y otros CPE de SUNAT
+type Client struct {
+	Username   string // RUC seguido del usuario SOL
+	Password   string
+	HTTPClient *http.Client
+	Recorder   Recorder // registra los intercambios SOAP; puede ser nil
 }
 
-type soapClient struct {
-	isProd     bool
-	httpClient *http.Client
-	username   string
-	password   string
+// NewClient crea un cliente SOAP; si httpClient es nil se usa uno con un
+// tiempo máximo de espera de 60 segundos
+func NewClient(username, password string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &Client{
+		Username:   username,
+		Password:   password,
+		HTTPClient: httpClient,
+	}
 }
 
-// SOAPFault estructura para decodificar errores SOAP de SUNAT
-type SOAPFault struct {
-	XMLName    xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Fault"`
-	FaultCode  string   `xml:"faultcode"`
-	FaultString string   `xml:"faultstring"`
+// Fault error SOAP devuelto por SUNAT. Code es el código de error de SUNAT
+// tomado del faultcode (soap-env:Client.0111) o del faultstring.
+type Fault struct {
+	StatusCode  int
+	FaultCode   string
+	FaultString string
+	Detail      string
+	Code        string
 }
 
-// NewSOAPClient crea una nueva instancia del cliente SOAP
-func NewSOAPClient(isProd bool) SOAPClient {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+func (f *Fault) Error() string {
+	if f.Code != "" {
+		return fmt.Sprintf("error de SUNAT %s: %s", f.Code, f.FaultString)
 	}
+	return fmt.Sprintf("error de SUNAT: %s - %s", f.FaultCode, f.FaultString)
+}
 
-	client := &http.Client{Transport: transport}
+// StatusResponse respuesta de getStatus y getStatusCdr
+type StatusResponse struct {
+	StatusCode    string `xml:"statusCode"`
+	StatusMessage string `xml:"statusMessage"`
+	Content       string `xml:"content"` // ZIP del CDR en base64, si lo hay
+}
 
-	return &soapClient{
-		isProd:     isProd,
-		httpClient: client,
-		username:   "20123456789MODDATOS", // Credenciales de prueba
-		password:   "moddatos",            // Credenciales de prueba
-	}
+// ComprobanteRef identifica un comprobante en billConsultService
+type ComprobanteRef struct {
+	RUC    string `xml:"rucComprobante"`
+	Tipo   string `xml:"tipoComprobante"`
+	Serie  string `xml:"serieComprobante"`
+	Numero string `xml:"numeroComprobante"`
+}
+
+// documento retorna el identificador RUC-TIPO-SERIE-NUMERO del comprobante
+func (r ComprobanteRef) documento() string {
+	return r.RUC + "-" + r.Tipo + "-" + r.Serie + "-" + r.Numero
 }
 
-// Call realiza una llamada SOAP
-func (c *soapClient) Call(endpoint, soapAction string, request interface{}, response interface{}) error {
-	// Marshal del request a XML
-	reqBody, err := xml.MarshalIndent(request, "", "  ")
+// SendBill envía un comprobante y retorna el ZIP del CDR
+func (c *Client) SendBill(ctx context.Context, endpoint, fileName string, zipContent []byte) ([]byte, error) {
+	request := &struct {
+		XMLName     xml.Name `xml:"ser:sendBill"`
+		FileName    string   `xml:"fileName"`
+		ContentFile string   `xml:"contentFile"`
+	}{
+		FileName:    fileName,
+		ContentFile: base64.StdEncoding.EncodeToString(zipContent),
+	}
+	response := &struct {
+		ApplicationResponse string `xml:"applicationResponse"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:sendBill", strings.TrimSuffix(fileName, ".zip"), request, response); err != nil {
+		return nil, err
+	}
+	cdrZip, err := base64.StdEncoding.DecodeString(strings.TrimSpace(response.ApplicationResponse))
 	if err != nil {
-		return fmt.Errorf("error marshal request: %v", err)
-	}
-
-	// Limpiar el body del request
-	cleanBody := strings.Replace(string(reqBody), `<?xml version="1.0" encoding="UTF-8"?>`, "", 1)
-	cleanBody = strings.TrimSpace(cleanBody)
-
-	// Construir envelope SOAP
-	soapEnvelope := fmt.Sprintf(`<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/" xmlns:ser="http://service.sunat.gob.pe">
-   <soapenv:Header>
-      <wsse:Security xmlns:wsse="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd">
-         <wsse:UsernameToken>
-            <wsse:Username>%s</wsse:Username>
-            <wsse:Password>%s</wsse:Password>
-         </wsse:UsernameToken>
-      </wsse:Security>
-   </soapenv:Header>
-   <soapenv:Body>
-      %s
-   </soapenv:Body>
-</soapenv:Envelope>`, c.username, c.password, cleanBody)
-
-	// Crear request HTTP
-	req, err := http.NewRequest("POST", endpoint, bytes.NewReader([]byte(soapEnvelope)))
+		return nil, fmt.Errorf("error decodificando CDR: %v", err)
+	}
+	return cdrZip, nil
+}
+
+// SendSummary envía un resumen diario o una comunicación de baja y retorna el ticket
+func (c *Client) SendSummary(ctx context.Context, endpoint, fileName string, zipContent []byte) (string, error) {
+	request := &struct {
+		XMLName     xml.Name `xml:"ser:sendSummary"`
+		FileName    string   `xml:"fileName"`
+		ContentFile string   `xml:"contentFile"`
+	}{
+		FileName:    fileName,
+		ContentFile: base64.StdEncoding.EncodeToString(zipContent),
+	}
+	response := &struct {
+		Ticket string `xml:"ticket"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:sendSummary", strings.TrimSuffix(fileName, ".zip"), request, response); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response.Ticket), nil
+}
+
+// SendPack envía un lote de comprobantes y retorna el ticket
+func (c *Client) SendPack(ctx context.Context, endpoint, fileName string, zipContent []byte) (string, error) {
+	request := &struct {
+		XMLName     xml.Name `xml:"ser:sendPack"`
+		FileName    string   `xml:"fileName"`
+		ContentFile string   `xml:"contentFile"`
+	}{
+		FileName:    fileName,
+		ContentFile: base64.StdEncoding.EncodeToString(zipContent),
+	}
+	response := &struct {
+		Ticket string `xml:"ticket"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:sendPack", strings.TrimSuffix(fileName, ".zip"), request, response); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response.Ticket), nil
+}
+
+// GetStatus consulta el estado de un ticket (billService)
+func (c *Client) GetStatus(ctx context.Context, endpoint, ticket string) (*StatusResponse, error) {
+	request := &struct {
+		XMLName xml.Name `xml:"ser:getStatus"`
+		Ticket  string   `xml:"ticket"`
+	}{
+		Ticket: ticket,
+	}
+	response := &struct {
+		Status StatusResponse `xml:"status"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:getStatus", ticket, request, response); err != nil {
+		return nil, err
+	}
+	return &response.Status, nil
+}
+
+// GetStatusComprobante consulta el estado de un comprobante (billConsultService)
+func (c *Client) GetStatusComprobante(ctx context.Context, endpoint string, ref ComprobanteRef) (*StatusResponse, error) {
+	request := &struct {
+		XMLName xml.Name `xml:"ser:getStatus"`
+		ComprobanteRef
+	}{
+		ComprobanteRef: ref,
+	}
+	response := &struct {
+		Status StatusResponse `xml:"status"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:getStatus", ref.documento(), request, response); err != nil {
+		return nil, err
+	}
+	return &response.Status, nil
+}
+
+// GetStatusCdr obtiene el CDR de un comprobante (billConsultService)
+func (c *Client) GetStatusCdr(ctx context.Context, endpoint string, ref ComprobanteRef) (*StatusResponse, error) {
+	request := &struct {
+		XMLName xml.Name `xml:"ser:getStatusCdr"`
+		ComprobanteRef
+	}{
+		ComprobanteRef: ref,
+	}
+	response := &struct {
+		Status StatusResponse `xml:"statusCdr"`
+	}{}
+
+	if err := c.call(ctx, endpoint, "urn:getStatusCdr", ref.documento(), request, response); err != nil {
+		return nil, err
+	}
+	return &response.Status, nil
+}
+
+// requestEnvelope sobre SOAP con la cabecera WS-Security
+type requestEnvelope struct {
+	XMLName   xml.Name `xml:"soapenv:Envelope"`
+	XmlnsEnv  string   `xml:"xmlns:soapenv,attr"`
+	XmlnsSer  string   `xml:"xmlns:ser,attr"`
+	XmlnsWsse string   `xml:"xmlns:wsse,attr"`
+	Username  string   `xml:"soapenv:Header>wsse:Security>wsse:UsernameToken>wsse:Username"`
+	Password  string   `xml:"soapenv:Header>wsse:Security>wsse:UsernameToken>wsse:Password"`
+	Body      struct {
+		Content interface{}
+	} `xml:"soapenv:Body"`
+}
+
+// Call envía la operación request y decodifica el primer elemento del Body
+// de la respuesta en response. Un soap:Fault se retorna como *SunatError.
+func (c *Client) Call(ctx context.Context, endpoint, soapAction string, request interface{}, response interface{}) error {
+	return c.call(ctx, endpoint, soapAction, "", request, response)
+}
+
+// call envía la operación y registra el intercambio en el Recorder como
+// parte de documento (nombre del archivo, comprobante o ticket)
+func (c *Client) call(ctx context.Context, endpoint, soapAction, documento string, request interface{}, response interface{}) (err error) {
+	env := requestEnvelope{
+		XmlnsEnv:  EnvelopeNamespace,
+		XmlnsSer:  ServiceNamespace,
+		XmlnsWsse: WSSENamespace,
+		Username:  c.Username,
+		Password:  c.Password,
+	}
+	env.Body.Content = request
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(env); err != nil {
+		return fmt.Errorf("error serializando request SOAP: %v", err)
+	}
+
+	exchange := &Exchange{
+		Operacion: strings.TrimPrefix(soapAction, "urn:"),
+		Endpoint:  endpoint,
+		Documento: documento,
+		Inicio:    time.Now(),
+		Request:   buf.Bytes(),
+	}
+	ctx, span := tracing.Start(ctx, "SOAP "+exchange.Operacion,
+		tracing.AttrOperacion.String(exchange.Operacion),
+		tracing.AttrDocumento.String(documento),
+	)
+	defer func() {
+		exchange.Duracion = time.Since(exchange.Inicio)
+		exchange.Err = err
+		observe(exchange)
+		if sunatErr, ok := AsSunatError(err); ok && sunatErr.Codigo != "" {
+			span.SetAttributes(tracing.AttrCodigo.String(sunatErr.Codigo))
+		}
+		tracing.End(span, err)
+		if c.Recorder != nil {
+			c.Recorder.Record(exchange)
+		}
+	}()
+
+	// Si el cliente de la API se desconecta, el envío a SUNAT debe terminar
+	// igual: del contexto solo se toma la traza. El límite de frecuencia se
+	// aplica por RUC, que son los 11 primeros caracteres del usuario SOL.
+	ctx = httpclient.WithRUC(context.WithoutCancel(ctx), c.Username[:min(11, len(c.Username))])
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(exchange.Request))
 	if err != nil {
 		return fmt.Errorf("error creando request: %v", err)
 	}
-
-	// Headers
 	req.Header.Set("Content-Type", "text/xml;charset=UTF-8")
 	req.Header.Set("SOAPAction", soapAction)
 
-	// Ejecutar request
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error ejecutando request: %v", err)
+		return transportError(err)
 	}
 	defer resp.Body.Close()
 
-	// Leer respuesta
+	exchange.StatusCode = resp.StatusCode
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error leyendo respuesta: %v", err)
+		return transportError(err)
 	}
+	exchange.Response = body
 
-	// Log para depuración
-	fmt.Printf("--- SUNAT Raw Response ---\n%s\n--------------------------\n", string(body))
+	return decodeResponse(resp.StatusCode, body, response)
+}
 
-	// Verificar código de respuesta
-	if resp.StatusCode != http.StatusOK {
-		// Intentar decodificar como SOAP Fault
-		var soapFault SOAPFault
-		if err := xml.Unmarshal(body, &soapFault); err == nil {
-			return fmt.Errorf("error de SUNAT: %s - %s", soapFault.FaultCode, soapFault.FaultString)
+// decodeResponse recorre el sobre SOAP respetando los espacios de nombres y
+// decodifica el contenido del Body
+func decodeResponse(statusCode int, body []byte, response interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	inBody := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if statusCode != http.StatusOK {
+				return httpError(statusCode, strings.TrimSpace(string(body)))
+			}
+			return fmt.Errorf("respuesta SOAP inválida: %v", err)
 		}
-		return fmt.Errorf("error de SUNAT: %s", string(body))
-	}
 
-	// Extraer body del envelope
-	bodyStr := string(body)
-	startTag := ""
-	endTag := ""
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !inBody {
+			inBody = start.Name.Space == EnvelopeNamespace && start.Name.Local == "Body"
+			continue
+		}
+
+		if start.Name.Space == EnvelopeNamespace && start.Name.Local == "Fault" {
+			var fault struct {
+				FaultCode   string `xml:"faultcode"`
+				FaultString string `xml:"faultstring"`
+				Detail      struct {
+					Message string `xml:"message"`
+					Text    string `xml:",chardata"`
+				} `xml:"detail"`
+			}
+			if err := decoder.DecodeElement(&fault, &start); err != nil {
+				return fmt.Errorf("error leyendo soap:Fault: %v", err)
+			}
+			detail := fault.Detail.Message
+			if detail == "" {
+				detail = fault.Detail.Text
+			}
+			return faultError(newFault(statusCode, fault.FaultCode, fault.FaultString, detail))
+		}
+
+		if statusCode != http.StatusOK {
+			return httpError(statusCode, "")
+		}
+		if err := decoder.DecodeElement(response, &start); err != nil {
+			return fmt.Errorf("error leyendo respuesta %s: %v", start.Name.Local, err)
+		}
+		return nil
+	}
 
-	if strings.Contains(bodyStr, "<soap:Body>") {
-		startTag = "<soap:Body>"
-		endTag = "</soap:Body>"
-	} else if strings.Contains(bodyStr, "<soapenv:Body>") {
-		startTag = "<soapenv:Body>"
-		endTag = "</soapenv:Body>"
-	} else if strings.Contains(bodyStr, "<env:Body>") {
-		startTag = "<env:Body>"
-		endTag = "</env:Body>"
+	if statusCode != http.StatusOK {
+		return httpError(statusCode, strings.TrimSpace(string(body)))
 	}
+	return fmt.Errorf("respuesta SOAP inválida: no se encontró el body")
+}
 
-	bodyStart := strings.Index(bodyStr, startTag)
-	bodyEnd := strings.Index(bodyStr, endTag)
+// httpError clasifica una respuesta HTTP de error sin soap:Fault
+func httpError(statusCode int, body string) *SunatError {
+	if body == "" {
+		body = http.StatusText(statusCode)
+	}
+	return restError(&RESTError{StatusCode: statusCode, Msg: body})
+}
 
-	if bodyStart < 0 || bodyEnd < 0 {
-		return fmt.Errorf("respuesta SOAP inválida: no se encontró el body")
+// newFault crea el error de un soap:Fault, extrayendo el código de SUNAT
+func newFault(statusCode int, faultCode, faultString, detail string) *Fault {
+	f := &Fault{
+		StatusCode:  statusCode,
+		FaultCode:   strings.TrimSpace(faultCode),
+		FaultString: strings.TrimSpace(faultString),
+		Detail:      strings.TrimSpace(detail),
 	}
 
-	respBody := bodyStr[bodyStart+len(startTag) : bodyEnd]
-	if err := xml.Unmarshal([]byte(respBody), response); err != nil {
-		return fmt.Errorf("error unmarshal response: %v", err)
+	if i := strings.LastIndex(f.FaultCode, "."); i >= 0 && isNumeric(f.FaultCode[i+1:]) {
+		f.Code = f.FaultCode[i+1:]
+	} else if isNumeric(f.FaultString) {
+		// Algunos servicios envían solo el código en el faultstring
+		f.Code = f.FaultString
+		if f.Detail != "" {
+			f.FaultString = f.Detail
+		}
 	}
+	return f
+}
 
-	return nil
-}
\ No newline at end of file
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
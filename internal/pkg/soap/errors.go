@@ -0,0 +1,150 @@
+package soap
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/metrics"
+	"ubl-converter/internal/pkg/oauth"
+)
+
+// SunatError error de SUNAT clasificado según la lista de códigos publicada
+type SunatError struct {
+	Codigo    string // código de SUNAT; vacío si la respuesta no lo incluye
+	Mensaje   string // mensaje del catálogo o, si no está, el devuelto por SUNAT
+	Detalle   string // mensaje devuelto por SUNAT
+	Categoria string // catalog.ErrorAutenticacion, ErrorValidacion, ErrorDuplicado o ErrorTransitorio
+	Err       error  // error original (*Fault, *RESTError)
+}
+
+func (e *SunatError) Error() string {
+	if e.Codigo == "" {
+		return fmt.Sprintf("error de SUNAT (%s): %s", e.Categoria, e.Mensaje)
+	}
+	return fmt.Sprintf("error de SUNAT %s (%s): %s", e.Codigo, e.Categoria, e.Mensaje)
+}
+
+func (e *SunatError) Unwrap() error {
+	return e.Err
+}
+
+// Reintentable indica si el envío puede repetirse más tarde
+func (e *SunatError) Reintentable() bool {
+	return e.Categoria == catalog.ErrorTransitorio
+}
+
+// AsSunatError busca un *SunatError en la cadena de errores
+func AsSunatError(err error) (*SunatError, bool) {
+	var sunatErr *SunatError
+	if errors.As(err, &sunatErr) {
+		return sunatErr, true
+	}
+	return nil, false
+}
+
+// newSunatError clasifica un código de SUNAT; categoria se usa cuando la
+// respuesta no incluye un código
+func newSunatError(codigo, detalle, categoria string, err error) *SunatError {
+	e := &SunatError{
+		Codigo:    codigo,
+		Mensaje:   detalle,
+		Detalle:   detalle,
+		Categoria: categoria,
+		Err:       err,
+	}
+	if codigo != "" {
+		info, found := catalog.GetErrorSUNAT(codigo)
+		e.Categoria = info.Categoria
+		if found {
+			e.Mensaje = info.Mensaje
+		}
+	}
+	if e.Mensaje == "" {
+		e.Mensaje = "error sin descripción"
+	}
+	return e
+}
+
+// faultError clasifica un soap:Fault. Sin código de SUNAT, los faults
+// Client son errores de la solicitud y los Server fallas del servicio.
+func faultError(f *Fault) *SunatError {
+	categoria := catalog.ErrorValidacion
+	if strings.Contains(f.FaultCode, "Server") {
+		categoria = catalog.ErrorTransitorio
+	}
+	return newSunatError(f.Code, f.FaultString, categoria, f)
+}
+
+// restError clasifica un error de las APIs REST por su código o, si no es
+// un código numérico de SUNAT, por el estado HTTP
+func restError(e *RESTError) *SunatError {
+	codigo := ""
+	if isNumeric(e.Cod) {
+		codigo = e.Cod
+	}
+
+	categoria := catalog.ErrorValidacion
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		categoria = catalog.ErrorAutenticacion
+	case e.StatusCode == http.StatusConflict:
+		categoria = catalog.ErrorDuplicado
+	case e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500:
+		categoria = catalog.ErrorTransitorio
+	}
+
+	detalle := e.Msg
+	for _, d := range e.Errors {
+		detalle += "; " + d.Cod + " - " + d.Msg
+	}
+	sunatErr := newSunatError(codigo, strings.TrimPrefix(detalle, "; "), categoria, e)
+	if _, found := catalog.GetErrorSUNAT(codigo); !found {
+		sunatErr.Categoria = categoria
+	}
+	return sunatErr
+}
+
+// transportError clasifica una falla de comunicación con SUNAT. Un token
+// rechazado es un error de credenciales y un envío que excede el tamaño
+// máximo es un error de validación; lo demás, incluido el límite de
+// frecuencia de llamadas, es transitorio.
+func transportError(err error) *SunatError {
+	if errors.Is(err, httpclient.ErrRequestTooLarge) {
+		return newSunatError("", err.Error(), catalog.ErrorValidacion, err)
+	}
+	var rateErr *httpclient.RateLimitError
+	if errors.As(err, &rateErr) {
+		return newSunatError("", rateErr.Error(), catalog.ErrorTransitorio, err)
+	}
+	var tokenErr *oauth.TokenError
+	if errors.As(err, &tokenErr) && tokenErr.StatusCode < 500 {
+		return newSunatError("", tokenErr.Error(), catalog.ErrorAutenticacion, err)
+	}
+	return newSunatError("", "no se pudo comunicar con SUNAT: "+err.Error(), catalog.ErrorTransitorio, err)
+}
+
+// observe publica la duración del intercambio y, si falló, el código y la
+// categoría del error de SUNAT
+func observe(exchange *Exchange) {
+	resultado := metrics.ResultadoOK
+	if exchange.Err != nil {
+		resultado = metrics.ResultadoError
+		var fault *Fault
+		if errors.As(exchange.Err, &fault) {
+			resultado = metrics.ResultadoFault
+		}
+		codigo, categoria := "sin_codigo", catalog.ErrorTransitorio
+		if sunatErr, ok := AsSunatError(exchange.Err); ok {
+			categoria = sunatErr.Categoria
+			if sunatErr.Codigo != "" {
+				codigo = sunatErr.Codigo
+			}
+		}
+		metrics.RegistrarErrorSUNAT(exchange.Operacion, codigo, categoria)
+	}
+	metrics.ObservarSUNAT(exchange.Operacion, resultado, exchange.Duracion)
+}
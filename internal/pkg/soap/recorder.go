@@ -0,0 +1,156 @@
+package soap
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Exchange intercambio SOAP con SUNAT
+type Exchange struct {
+	Operacion  string // sendBill, getStatus, ...
+	Endpoint   string
+	Documento  string // RUC-TIPO-SERIE-NUMERO, nombre del resumen o ticket
+	Inicio     time.Time
+	Duracion   time.Duration
+	StatusCode int
+	Request    []byte // sobre enviado, con la clave SOL
+	Response   []byte
+	Err        error
+}
+
+// Recorder registra los intercambios SOAP. Las implementaciones deben
+// redactar las credenciales antes de escribirlos.
+type Recorder interface {
+	Record(exchange *Exchange)
+}
+
+// Recorders envía cada intercambio a todos los registradores
+type Recorders []Recorder
+
+// Record implementa Recorder
+func (r Recorders) Record(exchange *Exchange) {
+	for _, recorder := range r {
+		recorder.Record(exchange)
+	}
+}
+
+var (
+	passwordPattern = regexp.MustCompile(`(<(?:[\w-]+:)?Password\b[^>]*>)[^<]*(</)`)
+	payloadPattern  = regexp.MustCompile(`(<((?:[\w-]+:)?(?:contentFile|applicationResponse|content))>)([^<]+)(</)`)
+)
+
+// RedactCredentials reemplaza la clave SOL del sobre SOAP
+func RedactCredentials(body []byte) []byte {
+	return passwordPattern.ReplaceAll(body, []byte("${1}***${2}"))
+}
+
+// TruncatePayloads acorta los ZIP en base64 (contentFile, applicationResponse
+// y content) que excedan max caracteres, indicando su tamaño original
+func TruncatePayloads(body []byte, max int) []byte {
+	return payloadPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		parts := payloadPattern.FindSubmatch(match)
+		payload := parts[3]
+		if len(payload) <= max {
+			return match
+		}
+		return []byte(fmt.Sprintf("%s%s...(%d bytes)%s", parts[1], payload[:max], len(payload), parts[4]))
+	})
+}
+
+// LogRecorder registra cada intercambio en el logger: un resumen en nivel
+// info y los sobres, sin credenciales y con los ZIP acortados, en nivel debug
+type LogRecorder struct {
+	Logger     *slog.Logger
+	MaxPayload int // caracteres de base64 que se conservan; 64 por defecto
+}
+
+// Record implementa Recorder
+func (r *LogRecorder) Record(exchange *Exchange) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	maxPayload := r.MaxPayload
+	if maxPayload <= 0 {
+		maxPayload = 64
+	}
+
+	attrs := []any{
+		"operacion", exchange.Operacion,
+		"endpoint", exchange.Endpoint,
+		"documento", exchange.Documento,
+		"status", exchange.StatusCode,
+		"duracion_ms", exchange.Duracion.Milliseconds(),
+	}
+	if exchange.Err != nil {
+		logger.Warn("intercambio SOAP con error", append(attrs, "error", exchange.Err.Error())...)
+	} else {
+		logger.Info("intercambio SOAP", attrs...)
+	}
+
+	logger.Debug("sobres SOAP",
+		"operacion", exchange.Operacion,
+		"documento", exchange.Documento,
+		"request", string(TruncatePayloads(RedactCredentials(exchange.Request), maxPayload)),
+		"response", string(TruncatePayloads(exchange.Response, maxPayload)),
+	)
+}
+
+// ArchiveRecorder guarda los sobres completos de cada intercambio, sin
+// credenciales, en un directorio por documento para auditoría:
+// Dir/<documento>/<fecha>-<operacion>-request.xml y -response.xml
+type ArchiveRecorder struct {
+	Dir    string
+	Logger *slog.Logger // recibe los errores de escritura
+}
+
+// NewArchiveRecorder crea el archivo de intercambios en dir
+func NewArchiveRecorder(dir string) *ArchiveRecorder {
+	return &ArchiveRecorder{Dir: dir}
+}
+
+// Record implementa Recorder
+func (r *ArchiveRecorder) Record(exchange *Exchange) {
+	documento := exchange.Documento
+	if documento == "" {
+		documento = "sin-documento"
+	}
+	// El documento viene de datos de la solicitud: no debe salir del directorio
+	documento = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(documento)
+	dir := filepath.Join(r.Dir, documento)
+
+	prefix := fmt.Sprintf("%s-%s", exchange.Inicio.Format("20060102T150405.000000000"), exchange.Operacion)
+	files := map[string][]byte{
+		prefix + "-request.xml":  RedactCredentials(exchange.Request),
+		prefix + "-response.xml": exchange.Response,
+	}
+	if exchange.Err != nil {
+		files[prefix+"-error.txt"] = []byte(exchange.Err.Error() + "\n")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		r.logError(err)
+		return
+	}
+	for name, content := range files {
+		if content == nil {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0640); err != nil {
+			r.logError(err)
+		}
+	}
+}
+
+func (r *ArchiveRecorder) logError(err error) {
+	logger := r.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger.Error("no se pudo archivar el intercambio SOAP", "error", err.Error())
+}
@@ -0,0 +1,166 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/oauth"
+	"ubl-converter/internal/pkg/tracing"
+)
+
+// GREScope es el alcance del token de la API de comprobantes
+const GREScope = "https://api-cpe.sunat.gob.pe"
+
+// RESTClient cliente de las APIs REST de SUNAT (guía de remisión electrónica).
+// La autenticación se delega al gestor de tokens, que mantiene un token por RUC.
+type RESTClient struct {
+	APIURL     string // URL base de la API de comprobantes
+	Tokens     *oauth.TokenManager
+	HTTPClient *http.Client
+}
+
+// GRETicket respuesta de SUNAT al envío de una guía de remisión
+type GRETicket struct {
+	NumTicket    string `json:"numTicket"`
+	FecRecepcion string `json:"fecRecepcion"`
+}
+
+// GREStatus respuesta de SUNAT a la consulta de un ticket de guía de remisión.
+// CodRespuesta: 0 aceptado, 98 en proceso, 99 con errores.
+type GREStatus struct {
+	CodRespuesta   string    `json:"codRespuesta"`
+	Error          *GREError `json:"error,omitempty"`
+	ArcCdr         string    `json:"arcCdr,omitempty"`
+	IndCdrGenerado string    `json:"indCdrGenerado,omitempty"`
+}
+
+// GREError detalle del error de una guía rechazada
+type GREError struct {
+	NumError string `json:"numError"`
+	DesError string `json:"desError"`
+}
+
+// RESTError error devuelto por las APIs REST de SUNAT
+type RESTError struct {
+	StatusCode int
+	Cod        string `json:"cod"`
+	Msg        string `json:"msg"`
+	Errors     []struct {
+		Cod string `json:"cod"`
+		Msg string `json:"msg"`
+	} `json:"errors"`
+}
+
+func (e *RESTError) Error() string {
+	msg := e.Msg
+	for _, detail := range e.Errors {
+		msg += "; " + detail.Cod + " - " + detail.Msg
+	}
+	return fmt.Sprintf("error de SUNAT (HTTP %d): %s - %s", e.StatusCode, e.Cod, msg)
+}
+
+// NewRESTClient crea un cliente de la API de guías de remisión
+func NewRESTClient(apiURL string, tokens *oauth.TokenManager) *RESTClient {
+	return &RESTClient{
+		APIURL:     apiURL,
+		Tokens:     tokens,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SendGRE envía el ZIP de una guía de remisión del emisor ruc; fileName es el
+// nombre del ZIP (RUC-TIPO-SERIE-NUMERO.zip). Como en los envíos SOAP, si el
+// cliente de la API se desconecta el envío termina igual: de ctx solo se
+// toma la traza.
+func (c *RESTClient) SendGRE(ctx context.Context, ruc, fileName string, zipContent []byte) (*GRETicket, error) {
+	hash := sha256.Sum256(zipContent)
+	body, err := json.Marshal(map[string]interface{}{
+		"archivo": map[string]string{
+			"nomArchivo": fileName,
+			"arcGreZip":  base64.StdEncoding.EncodeToString(zipContent),
+			"hashZip":    fmt.Sprintf("%x", hash[:]),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error serializando envío: %v", err)
+	}
+
+	name := strings.TrimSuffix(fileName, ".zip")
+	endpoint := fmt.Sprintf("%s/comprobantes/%s", strings.TrimRight(c.APIURL, "/"), url.PathEscape(name))
+
+	var ticket GRETicket
+	if err := c.do(context.WithoutCancel(ctx), "SendGRE", ruc, http.MethodPost, endpoint, body, &ticket); err != nil {
+		return nil, fmt.Errorf("error enviando guía: %w", err)
+	}
+	return &ticket, nil
+}
+
+// GREStatus consulta el estado de un ticket de envío de guía de remisión
+func (c *RESTClient) GREStatus(ctx context.Context, ruc, ticket string) (*GREStatus, error) {
+	endpoint := fmt.Sprintf("%s/comprobantes/envios/%s", strings.TrimRight(c.APIURL, "/"), url.PathEscape(ticket))
+
+	var status GREStatus
+	if err := c.do(ctx, "GREStatus", ruc, http.MethodGet, endpoint, nil, &status); err != nil {
+		return nil, fmt.Errorf("error consultando ticket: %w", err)
+	}
+	return &status, nil
+}
+
+// do realiza una llamada autenticada con el token del RUC
+func (c *RESTClient) do(ctx context.Context, operacion, ruc, method, endpoint string, body []byte, result interface{}) (err error) {
+	ctx, span := tracing.Start(ctx, "REST "+operacion, tracing.AttrOperacion.String(operacion))
+	defer func() { tracing.End(span, err) }()
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(httpclient.WithRUC(ctx, ruc), method, endpoint, reader)
+	if err != nil {
+		return fmt.Errorf("error creando request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := *c.HTTPClient
+	client.Transport = c.Tokens.Transport(ruc, c.HTTPClient.Transport)
+	resp, err := client.Do(req)
+	if err != nil {
+		return transportError(err)
+	}
+	defer resp.Body.Close()
+
+	return decodeRESTResponse(resp, result)
+}
+
+// decodeRESTResponse decodifica una respuesta JSON o el error de SUNAT
+func decodeRESTResponse(resp *http.Response, result interface{}) error {
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return transportError(err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		restErr := &RESTError{StatusCode: resp.StatusCode}
+		if err := json.Unmarshal(data, restErr); err != nil || (restErr.Cod == "" && restErr.Msg == "") {
+			restErr.Msg = strings.TrimSpace(string(data))
+		}
+		return restError(restErr)
+	}
+
+	if err := json.Unmarshal(data, result); err != nil {
+		return fmt.Errorf("error decodificando respuesta: %v", err)
+	}
+	return nil
+}
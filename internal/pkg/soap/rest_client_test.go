@@ -0,0 +1,143 @@
+package soap_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"ubl-converter/internal/pkg/cdr"
+	"ubl-converter/internal/pkg/oauth"
+	"ubl-converter/internal/pkg/soap"
+	"ubl-converter/internal/pkg/sunatfake"
+)
+
+const rucPrueba = "20123456789"
+
+func nuevoClienteGRE(t *testing.T) (*sunatfake.GREServer, *soap.RESTClient) {
+	t.Helper()
+	srv := sunatfake.NewGREServer("cid", "csec", rucPrueba+"MODDATOS", "moddatos")
+	t.Cleanup(srv.Close)
+
+	tokens := oauth.NewTokenManager(srv.TokenURL(), soap.GREScope, func(ruc string) (oauth.Credentials, error) {
+		return oauth.Credentials{
+			ClientID:     "cid",
+			ClientSecret: "csec",
+			Username:     ruc + "MODDATOS",
+			Password:     "moddatos",
+		}, nil
+	})
+	return srv, soap.NewRESTClient(srv.APIURL(), tokens)
+}
+
+func zipGuia(t *testing.T, nombre string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create(nombre + ".xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(`<DespatchAdvice/>`))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSendGREYConsultaDeTicket(t *testing.T) {
+	srv, client := nuevoClienteGRE(t)
+	ctx := context.Background()
+
+	nombre := rucPrueba + "-09-T001-1"
+	contenido := zipGuia(t, nombre)
+	ticket, err := client.SendGRE(ctx, rucPrueba, nombre+".zip", contenido)
+	if err != nil {
+		t.Fatalf("SendGRE: %v", err)
+	}
+	if ticket.NumTicket == "" {
+		t.Fatal("SendGRE no retornó ticket")
+	}
+
+	envios := srv.Envios()
+	if len(envios) != 1 || envios[0].NomArchivo != nombre+".zip" || !bytes.Equal(envios[0].Zip, contenido) {
+		t.Fatalf("envío recibido por el servidor = %+v", envios)
+	}
+
+	status, err := client.GREStatus(ctx, rucPrueba, ticket.NumTicket)
+	if err != nil {
+		t.Fatalf("GREStatus: %v", err)
+	}
+	if status.CodRespuesta != "0" || status.IndCdrGenerado != "1" {
+		t.Fatalf("estado = %+v, se esperaba codRespuesta 0 con CDR", status)
+	}
+	zipCDR, err := base64.StdEncoding.DecodeString(status.ArcCdr)
+	if err != nil {
+		t.Fatalf("arcCdr no está en base64: %v", err)
+	}
+	constancia, err := cdr.Parse(zipCDR)
+	if err != nil {
+		t.Fatalf("cdr.Parse: %v", err)
+	}
+	if !constancia.Accepted() || constancia.ReferenceID != "T001-1" {
+		t.Fatalf("CDR = %+v, se esperaba T001-1 aceptada", constancia)
+	}
+
+	// Las tres llamadas usan el mismo token en caché
+	if n := srv.TokenRequests(); n != 1 {
+		t.Fatalf("se solicitaron %d tokens, se esperaba 1", n)
+	}
+}
+
+func TestGREStatusTicketInexistente(t *testing.T) {
+	_, client := nuevoClienteGRE(t)
+
+	status, err := client.GREStatus(context.Background(), rucPrueba, "no-existe")
+	if err != nil {
+		t.Fatalf("GREStatus: %v", err)
+	}
+	if status.CodRespuesta != "99" || status.Error == nil || status.Error.NumError != "2001" {
+		t.Fatalf("estado = %+v, se esperaba codRespuesta 99 con error 2001", status)
+	}
+}
+
+func TestGRERenuevaTokenPorVencer(t *testing.T) {
+	srv, client := nuevoClienteGRE(t)
+	ctx := context.Background()
+
+	if _, err := client.GREStatus(ctx, rucPrueba, "no-existe"); err != nil {
+		t.Fatalf("GREStatus: %v", err)
+	}
+	// El token dura una hora: con un margen mayor se considera por vencer
+	client.Tokens.RefreshMargin = 2 * time.Hour
+	if _, err := client.GREStatus(ctx, rucPrueba, "no-existe"); err != nil {
+		t.Fatalf("GREStatus: %v", err)
+	}
+	if n := srv.TokenRequests(); n != 2 {
+		t.Fatalf("se solicitaron %d tokens, se esperaba 2", n)
+	}
+}
+
+func TestGRERenuevaTokenRevocado(t *testing.T) {
+	srv, client := nuevoClienteGRE(t)
+	ctx := context.Background()
+
+	if _, err := client.GREStatus(ctx, rucPrueba, "no-existe"); err != nil {
+		t.Fatalf("GREStatus: %v", err)
+	}
+	srv.RevocarTokens()
+
+	// El 401 descarta el token en caché y el envío se reintenta con uno nuevo
+	nombre := rucPrueba + "-09-T001-2"
+	if _, err := client.SendGRE(ctx, rucPrueba, nombre+".zip", zipGuia(t, nombre)); err != nil {
+		t.Fatalf("SendGRE tras revocar el token: %v", err)
+	}
+	if n := srv.TokenRequests(); n != 2 {
+		t.Fatalf("se solicitaron %d tokens, se esperaba 2", n)
+	}
+	if envios := srv.Envios(); len(envios) != 1 {
+		t.Fatalf("el servidor recibió %d envíos, se esperaba 1", len(envios))
+	}
+}
@@ -0,0 +1,107 @@
+package soap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"ubl-converter/internal/pkg/httpclient"
+	"ubl-converter/internal/pkg/oauth"
+	"ubl-converter/internal/pkg/tracing"
+)
+
+// ValidezScope es el alcance del token de la consulta integrada de comprobantes
+const ValidezScope = "https://api.sunat.gob.pe/v1/contribuyente/contribuyentes"
+
+// ValidezClient cliente de la consulta integrada de validez de comprobantes
+// de SUNAT, que permite verificar comprobantes emitidos por terceros
+type ValidezClient struct {
+	APIURL     string // URL base, p. ej. https://api.sunat.gob.pe/v1/contribuyente/contribuyentes
+	Tokens     *oauth.TokenManager
+	HTTPClient *http.Client
+}
+
+// ComprobanteConsulta datos del comprobante a validar. FechaEmision tiene el
+// formato dd/mm/aaaa y Monto es el importe total con dos decimales.
+type ComprobanteConsulta struct {
+	NumRuc       string `json:"numRuc"`
+	CodComp      string `json:"codComp"`
+	NumeroSerie  string `json:"numeroSerie"`
+	Numero       string `json:"numero"`
+	FechaEmision string `json:"fechaEmision"`
+	Monto        string `json:"monto,omitempty"`
+}
+
+// ValidezResultado resultado de la consulta de validez
+type ValidezResultado struct {
+	EstadoCp      string   `json:"estadoCp"`
+	EstadoRuc     string   `json:"estadoRuc"`
+	CondDomiRuc   string   `json:"condDomiRuc"`
+	Observaciones []string `json:"observaciones"`
+}
+
+// NewValidezClient crea un cliente de la consulta integrada de comprobantes
+func NewValidezClient(apiURL string, tokens *oauth.TokenManager) *ValidezClient {
+	return &ValidezClient{
+		APIURL:     apiURL,
+		Tokens:     tokens,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// ValidarComprobante consulta la validez de un comprobante; rucConsultante es
+// el RUC de quien consulta y determina las credenciales usadas
+func (c *ValidezClient) ValidarComprobante(ctx context.Context, rucConsultante string, comprobante ComprobanteConsulta) (_ *ValidezResultado, err error) {
+	ctx, span := tracing.Start(ctx, "REST ValidarComprobante", tracing.AttrOperacion.String("ValidarComprobante"))
+	defer func() { tracing.End(span, err) }()
+
+	body, err := json.Marshal(comprobante)
+	if err != nil {
+		return nil, fmt.Errorf("error serializando consulta: %v", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/validarcomprobante", strings.TrimRight(c.APIURL, "/"), url.PathEscape(rucConsultante))
+	req, err := http.NewRequestWithContext(httpclient.WithRUC(ctx, rucConsultante), http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creando request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := *c.HTTPClient
+	client.Transport = c.Tokens.Transport(rucConsultante, c.HTTPClient.Transport)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, transportError(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, transportError(err)
+	}
+
+	// SUNAT informa los errores de negocio con success=false, incluso con HTTP 200
+	var result struct {
+		Success   bool              `json:"success"`
+		Message   string            `json:"message"`
+		ErrorCode string            `json:"errorCode"`
+		Data      *ValidezResultado `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, restError(&RESTError{StatusCode: resp.StatusCode, Msg: strings.TrimSpace(string(data))})
+		}
+		return nil, fmt.Errorf("error decodificando respuesta: %v", err)
+	}
+	if !result.Success || result.Data == nil {
+		return nil, restError(&RESTError{StatusCode: resp.StatusCode, Cod: result.ErrorCode, Msg: strings.TrimSpace(result.Message)})
+	}
+
+	return result.Data, nil
+}
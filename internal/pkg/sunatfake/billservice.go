@@ -0,0 +1,646 @@
+package sunatfake
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/etree"
+
+	"ubl-converter/internal/pkg/catalog"
+	"ubl-converter/internal/pkg/signature"
+)
+
+// BillConfig configuración del simulador de billService
+type BillConfig struct {
+	Usuario         string        // usuario SOL aceptado; el username es el RUC seguido del usuario
+	Clave           string        // clave SOL aceptada
+	Latencia        time.Duration // demora agregada a cada respuesta
+	FallaCodigo     string        // código de excepción inyectado al azar (por ejemplo "0130")
+	FallaTasa       float64       // proporción de solicitudes que fallan con FallaCodigo (0 a 1)
+	TicketEnProceso int           // consultas de un ticket que responden "en proceso" antes del resultado
+
+	// Certificado firma los CDR; si es nil los CDR se devuelven sin firma
+	Certificado *signature.CertificateInfo
+}
+
+// BillServer simula los servicios SOAP billService, billConsultService y
+// otros CPE de SUNAT: valida el usuario SOL, abre el ZIP, verifica la firma
+// y el contenido del comprobante y devuelve CDR firmados. Todas las rutas
+// atienden todas las operaciones, salvo /sim/fallas que permite inyectar
+// excepciones durante las pruebas.
+type BillServer struct {
+	cfg BillConfig
+
+	mu           sync.Mutex
+	comprobantes map[string]*billComprobante // por RUC-TIPO-SERIE-NUMERO, sin ceros a la izquierda
+	tickets      map[string]*billTicket
+	fallas       []string // excepciones forzadas pendientes, en orden
+	rand         *rand.Rand
+	numero       int
+}
+
+type billComprobante struct {
+	codigo string // ResponseCode del CDR
+	cdrZip []byte
+}
+
+type billTicket struct {
+	ruc       string // emisor que envió el resumen
+	consultas int
+	codigo    string // TicketProcesado o TicketConErrores
+	cdrZip    []byte
+}
+
+// billFalla excepción de SUNAT que se responde como soap:Fault
+type billFalla string
+
+// Tipos de comprobante que se envían por sendBill, con el elemento raíz de su XML
+var raizPorTipo = map[string]string{
+	"01": "Invoice",
+	"03": "Invoice",
+	"07": "CreditNote",
+	"08": "DebitNote",
+	"20": "Retention",
+	"40": "Perception",
+}
+
+// Tipos de archivo que se envían por sendSummary, con el elemento raíz de su XML
+var raizPorResumen = map[string]string{
+	"RC": "SummaryDocuments",
+	"RA": "VoidedDocuments",
+	"RR": "VoidedDocuments",
+}
+
+var nombrePorTipo = map[string]string{
+	"01": "La Factura numero",
+	"03": "La Boleta numero",
+	"07": "La Nota de Credito numero",
+	"08": "La Nota de Debito numero",
+	"20": "El Comprobante de Retencion numero",
+	"40": "El Comprobante de Percepcion numero",
+	"RC": "El Resumen diario",
+	"RA": "La Comunicacion de baja",
+	"RR": "La Comunicacion de reversion",
+}
+
+const sunatRUC = "20131312955"
+
+// NewBillServer crea el simulador con la configuración indicada
+func NewBillServer(cfg BillConfig) *BillServer {
+	return &BillServer{
+		cfg:          cfg,
+		comprobantes: make(map[string]*billComprobante),
+		tickets:      make(map[string]*billTicket),
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// InyectarFalla hace que las siguientes veces solicitudes respondan con la
+// excepción codigo, antes de cualquier otra validación
+func (s *BillServer) InyectarFalla(codigo string, veces int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < veces; i++ {
+		s.fallas = append(s.fallas, codigo)
+	}
+}
+
+// siguienteFalla retorna la excepción forzada o aleatoria de la solicitud, si la hay
+func (s *BillServer) siguienteFalla() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.fallas) > 0 {
+		codigo := s.fallas[0]
+		s.fallas = s.fallas[1:]
+		return codigo
+	}
+	if s.cfg.FallaCodigo != "" && s.rand.Float64() < s.cfg.FallaTasa {
+		return s.cfg.FallaCodigo
+	}
+	return ""
+}
+
+// ServeHTTP atiende las operaciones SOAP y la ruta /sim/fallas
+func (s *BillServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/sim/fallas" {
+		s.handleFallas(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "solo se aceptan solicitudes POST", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.cfg.Latencia > 0 {
+		time.Sleep(s.cfg.Latencia)
+	}
+
+	var env struct {
+		Security *struct {
+			Username string `xml:"UsernameToken>Username"`
+			Password string `xml:"UsernameToken>Password"`
+		} `xml:"Header>Security"`
+		Body struct {
+			Inner []byte `xml:",innerxml"`
+		} `xml:"Body"`
+	}
+	if err := xml.NewDecoder(r.Body).Decode(&env); err != nil {
+		writeSOAPFault(w, "", "El mensaje SOAP no es válido: "+err.Error())
+		return
+	}
+
+	// Autenticación WS-Security: RUC de 11 dígitos seguido del usuario SOL
+	if env.Security == nil {
+		writeSOAPFault(w, "0101", "")
+		return
+	}
+	username := strings.TrimSpace(env.Security.Username)
+	if len(username) <= 11 || !soloDigitos(username[:11]) ||
+		username[11:] != s.cfg.Usuario || env.Security.Password != s.cfg.Clave {
+		writeSOAPFault(w, "0102", "")
+		return
+	}
+	ruc := username[:11]
+
+	if codigo := s.siguienteFalla(); codigo != "" {
+		writeSOAPFault(w, codigo, "")
+		return
+	}
+
+	var op struct {
+		XMLName     xml.Name
+		FileName    string `xml:"fileName"`
+		ContentFile string `xml:"contentFile"`
+		Ticket      string `xml:"ticket"`
+		RUC         string `xml:"rucComprobante"`
+		Tipo        string `xml:"tipoComprobante"`
+		Serie       string `xml:"serieComprobante"`
+		Numero      string `xml:"numeroComprobante"`
+	}
+	if err := xml.Unmarshal(env.Body.Inner, &op); err != nil {
+		writeSOAPFault(w, "", "El mensaje SOAP no contiene una operación: "+err.Error())
+		return
+	}
+
+	var (
+		body  string
+		falla billFalla
+	)
+	switch op.XMLName.Local {
+	case "sendBill":
+		body, falla = s.sendBill(ruc, strings.TrimSpace(op.FileName), op.ContentFile)
+	case "sendSummary":
+		body, falla = s.sendSummary(ruc, strings.TrimSpace(op.FileName), op.ContentFile)
+	case "getStatus":
+		if strings.TrimSpace(op.Ticket) != "" {
+			body, falla = s.getStatusTicket(ruc, strings.TrimSpace(op.Ticket))
+		} else {
+			body = s.getStatusComprobante("getStatusResponse", "status", ruc, op.RUC, op.Tipo, op.Serie, op.Numero, false)
+		}
+	case "getStatusCdr":
+		body = s.getStatusComprobante("getStatusCdrResponse", "statusCdr", ruc, op.RUC, op.Tipo, op.Serie, op.Numero, true)
+	default:
+		writeSOAPFault(w, "", "Operación no soportada por el simulador: "+op.XMLName.Local)
+		return
+	}
+	if falla != "" {
+		writeSOAPFault(w, string(falla), "")
+		return
+	}
+	writeSOAP(w, body)
+}
+
+func (s *BillServer) sendBill(ruc, fileName, contentFile string) (string, billFalla) {
+	nombre, partes, xmlData, falla := leerArchivo(ruc, fileName, contentFile)
+	if falla != "" {
+		return "", falla
+	}
+	tipo := partes[1]
+	if _, ok := raizPorResumen[tipo]; ok {
+		return "", "0152"
+	}
+	raiz, ok := raizPorTipo[tipo]
+	if !ok || len(partes[2]) != 4 || !soloDigitos(partes[3]) {
+		return "", "0151"
+	}
+
+	id := partes[2] + "-" + strings.TrimLeft(partes[3], "0")
+	clave := ruc + "-" + tipo + "-" + id
+	s.mu.Lock()
+	_, registrado := s.comprobantes[clave]
+	s.mu.Unlock()
+	if registrado {
+		return "", "1033"
+	}
+
+	codigo, falla := validarComprobante(partes, raiz, xmlData)
+	if falla != "" {
+		return "", falla
+	}
+
+	cdrZip, err := s.buildCDR(ruc, nombre, id, codigo, descripcionCDR(tipo, id, codigo))
+	if err != nil {
+		return "", "0125"
+	}
+
+	s.mu.Lock()
+	if _, registrado := s.comprobantes[clave]; registrado {
+		s.mu.Unlock()
+		return "", "1033"
+	}
+	s.comprobantes[clave] = &billComprobante{codigo: codigo, cdrZip: cdrZip}
+	s.mu.Unlock()
+
+	return fmt.Sprintf(`<br:sendBillResponse xmlns:br="http://service.sunat.gob.pe"><applicationResponse>%s</applicationResponse></br:sendBillResponse>`,
+		base64.StdEncoding.EncodeToString(cdrZip)), ""
+}
+
+func (s *BillServer) sendSummary(ruc, fileName, contentFile string) (string, billFalla) {
+	nombre, partes, xmlData, falla := leerArchivo(ruc, fileName, contentFile)
+	if falla != "" {
+		return "", falla
+	}
+	tipo := partes[1]
+	raiz, ok := raizPorResumen[tipo]
+	if !ok || len(partes[2]) != 8 || !soloDigitos(partes[2]) || !soloDigitos(partes[3]) {
+		return "", "0151"
+	}
+
+	codigo, falla := validarComprobante(partes, raiz, xmlData)
+	if falla != "" {
+		return "", falla
+	}
+
+	id := tipo + "-" + partes[2] + "-" + partes[3]
+	cdrZip, err := s.buildCDR(ruc, nombre, id, codigo, descripcionCDR(tipo, id, codigo))
+	if err != nil {
+		return "", "0125"
+	}
+
+	s.mu.Lock()
+	s.numero++
+	ticket := fmt.Sprintf("%d%06d", time.Now().UnixMilli(), s.numero)
+	estado := "0"
+	if !aceptado(codigo) {
+		estado = "99"
+	}
+	s.tickets[ticket] = &billTicket{ruc: ruc, codigo: estado, cdrZip: cdrZip}
+	s.mu.Unlock()
+
+	return fmt.Sprintf(`<br:sendSummaryResponse xmlns:br="http://service.sunat.gob.pe"><ticket>%s</ticket></br:sendSummaryResponse>`, ticket), ""
+}
+
+// getStatusTicket responde el estado de un ticket; los tickets de otro emisor
+// se informan como inexistentes
+func (s *BillServer) getStatusTicket(ruc, ticket string) (string, billFalla) {
+	s.mu.Lock()
+	t, ok := s.tickets[ticket]
+	ok = ok && t.ruc == ruc
+	if ok {
+		t.consultas++
+	}
+	s.mu.Unlock()
+	if !ok {
+		return "", "0127"
+	}
+
+	if t.consultas <= s.cfg.TicketEnProceso {
+		return statusResponse("getStatusResponse", "status", "98", "", nil), ""
+	}
+	return statusResponse("getStatusResponse", "status", t.codigo, "", t.cdrZip), ""
+}
+
+func (s *BillServer) getStatusComprobante(operacion, elemento, usuarioRUC, ruc, tipo, serie, numero string, conCDR bool) string {
+	ruc, tipo, serie, numero = strings.TrimSpace(ruc), strings.TrimSpace(tipo), strings.TrimSpace(serie), strings.TrimSpace(numero)
+	if ruc != usuarioRUC {
+		return statusResponse(operacion, elemento, "0012", "", nil)
+	}
+	n, err := strconv.Atoi(numero)
+	if err != nil || n <= 0 {
+		return statusResponse(operacion, elemento, "0007", "", nil)
+	}
+
+	s.mu.Lock()
+	encontrado := s.comprobantes[fmt.Sprintf("%s-%s-%s-%d", ruc, tipo, serie, n)]
+	s.mu.Unlock()
+	if encontrado == nil {
+		return statusResponse(operacion, elemento, catalog.ConsultaNoExiste, "", nil)
+	}
+
+	codigo := catalog.ConsultaAceptado
+	if !aceptado(encontrado.codigo) {
+		codigo = catalog.ConsultaRechazado
+	}
+	if !conCDR {
+		return statusResponse(operacion, elemento, codigo, "", nil)
+	}
+	return statusResponse(operacion, elemento, codigo, "", encontrado.cdrZip)
+}
+
+func (s *BillServer) handleFallas(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		var body struct {
+			Codigo string `json:"codigo"`
+			Veces  int    `json:"veces"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Codigo == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "se requiere el código de la excepción"})
+			return
+		}
+		if body.Veces <= 0 {
+			body.Veces = 1
+		}
+		s.InyectarFalla(body.Codigo, body.Veces)
+	}
+
+	s.mu.Lock()
+	pendientes := append([]string{}, s.fallas...)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"pendientes": pendientes})
+}
+
+// leerArchivo valida el nombre del ZIP y retorna el XML que contiene
+func leerArchivo(ruc, fileName, contentFile string) (string, []string, []byte, billFalla) {
+	if !strings.HasSuffix(fileName, ".zip") {
+		return "", nil, nil, "0151"
+	}
+	nombre := strings.TrimSuffix(fileName, ".zip")
+	partes := strings.Split(nombre, "-")
+	if len(partes) != 4 || len(partes[0]) != 11 || !soloDigitos(partes[0]) {
+		return "", nil, nil, "0151"
+	}
+	if partes[0] != ruc {
+		return "", nil, nil, "0154"
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.TrimSpace(contentFile))
+	if err != nil {
+		return "", nil, nil, "0156"
+	}
+	if len(content) == 0 {
+		return "", nil, nil, "0155"
+	}
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", nil, nil, "0156"
+	}
+
+	var xmlFiles []*zip.File
+	for _, f := range reader.File {
+		if strings.HasSuffix(strings.ToLower(f.Name), ".xml") {
+			xmlFiles = append(xmlFiles, f)
+		}
+	}
+	switch {
+	case len(xmlFiles) == 0:
+		return "", nil, nil, "0157"
+	case len(xmlFiles) > 1:
+		return "", nil, nil, "0158"
+	case xmlFiles[0].Name != nombre+".xml":
+		return "", nil, nil, "0161"
+	}
+
+	rc, err := xmlFiles[0].Open()
+	if err != nil {
+		return "", nil, nil, "0156"
+	}
+	defer rc.Close()
+	xmlData, err := io.ReadAll(rc)
+	if err != nil {
+		return "", nil, nil, "0156"
+	}
+	if len(bytes.TrimSpace(xmlData)) == 0 {
+		return "", nil, nil, "0160"
+	}
+	return nombre, partes, xmlData, ""
+}
+
+// validarComprobante compara el contenido del XML con el nombre del archivo y
+// verifica la firma. Las diferencias con el nombre son excepciones; una firma
+// inválida rechaza el comprobante con su CDR.
+func validarComprobante(partes []string, raiz string, xmlData []byte) (string, billFalla) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(xmlData); err != nil {
+		return "", "0306"
+	}
+	root := doc.Root()
+	if root == nil {
+		return "", "0300"
+	}
+	if root.Tag != raiz {
+		return "", "1003"
+	}
+	if tipo := root.SelectElement("InvoiceTypeCode"); raiz == "Invoice" &&
+		(tipo == nil || strings.TrimSpace(tipo.Text()) != partes[1]) {
+		return "", "1003"
+	}
+
+	if emisor := rucEmisor(root); emisor != partes[0] {
+		return "", "1034"
+	}
+
+	id := ""
+	if e := root.SelectElement("ID"); e != nil {
+		id = strings.TrimSpace(e.Text())
+	}
+	if _, resumen := raizPorResumen[partes[1]]; resumen {
+		if id != partes[1]+"-"+partes[2]+"-"+partes[3] {
+			return "", "1036"
+		}
+	} else {
+		serie, numero, _ := strings.Cut(id, "-")
+		if serie != partes[2] {
+			return "", "1035"
+		}
+		if !mismoNumero(numero, partes[3]) {
+			return "", "1036"
+		}
+	}
+
+	if _, err := signature.VerifyXML(string(xmlData)); err != nil {
+		return "2335", ""
+	}
+	return "0", ""
+}
+
+// rucEmisor retorna el RUC del emisor consignado en el XML
+func rucEmisor(root *etree.Element) string {
+	for _, path := range []string{
+		"AccountingSupplierParty/Party/PartyIdentification/ID",
+		"AccountingSupplierParty/CustomerAssignedAccountID",
+		"AgentParty/PartyIdentification/ID",
+	} {
+		if e := root.FindElement(path); e != nil && strings.TrimSpace(e.Text()) != "" {
+			return strings.TrimSpace(e.Text())
+		}
+	}
+	return ""
+}
+
+func descripcionCDR(tipo, id, codigo string) string {
+	if !aceptado(codigo) {
+		e, _ := catalog.GetErrorSUNAT(codigo)
+		return e.Mensaje
+	}
+	nombre := nombrePorTipo[tipo]
+	if strings.HasPrefix(nombre, "El ") {
+		return fmt.Sprintf("%s %s, ha sido aceptado", nombre, id)
+	}
+	return fmt.Sprintf("%s %s, ha sido aceptada", nombre, id)
+}
+
+// buildCDR genera el ZIP con la constancia de recepción, firmada con el
+// certificado configurado
+func (s *BillServer) buildCDR(ruc, nombre, id, codigo, descripcion string) ([]byte, error) {
+	now := time.Now()
+	s.mu.Lock()
+	s.numero++
+	cdrID := fmt.Sprintf("%d", now.UnixMilli()*1000+int64(s.numero%1000))
+	s.mu.Unlock()
+
+	// Igual que los comprobantes, se firma el documento sin espacios de
+	// nombres ni extensiones y luego se agregan ambos
+	render := func(cabecera, espacios, extensiones string) string {
+		return fmt.Sprintf(`%s<ar:ApplicationResponse%s>%s
+  <cbc:UBLVersionID>2.0</cbc:UBLVersionID>
+  <cbc:CustomizationID>1.0</cbc:CustomizationID>
+  <cbc:ID>%s</cbc:ID>
+  <cbc:IssueDate>%s</cbc:IssueDate>
+  <cbc:IssueTime>%s</cbc:IssueTime>
+  <cbc:ResponseDate>%s</cbc:ResponseDate>
+  <cbc:ResponseTime>%s</cbc:ResponseTime>
+  <cac:SenderParty>
+    <cac:PartyIdentification>
+      <cbc:ID>%s</cbc:ID>
+    </cac:PartyIdentification>
+  </cac:SenderParty>
+  <cac:ReceiverParty>
+    <cac:PartyIdentification>
+      <cbc:ID>%s</cbc:ID>
+    </cac:PartyIdentification>
+  </cac:ReceiverParty>
+  <cac:DocumentResponse>
+    <cac:Response>
+      <cbc:ReferenceID>%s</cbc:ReferenceID>
+      <cbc:ResponseCode>%s</cbc:ResponseCode>
+      <cbc:Description>%s</cbc:Description>
+    </cac:Response>
+    <cac:DocumentReference>
+      <cbc:ID>%s</cbc:ID>
+    </cac:DocumentReference>
+  </cac:DocumentResponse>
+</ar:ApplicationResponse>
+`, cabecera, espacios, extensiones, cdrID, now.Format("2006-01-02"), now.Format("15:04:05"), now.Format("2006-01-02"), now.Format("15:04:05"),
+			sunatRUC, ruc, id, codigo, html.EscapeString(descripcion), id)
+	}
+
+	const (
+		cabecera = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+		espacios = ` xmlns:ar="urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2"` +
+			` xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"` +
+			` xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"` +
+			` xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"`
+	)
+	xmlCDR := render(cabecera, espacios, "")
+	if s.cfg.Certificado != nil {
+		firma, err := signature.SignXMLAsElement(context.Background(), strings.TrimSpace(render("", "", "")), s.cfg.Certificado)
+		if err != nil {
+			return nil, err
+		}
+		xmlCDR = render(cabecera, espacios, "\n  <ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent>"+firma+
+			"</ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("R-" + nombre + ".xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(f, xmlCDR); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func statusResponse(operacion, elemento, codigo, mensaje string, cdrZip []byte) string {
+	if mensaje == "" {
+		mensaje, _ = catalog.GetCodigoConsulta(codigo)
+	}
+	content := ""
+	if cdrZip != nil {
+		content = "<content>" + base64.StdEncoding.EncodeToString(cdrZip) + "</content>"
+	}
+	return fmt.Sprintf(`<br:%s xmlns:br="http://service.sunat.gob.pe"><%s><statusCode>%s</statusCode><statusMessage>%s</statusMessage>%s</%s></br:%s>`,
+		operacion, elemento, codigo, html.EscapeString(mensaje), content, elemento, operacion)
+}
+
+func writeSOAP(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Header/><soap-env:Body>%s</soap-env:Body></soap-env:Envelope>`, body)
+}
+
+// writeSOAPFault responde una excepción de SUNAT; sin código se responde un
+// error genérico del cliente con el mensaje indicado
+func writeSOAPFault(w http.ResponseWriter, codigo, mensaje string) {
+	faultCode := "soap-env:Client"
+	if codigo != "" {
+		e, _ := catalog.GetErrorSUNAT(codigo)
+		if mensaje == "" {
+			mensaje = e.Mensaje
+		}
+		if e.Categoria == catalog.ErrorTransitorio {
+			faultCode = "soap-env:Server"
+		}
+		faultCode += "." + codigo
+	}
+
+	w.Header().Set("Content-Type", "text/xml;charset=UTF-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><soap-env:Envelope xmlns:soap-env="http://schemas.xmlsoap.org/soap/envelope/"><soap-env:Body><soap-env:Fault><faultcode>%s</faultcode><faultstring>%s</faultstring></soap-env:Fault></soap-env:Body></soap-env:Envelope>`,
+		faultCode, html.EscapeString(mensaje))
+}
+
+// aceptado indica si el código del CDR es una aceptación (con o sin observaciones)
+func aceptado(codigo string) bool {
+	n, err := strconv.Atoi(codigo)
+	return err == nil && (n == 0 || n >= 4000)
+}
+
+// mismoNumero compara números de comprobante sin considerar ceros a la izquierda
+func mismoNumero(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return na == nb
+}
+
+func soloDigitos(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
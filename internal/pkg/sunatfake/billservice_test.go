@@ -0,0 +1,203 @@
+package sunatfake_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ubl-converter/internal/pkg/cdr"
+	"ubl-converter/internal/pkg/signature"
+	"ubl-converter/internal/pkg/soap"
+	"ubl-converter/internal/pkg/sunatfake"
+)
+
+const rucEmisor = "20123456789"
+
+func nuevoBillServer(t *testing.T, cfg sunatfake.BillConfig) (*sunatfake.BillServer, string) {
+	t.Helper()
+	cfg.Usuario, cfg.Clave = "MODDATOS", "moddatos"
+	cfg.Certificado = certificadoPrueba(t)
+	sim := sunatfake.NewBillServer(cfg)
+	srv := httptest.NewServer(sim)
+	t.Cleanup(srv.Close)
+	return sim, srv.URL + "/ol-ti-itcpfegem-beta/billService"
+}
+
+func certificadoPrueba(t *testing.T) *signature.CertificateInfo {
+	t.Helper()
+	cert, key, err := signature.LoadKeyPairFromPEM("../../../certificados/C23022479065.pem")
+	if err != nil {
+		t.Fatalf("certificado de prueba: %v", err)
+	}
+	return &signature.CertificateInfo{Certificate: cert, PrivateKey: key}
+}
+
+// documentoFirmado arma un XML con el cuerpo indicado y, si cert no es nil,
+// lo firma como SignXMLAsElement: sin espacios de nombres ni extensiones
+func documentoFirmado(t *testing.T, raiz, espacios, cuerpo string, cert *signature.CertificateInfo) string {
+	t.Helper()
+	render := func(espacios, extensiones string) string {
+		return fmt.Sprintf("<%s%s>%s%s</%s>", raiz, espacios, extensiones, cuerpo, raiz)
+	}
+	espacios += ` xmlns:ext="urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"` +
+		` xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"` +
+		` xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"`
+	if cert == nil {
+		return `<?xml version="1.0" encoding="UTF-8"?>` + render(espacios, "")
+	}
+	firma, err := signature.SignXMLAsElement(context.Background(), render("", ""), cert)
+	if err != nil {
+		t.Fatalf("firmando %s: %v", raiz, err)
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>` + render(espacios,
+		"<ext:UBLExtensions><ext:UBLExtension><ext:ExtensionContent>"+firma+"</ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>")
+}
+
+func factura(t *testing.T, serieNumero string, cert *signature.CertificateInfo) string {
+	return documentoFirmado(t, "Invoice", ` xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"`,
+		"<cbc:ID>"+serieNumero+"</cbc:ID><cbc:InvoiceTypeCode>01</cbc:InvoiceTypeCode>"+
+			"<cac:AccountingSupplierParty><cac:Party><cac:PartyIdentification><cbc:ID>"+rucEmisor+
+			"</cbc:ID></cac:PartyIdentification></cac:Party></cac:AccountingSupplierParty>", cert)
+}
+
+func resumen(t *testing.T, id string, cert *signature.CertificateInfo) string {
+	return documentoFirmado(t, "SummaryDocuments", ` xmlns="urn:sunat:names:specification:ubl:peru:schema:xsd:SummaryDocuments-1"`,
+		"<cbc:ID>"+id+"</cbc:ID>"+
+			"<cac:AccountingSupplierParty><cac:Party><cac:PartyIdentification><cbc:ID>"+rucEmisor+
+			"</cbc:ID></cac:PartyIdentification></cac:Party></cac:AccountingSupplierParty>", cert)
+}
+
+func zipXML(t *testing.T, nombre, contenido string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create(nombre + ".xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(contenido))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSendBillYConsultaDelCDR(t *testing.T) {
+	_, endpoint := nuevoBillServer(t, sunatfake.BillConfig{})
+	cert := certificadoPrueba(t)
+	client := soap.NewClient(rucEmisor+"MODDATOS", "moddatos", nil)
+
+	nombre := rucEmisor + "-01-F001-1"
+	cdrZip, err := client.SendBill(context.Background(), endpoint, nombre+".zip", zipXML(t, nombre, factura(t, "F001-1", cert)))
+	if err != nil {
+		t.Fatalf("SendBill: %v", err)
+	}
+	constancia, err := cdr.Parse(cdrZip)
+	if err != nil {
+		t.Fatalf("cdr.Parse: %v", err)
+	}
+	if !constancia.Accepted() || constancia.ReferenceID != "F001-1" {
+		t.Fatalf("CDR = %+v, se esperaba F001-1 aceptada", constancia)
+	}
+
+	ref := soap.ComprobanteRef{RUC: rucEmisor, Tipo: "01", Serie: "F001", Numero: "1"}
+	status, err := client.GetStatusCdr(context.Background(), endpoint, ref)
+	if err != nil {
+		t.Fatalf("GetStatusCdr: %v", err)
+	}
+	if status.Content == "" {
+		t.Fatalf("GetStatusCdr = %+v, se esperaba el CDR", status)
+	}
+
+	// El mismo comprobante no puede enviarse dos veces
+	_, err = client.SendBill(context.Background(), endpoint, nombre+".zip", zipXML(t, nombre, factura(t, "F001-1", cert)))
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.Code != "1033" {
+		t.Fatalf("reenvío: err = %v, se esperaba la excepción 1033", err)
+	}
+}
+
+func TestSendBillSinFirmaSeRechaza(t *testing.T) {
+	_, endpoint := nuevoBillServer(t, sunatfake.BillConfig{})
+	client := soap.NewClient(rucEmisor+"MODDATOS", "moddatos", nil)
+
+	nombre := rucEmisor + "-01-F001-2"
+	cdrZip, err := client.SendBill(context.Background(), endpoint, nombre+".zip", zipXML(t, nombre, factura(t, "F001-2", nil)))
+	if err != nil {
+		t.Fatalf("SendBill: %v", err)
+	}
+	constancia, err := cdr.Parse(cdrZip)
+	if err != nil {
+		t.Fatalf("cdr.Parse: %v", err)
+	}
+	if constancia.Accepted() || constancia.ResponseCode != "2335" {
+		t.Fatalf("CDR = %+v, se esperaba el rechazo 2335", constancia)
+	}
+}
+
+func TestSendBillUsuarioInvalido(t *testing.T) {
+	_, endpoint := nuevoBillServer(t, sunatfake.BillConfig{})
+	client := soap.NewClient(rucEmisor+"MODDATOS", "otra", nil)
+
+	nombre := rucEmisor + "-01-F001-3"
+	_, err := client.SendBill(context.Background(), endpoint, nombre+".zip", zipXML(t, nombre, factura(t, "F001-3", nil)))
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.Code != "0102" {
+		t.Fatalf("err = %v, se esperaba la excepción 0102", err)
+	}
+}
+
+func TestSendSummaryYGetStatus(t *testing.T) {
+	_, endpoint := nuevoBillServer(t, sunatfake.BillConfig{TicketEnProceso: 1})
+	cert := certificadoPrueba(t)
+	client := soap.NewClient(rucEmisor+"MODDATOS", "moddatos", nil)
+
+	nombre := rucEmisor + "-RC-20240101-1"
+	ticket, err := client.SendSummary(context.Background(), endpoint, nombre+".zip", zipXML(t, nombre, resumen(t, "RC-20240101-1", cert)))
+	if err != nil {
+		t.Fatalf("SendSummary: %v", err)
+	}
+	if ticket == "" {
+		t.Fatal("SendSummary no retornó ticket")
+	}
+
+	status, err := client.GetStatus(context.Background(), endpoint, ticket)
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if status.StatusCode != "98" {
+		t.Fatalf("primera consulta = %+v, se esperaba 98 (en proceso)", status)
+	}
+
+	status, err = client.GetStatus(context.Background(), endpoint, ticket)
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if status.StatusCode != "0" || status.Content == "" {
+		t.Fatalf("segunda consulta = %+v, se esperaba 0 con el CDR", status)
+	}
+
+	// Otro emisor no puede consultar el ticket
+	otro := soap.NewClient("20987654321MODDATOS", "moddatos", nil)
+	_, err = otro.GetStatus(context.Background(), endpoint, ticket)
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || fault.Code != "0127" {
+		t.Fatalf("consulta de otro emisor: err = %v, se esperaba la excepción 0127", err)
+	}
+}
+
+func TestGetStatusTicketInexistente(t *testing.T) {
+	_, endpoint := nuevoBillServer(t, sunatfake.BillConfig{})
+	client := soap.NewClient(rucEmisor+"MODDATOS", "moddatos", nil)
+
+	_, err := client.GetStatus(context.Background(), endpoint, "123")
+	var fault *soap.Fault
+	if !errors.As(err, &fault) || !strings.HasSuffix(fault.FaultCode, "0127") {
+		t.Fatalf("err = %v, se esperaba la excepción 0127", err)
+	}
+}
@@ -0,0 +1,264 @@
+// Package sunatfake implementa un servidor local que imita las APIs de SUNAT,
+// para probar los envíos sin conectarse a los servicios reales.
+package sunatfake
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// GREEnvio guía recibida por el servidor falso
+type GREEnvio struct {
+	Ticket     string
+	NomArchivo string
+	Zip        []byte
+}
+
+// GREServer imita el servicio de seguridad (token OAuth2) y la API de
+// comprobantes de guías de remisión
+type GREServer struct {
+	ClientID     string
+	ClientSecret string
+	Username     string
+	Password     string
+
+	server *httptest.Server
+	mu     sync.Mutex
+	envios map[string]GREEnvio
+	orden  []string
+	tokens int
+	// primer token vigente; RevocarTokens invalida los emitidos hasta ahora
+	vigenteDesde int
+}
+
+// NewGREServer inicia un servidor falso que acepta las credenciales indicadas
+func NewGREServer(clientID, clientSecret, username, password string) *GREServer {
+	s := &GREServer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Username:     username,
+		Password:     password,
+		envios:       make(map[string]GREEnvio),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/seguridad/", s.handleToken)
+	mux.HandleFunc("/gem/comprobantes/", s.handleComprobantes)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// TokenURL URL base del servicio de seguridad
+func (s *GREServer) TokenURL() string {
+	return s.server.URL + "/seguridad"
+}
+
+// APIURL URL base de la API de comprobantes
+func (s *GREServer) APIURL() string {
+	return s.server.URL + "/gem"
+}
+
+// Envios retorna las guías recibidas en el orden de llegada
+func (s *GREServer) Envios() []GREEnvio {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	envios := make([]GREEnvio, 0, len(s.orden))
+	for _, ticket := range s.orden {
+		envios = append(envios, s.envios[ticket])
+	}
+	return envios
+}
+
+// TokenRequests retorna cuántos tokens se han emitido
+func (s *GREServer) TokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens
+}
+
+// RevocarTokens invalida los tokens emitidos hasta ahora, como cuando SUNAT
+// los revoca antes de que expiren
+func (s *GREServer) RevocarTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vigenteDesde = s.tokens + 1
+}
+
+// Close detiene el servidor
+func (s *GREServer) Close() {
+	s.server.Close()
+}
+
+func (s *GREServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/"+s.ClientID+"/oauth2/token/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"cod": "404", "msg": "recurso no encontrado"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"cod": "400", "msg": err.Error()})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "password" ||
+		r.PostForm.Get("client_id") != s.ClientID ||
+		r.PostForm.Get("client_secret") != s.ClientSecret ||
+		r.PostForm.Get("username") != s.Username ||
+		r.PostForm.Get("password") != s.Password {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error":             "invalid_client",
+			"error_description": "credenciales inválidas",
+		})
+		return
+	}
+
+	s.mu.Lock()
+	s.tokens++
+	token := fmt.Sprintf("token-de-prueba-%d", s.tokens)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token,
+		"token_type":   "JWT",
+		"expires_in":   3600,
+	})
+}
+
+func (s *GREServer) handleComprobantes(w http.ResponseWriter, r *http.Request) {
+	if !s.tokenVigente(r.Header.Get("Authorization")) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"cod": "401", "msg": "token inválido"})
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/gem/comprobantes/")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(name, "envios/"):
+		s.handleStatus(w, strings.TrimPrefix(name, "envios/"))
+	case r.Method == http.MethodPost:
+		s.handleSend(w, r, name)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"cod": "404", "msg": "recurso no encontrado"})
+	}
+}
+
+// tokenVigente indica si la cabecera Authorization lleva un token emitido por
+// el servidor y no revocado
+func (s *GREServer) tokenVigente(authorization string) bool {
+	var n int
+	if _, err := fmt.Sscanf(authorization, "Bearer token-de-prueba-%d", &n); err != nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return n >= s.vigenteDesde && n <= s.tokens
+}
+
+func (s *GREServer) handleSend(w http.ResponseWriter, r *http.Request, name string) {
+	var body struct {
+		Archivo struct {
+			NomArchivo string `json:"nomArchivo"`
+			ArcGreZip  string `json:"arcGreZip"`
+			HashZip    string `json:"hashZip"`
+		} `json:"archivo"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"cod": "400", "msg": err.Error()})
+		return
+	}
+
+	content, err := base64.StdEncoding.DecodeString(body.Archivo.ArcGreZip)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"cod": "422", "msg": "archivo no está en base64"})
+		return
+	}
+	hash := sha256.Sum256(content)
+	if fmt.Sprintf("%x", hash[:]) != body.Archivo.HashZip {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"cod": "422", "msg": "el hash del archivo no coincide"})
+		return
+	}
+	if body.Archivo.NomArchivo != name+".zip" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"cod": "422", "msg": "el nombre del archivo no coincide"})
+		return
+	}
+
+	s.mu.Lock()
+	ticket := fmt.Sprintf("%08d-0000-0000-0000-000000000000", len(s.orden)+1)
+	s.envios[ticket] = GREEnvio{Ticket: ticket, NomArchivo: body.Archivo.NomArchivo, Zip: content}
+	s.orden = append(s.orden, ticket)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"numTicket":    ticket,
+		"fecRecepcion": "2024-01-01T00:00:00",
+	})
+}
+
+func (s *GREServer) handleStatus(w http.ResponseWriter, ticket string) {
+	s.mu.Lock()
+	envio, ok := s.envios[ticket]
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"codRespuesta": "99",
+			"error":        map[string]string{"numError": "2001", "desError": "El ticket no existe"},
+		})
+		return
+	}
+
+	cdr, err := buildCDR(strings.TrimSuffix(envio.NomArchivo, ".zip"))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"cod": "500", "msg": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{
+		"codRespuesta":   "0",
+		"arcCdr":         base64.StdEncoding.EncodeToString(cdr),
+		"indCdrGenerado": "1",
+	})
+}
+
+// buildCDR genera un ZIP con una constancia de recepción aceptada
+func buildCDR(name string) ([]byte, error) {
+	parts := strings.SplitN(name, "-", 3)
+	id := name
+	if len(parts) == 3 {
+		id = parts[2]
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("R-" + name + ".xml")
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(f, `<?xml version="1.0" encoding="UTF-8"?>
+<ar:ApplicationResponse xmlns:ar="urn:oasis:names:specification:ubl:schema:xsd:ApplicationResponse-2" xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2" xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cbc:UBLVersionID>2.0</cbc:UBLVersionID>
+  <cbc:CustomizationID>1.0</cbc:CustomizationID>
+  <cac:DocumentResponse>
+    <cac:Response>
+      <cbc:ReferenceID>%s</cbc:ReferenceID>
+      <cbc:ResponseCode>0</cbc:ResponseCode>
+      <cbc:Description>La Guia numero %s, ha sido aceptada</cbc:Description>
+    </cac:Response>
+  </cac:DocumentResponse>
+</ar:ApplicationResponse>
+`, id, id)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
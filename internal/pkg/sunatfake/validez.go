@@ -0,0 +1,154 @@
+package sunatfake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// ValidezServer imita el servicio de seguridad (client_credentials) y la
+// consulta integrada de validez de comprobantes
+type ValidezServer struct {
+	ClientID     string
+	ClientSecret string
+
+	server       *httptest.Server
+	mu           sync.Mutex
+	comprobantes map[string]ValidezEstado
+	consultas    int
+}
+
+// ValidezEstado estado que el servidor falso informa para un comprobante
+type ValidezEstado struct {
+	EstadoCp      string
+	EstadoRuc     string
+	CondDomiRuc   string
+	Observaciones []string
+}
+
+const fakeValidezToken = "token-validez-de-prueba"
+
+// NewValidezServer inicia un servidor falso que acepta las credenciales indicadas
+func NewValidezServer(clientID, clientSecret string) *ValidezServer {
+	s := &ValidezServer{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		comprobantes: make(map[string]ValidezEstado),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/seguridad/", s.handleToken)
+	mux.HandleFunc("/contribuyentes/", s.handleValidar)
+	s.server = httptest.NewServer(mux)
+	return s
+}
+
+// TokenURL URL base del servicio de seguridad
+func (s *ValidezServer) TokenURL() string {
+	return s.server.URL + "/seguridad"
+}
+
+// APIURL URL base de la consulta de validez
+func (s *ValidezServer) APIURL() string {
+	return s.server.URL + "/contribuyentes"
+}
+
+// Registrar define el estado de un comprobante; los comprobantes no
+// registrados se informan como inexistentes
+func (s *ValidezServer) Registrar(ruc, tipo, serie, numero string, estado ValidezEstado) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.comprobantes[strings.Join([]string{ruc, tipo, serie, numero}, "-")] = estado
+}
+
+// Consultas retorna cuántas consultas de validez se han recibido
+func (s *ValidezServer) Consultas() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.consultas
+}
+
+// Close detiene el servidor
+func (s *ValidezServer) Close() {
+	s.server.Close()
+}
+
+func (s *ValidezServer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/"+s.ClientID+"/oauth2/token/") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"cod": "404", "msg": "recurso no encontrado"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"cod": "400", "msg": err.Error()})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "client_credentials" ||
+		r.PostForm.Get("client_id") != s.ClientID ||
+		r.PostForm.Get("client_secret") != s.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error":             "invalid_client",
+			"error_description": "credenciales inválidas",
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": fakeValidezToken,
+		"token_type":   "JWT",
+		"expires_in":   3600,
+	})
+}
+
+func (s *ValidezServer) handleValidar(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+fakeValidezToken {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"cod": "401", "msg": "token inválido"})
+		return
+	}
+	if r.Method != http.MethodPost || !strings.HasSuffix(r.URL.Path, "/validarcomprobante") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"cod": "404", "msg": "recurso no encontrado"})
+		return
+	}
+
+	var body struct {
+		NumRuc       string `json:"numRuc"`
+		CodComp      string `json:"codComp"`
+		NumeroSerie  string `json:"numeroSerie"`
+		Numero       string `json:"numero"`
+		FechaEmision string `json:"fechaEmision"`
+		Monto        string `json:"monto"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": false, "message": "JSON inválido", "errorCode": "400"})
+		return
+	}
+	if body.NumRuc == "" || body.CodComp == "" || body.NumeroSerie == "" || body.Numero == "" || body.FechaEmision == "" {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": false, "message": "Faltan datos del comprobante", "errorCode": "400"})
+		return
+	}
+
+	s.mu.Lock()
+	s.consultas++
+	estado, ok := s.comprobantes[strings.Join([]string{body.NumRuc, body.CodComp, body.NumeroSerie, body.Numero}, "-")]
+	s.mu.Unlock()
+	if !ok {
+		estado = ValidezEstado{EstadoCp: "0"}
+	}
+
+	data := map[string]interface{}{"estadoCp": estado.EstadoCp}
+	if estado.EstadoRuc != "" {
+		data["estadoRuc"] = estado.EstadoRuc
+	}
+	if estado.CondDomiRuc != "" {
+		data["condDomiRuc"] = estado.CondDomiRuc
+	}
+	if len(estado.Observaciones) > 0 {
+		data["observaciones"] = estado.Observaciones
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Operation Success! ",
+		"data":    data,
+	})
+}
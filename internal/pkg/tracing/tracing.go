@@ -0,0 +1,88 @@
+// Package tracing configura las trazas de OpenTelemetry y crea los spans de
+// la conversión, la firma, el ZIP, el PDF y las llamadas a SUNAT. Sin Setup
+// los spans no se registran.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"ubl-converter/internal/pkg/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName nombre del tracer de la aplicación
+const TracerName = "ubl-converter"
+
+// Atributos de los spans
+const (
+	AttrDocumento = attribute.Key("ubl.documento")   // RUC-TIPO-SERIE-NUMERO
+	AttrTipo      = attribute.Key("ubl.tipo")        // tipo de comprobante
+	AttrOperacion = attribute.Key("sunat.operacion") // sendBill, getStatus, ...
+	AttrCodigo    = attribute.Key("sunat.codigo")    // código de error de SUNAT
+)
+
+// Setup configura el proveedor global de trazas con el exportador indicado
+// y retorna la función que envía los spans pendientes al terminar
+func Setup(ctx context.Context, cfg config.TracingConfig) (func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case config.TracingNone, "":
+		return func(context.Context) error { return nil }, nil
+	case config.TracingOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case config.TracingStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("exportador de trazas inválido: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creando el exportador de trazas: %v", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("error creando el recurso de trazas: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start inicia un span hijo del span de ctx
+func Start(ctx context.Context, nombre string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(TracerName).Start(ctx, nombre, trace.WithAttributes(attrs...))
+}
+
+// End registra el error, si lo hay, y termina el span
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
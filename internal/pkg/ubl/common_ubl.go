@@ -2,8 +2,8 @@ package ubl
 
 // Signature representa el elemento cac:Signature en UBL
 type Signature struct {
-	ID                       string                   `xml:"cbc:ID"`
-	SignatoryParty           SignatoryParty           `xml:"cac:SignatoryParty"`
+	ID                         string                     `xml:"cbc:ID"`
+	SignatoryParty             SignatoryParty             `xml:"cac:SignatoryParty"`
 	DigitalSignatureAttachment DigitalSignatureAttachment `xml:"cac:DigitalSignatureAttachment"`
 }
 
@@ -15,7 +15,23 @@ type SignatoryParty struct {
 
 // PartyIdentification representa el elemento cac:PartyIdentification en UBL
 type PartyIdentification struct {
-	ID string `xml:"cbc:ID"`
+	ID Identifier `xml:"cbc:ID"`
+}
+
+// Identifier representa un identificador con su esquema (p.ej. tipo de documento, catálogo 06)
+type Identifier struct {
+	Value            string `xml:",chardata"`
+	SchemeID         string `xml:"schemeID,attr,omitempty"`
+	SchemeName       string `xml:"schemeName,attr,omitempty"`
+	SchemeAgencyName string `xml:"schemeAgencyName,attr,omitempty"`
+}
+
+// ExchangeRate representa el elemento cac:ExchangeRate en UBL
+type ExchangeRate struct {
+	SourceCurrencyCode string  `xml:"cbc:SourceCurrencyCode"`
+	TargetCurrencyCode string  `xml:"cbc:TargetCurrencyCode"`
+	CalculationRate    float64 `xml:"cbc:CalculationRate"`
+	Date               string  `xml:"cbc:Date,omitempty"`
 }
 
 // DigitalSignatureAttachment representa el elemento cac:DigitalSignatureAttachment en UBL
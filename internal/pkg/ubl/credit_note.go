@@ -7,7 +7,7 @@ type CreditNote struct {
 	ID                   string `xml:"cbc:ID"`
 	IssueDate            string `xml:"cbc:IssueDate"`
 	IssueTime            string `xml:"cbc:IssueTime"`
-	Note                 string `xml:"cbc:Note,omitempty"`
+	Notes                []Note `xml:"cbc:Note"`
 	DocumentCurrencyCode string `xml:"cbc:DocumentCurrencyCode"`
 	LineCountNumeric     int    `xml:"cbc:LineCountNumeric,omitempty"`
 
@@ -0,0 +1,101 @@
+package ubl
+
+// DespatchAdvice represents a SUNAT despatch advice (guía de remisión
+// electrónica, tipo 09 remitente y 31 transportista)
+type DespatchAdvice struct {
+	UBLVersionID           string `xml:"cbc:UBLVersionID"`
+	CustomizationID        string `xml:"cbc:CustomizationID"`
+	ID                     string `xml:"cbc:ID"`
+	IssueDate              string `xml:"cbc:IssueDate"`
+	IssueTime              string `xml:"cbc:IssueTime"`
+	DespatchAdviceTypeCode string `xml:"cbc:DespatchAdviceTypeCode"`
+	Notes                  []Note `xml:"cbc:Note"`
+
+	AdditionalDocumentReferences []DocumentReference `xml:"cac:AdditionalDocumentReference"`
+	Signature                    Signature           `xml:"cac:Signature"`
+
+	DespatchSupplierParty DespatchParty    `xml:"cac:DespatchSupplierParty"`
+	DeliveryCustomerParty DespatchParty    `xml:"cac:DeliveryCustomerParty"`
+	Shipment              DespatchShipment `xml:"cac:Shipment"`
+	DespatchLines         []DespatchLine   `xml:"cac:DespatchLine"`
+}
+
+// DespatchParty represents the sender, carrier or recipient of a despatch advice
+type DespatchParty struct {
+	Party Party `xml:"cac:Party"`
+}
+
+// DespatchShipment represents the transfer data: reason, weight, transport
+// stages, origin and destination addresses and vehicles
+type DespatchShipment struct {
+	ID                     string                  `xml:"cbc:ID"`
+	HandlingCode           string                  `xml:"cbc:HandlingCode,omitempty"`
+	HandlingInstructions   string                  `xml:"cbc:HandlingInstructions,omitempty"`
+	GrossWeightMeasure     Measure                 `xml:"cbc:GrossWeightMeasure"`
+	ShipmentStages         []DespatchShipmentStage `xml:"cac:ShipmentStage"`
+	Delivery               DespatchDelivery        `xml:"cac:Delivery"`
+	TransportHandlingUnits []TransportHandlingUnit `xml:"cac:TransportHandlingUnit"`
+}
+
+// DespatchShipmentStage represents a transport stage: mode (catálogo 18),
+// start date, carrier (public transport) and drivers (private transport)
+type DespatchShipmentStage struct {
+	TransportModeCode string         `xml:"cbc:TransportModeCode,omitempty"`
+	TransitPeriod     TransitPeriod  `xml:"cac:TransitPeriod"`
+	CarrierParty      *Party         `xml:"cac:CarrierParty,omitempty"`
+	DriverPersons     []DriverPerson `xml:"cac:DriverPerson"`
+}
+
+// TransitPeriod represents the start date of the transfer
+type TransitPeriod struct {
+	StartDate string `xml:"cbc:StartDate"`
+}
+
+// DriverPerson represents a vehicle driver and its driving license
+type DriverPerson struct {
+	ID                        Identifier                `xml:"cbc:ID"`
+	FirstName                 string                    `xml:"cbc:FirstName"`
+	FamilyName                string                    `xml:"cbc:FamilyName"`
+	JobTitle                  string                    `xml:"cbc:JobTitle"`
+	IdentityDocumentReference IdentityDocumentReference `xml:"cac:IdentityDocumentReference"`
+}
+
+// IdentityDocumentReference represents the driving license of a driver
+type IdentityDocumentReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// DespatchDelivery represents the arrival address and the departure (with the sender, for carriers)
+type DespatchDelivery struct {
+	DeliveryAddress Address  `xml:"cac:DeliveryAddress"`
+	Despatch        Despatch `xml:"cac:Despatch"`
+}
+
+// Despatch represents the departure address and, in a carrier's despatch advice, the sender
+type Despatch struct {
+	DespatchAddress Address `xml:"cac:DespatchAddress"`
+	DespatchParty   *Party  `xml:"cac:DespatchParty,omitempty"`
+}
+
+// TransportHandlingUnit represents the vehicles used in the transfer
+type TransportHandlingUnit struct {
+	TransportEquipment []TransportEquipment `xml:"cac:TransportEquipment"`
+}
+
+// TransportEquipment represents a vehicle by its license plate
+type TransportEquipment struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// DespatchLine represents a despatched item
+type DespatchLine struct {
+	ID                 string             `xml:"cbc:ID"`
+	DeliveredQuantity  Quantity           `xml:"cbc:DeliveredQuantity"`
+	OrderLineReference OrderLineReference `xml:"cac:OrderLineReference"`
+	Item               Item               `xml:"cac:Item"`
+}
+
+// OrderLineReference represents the line number of a despatched item
+type OrderLineReference struct {
+	LineID string `xml:"cbc:LineID"`
+}
@@ -2,20 +2,50 @@ package ubl
 
 // Invoice represents a UBL invoice document
 type Invoice struct {
-	UBLVersionID         string `xml:"cbc:UBLVersionID"`
-	CustomizationID      string `xml:"cbc:CustomizationID"`
-	ID                   string `xml:"cbc:ID"`
-	IssueDate            string `xml:"cbc:IssueDate"`
-	IssueTime            string `xml:"cbc:IssueTime"`
-	InvoiceTypeCode      string `xml:"cbc:InvoiceTypeCode"`
-	DocumentCurrencyCode string `xml:"cbc:DocumentCurrencyCode"`
-	LineCountNumeric     int    `xml:"cbc:LineCountNumeric,omitempty"`
-
-	AccountingSupplierParty SupplierParty `xml:"cac:AccountingSupplierParty"`
-	AccountingCustomerParty CustomerParty `xml:"cac:AccountingCustomerParty"`
-	TaxTotal                []TaxTotal    `xml:"cac:TaxTotal"`
-	LegalMonetaryTotal      MonetaryTotal `xml:"cac:LegalMonetaryTotal"`
-	InvoiceLines            []InvoiceLine `xml:"cac:InvoiceLine"`
+	UBLVersionID         string          `xml:"cbc:UBLVersionID"`
+	CustomizationID      string          `xml:"cbc:CustomizationID"`
+	ID                   string          `xml:"cbc:ID"`
+	IssueDate            string          `xml:"cbc:IssueDate"`
+	IssueTime            string          `xml:"cbc:IssueTime"`
+	InvoiceTypeCode      InvoiceTypeCode `xml:"cbc:InvoiceTypeCode"`
+	Notes                []Note          `xml:"cbc:Note"`
+	DocumentCurrencyCode string          `xml:"cbc:DocumentCurrencyCode"`
+	LineCountNumeric     int             `xml:"cbc:LineCountNumeric,omitempty"`
+
+	AdditionalDocumentReferences []DocumentReference `xml:"cac:AdditionalDocumentReference"`
+
+	AccountingSupplierParty SupplierParty     `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty CustomerParty     `xml:"cac:AccountingCustomerParty"`
+	Delivery                *Delivery         `xml:"cac:Delivery,omitempty"`
+	DeliveryTerms           *DeliveryTerms    `xml:"cac:DeliveryTerms,omitempty"`
+	PaymentMeans            []PaymentMeans    `xml:"cac:PaymentMeans"`
+	PaymentTerms            []PaymentTerms    `xml:"cac:PaymentTerms"`
+	PrepaidPayments         []PrepaidPayment  `xml:"cac:PrepaidPayment"`
+	AllowanceCharges        []AllowanceCharge `xml:"cac:AllowanceCharge"`
+	PaymentExchangeRate     *ExchangeRate     `xml:"cac:PaymentExchangeRate,omitempty"`
+	TaxTotal                []TaxTotal        `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal      MonetaryTotal     `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines            []InvoiceLine     `xml:"cac:InvoiceLine"`
+}
+
+// InvoiceTypeCode represents the document type code, with the operation type (catálogo 51) as listID
+type InvoiceTypeCode struct {
+	Value  string `xml:",chardata"`
+	ListID string `xml:"listID,attr,omitempty"`
+}
+
+// Note represents a document note; languageLocaleID carries the legend code (catálogo 52)
+type Note struct {
+	Value            string `xml:",chardata"`
+	LanguageLocaleID string `xml:"languageLocaleID,attr,omitempty"`
+}
+
+// DocumentReference represents an additional document reference, such as an advance payment invoice
+type DocumentReference struct {
+	ID                 string `xml:"cbc:ID"`
+	DocumentTypeCode   string `xml:"cbc:DocumentTypeCode,omitempty"`
+	DocumentStatusCode string `xml:"cbc:DocumentStatusCode,omitempty"`
+	IssuerParty        *Party `xml:"cac:IssuerParty,omitempty"`
 }
 
 // SupplierParty represents the supplier party in an invoice
@@ -32,8 +62,16 @@ type CustomerParty struct {
 
 // Party represents a party (organization, person, etc.)
 type Party struct {
-	PartyName        []PartyName        `xml:"cac:PartyName"`
-	PartyLegalEntity []PartyLegalEntity `xml:"cac:PartyLegalEntity"`
+	PartyIdentification []PartyIdentification `xml:"cac:PartyIdentification"`
+	PartyName           []PartyName           `xml:"cac:PartyName"`
+	PartyLegalEntity    []PartyLegalEntity    `xml:"cac:PartyLegalEntity"`
+	Contact             *Contact              `xml:"cac:Contact,omitempty"`
+}
+
+// Contact represents the contact information of a party
+type Contact struct {
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
 }
 
 // PartyName represents the name of a party
@@ -43,8 +81,105 @@ type PartyName struct {
 
 // PartyLegalEntity represents legal entity information
 type PartyLegalEntity struct {
-	RegistrationName string `xml:"cbc:RegistrationName"`
-	CompanyID        string `xml:"cbc:CompanyID"`
+	RegistrationName    string   `xml:"cbc:RegistrationName"`
+	CompanyID           string   `xml:"cbc:CompanyID,omitempty"`
+	RegistrationAddress *Address `xml:"cac:RegistrationAddress,omitempty"`
+}
+
+// Address represents a postal address. For the supplier, ID is the ubigeo
+// and AddressTypeCode the establishment code registered with SUNAT.
+type Address struct {
+	ID                  string       `xml:"cbc:ID,omitempty"`
+	AddressTypeCode     string       `xml:"cbc:AddressTypeCode,omitempty"`
+	CitySubdivisionName string       `xml:"cbc:CitySubdivisionName,omitempty"`
+	CityName            string       `xml:"cbc:CityName,omitempty"`
+	CountrySubentity    string       `xml:"cbc:CountrySubentity,omitempty"`
+	District            string       `xml:"cbc:District,omitempty"`
+	AddressLine         *AddressLine `xml:"cac:AddressLine,omitempty"`
+	Country             *Country     `xml:"cac:Country,omitempty"`
+}
+
+// AddressLine represents a free-form address line
+type AddressLine struct {
+	Line string `xml:"cbc:Line"`
+}
+
+// Country represents a country by its ISO 3166-1 alpha-2 code
+type Country struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+// Delivery represents the delivery of the goods, used in export invoices
+type Delivery struct {
+	DeliveryLocation *Location `xml:"cac:DeliveryLocation,omitempty"`
+	Shipment         *Shipment `xml:"cac:Shipment,omitempty"`
+}
+
+// Location represents a location, such as a port or the delivery place
+type Location struct {
+	ID      string   `xml:"cbc:ID,omitempty"`
+	Address *Address `xml:"cac:Address,omitempty"`
+}
+
+// Shipment represents the shipment data of an export
+type Shipment struct {
+	ID                       string         `xml:"cbc:ID"`
+	GrossWeightMeasure       *Measure       `xml:"cbc:GrossWeightMeasure,omitempty"`
+	ShipmentStage            *ShipmentStage `xml:"cac:ShipmentStage,omitempty"`
+	FirstArrivalPortLocation *Location      `xml:"cac:FirstArrivalPortLocation,omitempty"`
+	LastExitPortLocation     *Location      `xml:"cac:LastExitPortLocation,omitempty"`
+}
+
+// ShipmentStage represents a stage of the shipment and its transport mode (UN/ECE rec. 19)
+type ShipmentStage struct {
+	TransportModeCode string `xml:"cbc:TransportModeCode"`
+}
+
+// Measure represents a measure with unit code
+type Measure struct {
+	Value    float64 `xml:",chardata"`
+	UnitCode string  `xml:"unitCode,attr"`
+}
+
+// DeliveryTerms represents the delivery terms of an export (Incoterm)
+type DeliveryTerms struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// PaymentMeans represents a means of payment, such as the detraction deposit account
+type PaymentMeans struct {
+	ID                    string            `xml:"cbc:ID"`
+	PaymentMeansCode      string            `xml:"cbc:PaymentMeansCode"`
+	PayeeFinancialAccount *FinancialAccount `xml:"cac:PayeeFinancialAccount,omitempty"`
+}
+
+// FinancialAccount represents a bank account
+type FinancialAccount struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// PaymentTerms represents the payment terms of an invoice (forma de pago, cuotas and detracción)
+type PaymentTerms struct {
+	ID             string          `xml:"cbc:ID"`
+	PaymentMeansID string          `xml:"cbc:PaymentMeansID,omitempty"`
+	PaymentPercent float64         `xml:"cbc:PaymentPercent,omitempty"`
+	Amount         *MonetaryAmount `xml:"cbc:Amount,omitempty"`
+	PaymentDueDate string          `xml:"cbc:PaymentDueDate,omitempty"`
+}
+
+// PrepaidPayment represents an advance payment (anticipo) deducted from the invoice
+type PrepaidPayment struct {
+	ID         Identifier     `xml:"cbc:ID"`
+	PaidAmount MonetaryAmount `xml:"cbc:PaidAmount"`
+}
+
+// AllowanceCharge represents a discount, charge or perception (catálogo 53)
+type AllowanceCharge struct {
+	ChargeIndicator           bool            `xml:"cbc:ChargeIndicator"`
+	AllowanceChargeReasonCode string          `xml:"cbc:AllowanceChargeReasonCode"`
+	MultiplierFactorNumeric   float64         `xml:"cbc:MultiplierFactorNumeric,omitempty"`
+	Amount                    MonetaryAmount  `xml:"cbc:Amount"`
+	BaseAmount                *MonetaryAmount `xml:"cbc:BaseAmount,omitempty"`
 }
 
 // TaxTotal represents tax information
@@ -78,9 +213,12 @@ type TaxScheme struct {
 
 // MonetaryTotal represents monetary total information
 type MonetaryTotal struct {
-	LineExtensionAmount MonetaryAmount `xml:"cbc:LineExtensionAmount"`
-	TaxInclusiveAmount  MonetaryAmount `xml:"cbc:TaxInclusiveAmount"`
-	PayableAmount       MonetaryAmount `xml:"cbc:PayableAmount"`
+	LineExtensionAmount  MonetaryAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxInclusiveAmount   MonetaryAmount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount *MonetaryAmount `xml:"cbc:AllowanceTotalAmount,omitempty"`
+	ChargeTotalAmount    *MonetaryAmount `xml:"cbc:ChargeTotalAmount,omitempty"`
+	PrepaidAmount        *MonetaryAmount `xml:"cbc:PrepaidAmount,omitempty"`
+	PayableAmount        MonetaryAmount  `xml:"cbc:PayableAmount"`
 }
 
 // MonetaryAmount represents a monetary amount with currency
@@ -91,12 +229,13 @@ type MonetaryAmount struct {
 
 // InvoiceLine represents an invoice line
 type InvoiceLine struct {
-	ID                  string         `xml:"cbc:ID"`
-	InvoicedQuantity    Quantity       `xml:"cbc:InvoicedQuantity"`
-	LineExtensionAmount MonetaryAmount `xml:"cbc:LineExtensionAmount"`
-	TaxTotal            []TaxTotal     `xml:"cac:TaxTotal"`
-	Item                Item           `xml:"cac:Item"`
-	Price               Price          `xml:"cac:Price"`
+	ID                  string            `xml:"cbc:ID"`
+	InvoicedQuantity    Quantity          `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount MonetaryAmount    `xml:"cbc:LineExtensionAmount"`
+	AllowanceCharges    []AllowanceCharge `xml:"cac:AllowanceCharge"`
+	TaxTotal            []TaxTotal        `xml:"cac:TaxTotal"`
+	Item                Item              `xml:"cac:Item"`
+	Price               Price             `xml:"cac:Price"`
 }
 
 // Quantity represents a quantity with unit code
@@ -107,7 +246,36 @@ type Quantity struct {
 
 // Item represents an item in an invoice line
 type Item struct {
-	Description string `xml:"cbc:Description"`
+	Description               string                    `xml:"cbc:Description"`
+	SellersItemIdentification *ItemIdentification       `xml:"cac:SellersItemIdentification,omitempty"`
+	CommodityClassification   []CommodityClassification `xml:"cac:CommodityClassification"`
+	AdditionalItemProperty    []ItemProperty            `xml:"cac:AdditionalItemProperty"`
+}
+
+// ItemIdentification represents an item code, such as the seller's product code
+type ItemIdentification struct {
+	ID string `xml:"cbc:ID"`
+}
+
+// CommodityClassification represents the SUNAT product code (catálogo 25, UNSPSC)
+type CommodityClassification struct {
+	ItemClassificationCode ClassificationCode `xml:"cbc:ItemClassificationCode"`
+}
+
+// ClassificationCode represents a code with its code list attributes
+type ClassificationCode struct {
+	Value          string `xml:",chardata"`
+	ListID         string `xml:"listID,attr,omitempty"`
+	ListAgencyName string `xml:"listAgencyName,attr,omitempty"`
+	ListName       string `xml:"listName,attr,omitempty"`
+}
+
+// ItemProperty represents an additional item property; NameCode is the
+// tax concept code (catálogo 55)
+type ItemProperty struct {
+	Name     string `xml:"cbc:Name"`
+	NameCode string `xml:"cbc:NameCode,omitempty"`
+	Value    string `xml:"cbc:Value,omitempty"`
 }
 
 // Price represents a price in an invoice line
@@ -0,0 +1,39 @@
+package ubl
+
+// Perception represents a SUNAT perception receipt (comprobante de percepción, tipo 40)
+type Perception struct {
+	UBLVersionID    string    `xml:"cbc:UBLVersionID"`
+	CustomizationID string    `xml:"cbc:CustomizationID"`
+	Signature       Signature `xml:"cac:Signature"`
+	ID              string    `xml:"cbc:ID"`
+	IssueDate       string    `xml:"cbc:IssueDate"`
+	IssueTime       string    `xml:"cbc:IssueTime,omitempty"`
+
+	AgentParty    Party `xml:"cac:AgentParty"`
+	ReceiverParty Party `xml:"cac:ReceiverParty"`
+
+	SUNATPerceptionSystemCode string         `xml:"sac:SUNATPerceptionSystemCode"`
+	SUNATPerceptionPercent    float64        `xml:"sac:SUNATPerceptionPercent"`
+	Note                      string         `xml:"cbc:Note,omitempty"`
+	TotalInvoiceAmount        MonetaryAmount `xml:"cbc:TotalInvoiceAmount"`
+	SUNATTotalCashed          MonetaryAmount `xml:"sac:SUNATTotalCashed"`
+
+	DocumentReferences []PerceptionDocumentReference `xml:"sac:SUNATPerceptionDocumentReference"`
+}
+
+// PerceptionDocumentReference represents a document (invoice) affected by the perception
+type PerceptionDocumentReference struct {
+	ID                    Identifier            `xml:"cbc:ID"`
+	IssueDate             string                `xml:"cbc:IssueDate"`
+	TotalInvoiceAmount    MonetaryAmount        `xml:"cbc:TotalInvoiceAmount"`
+	Payment               Payment               `xml:"cac:Payment"`
+	PerceptionInformation PerceptionInformation `xml:"sac:SUNATPerceptionInformation"`
+}
+
+// PerceptionInformation represents the perception amounts for a referenced document
+type PerceptionInformation struct {
+	SUNATPerceptionAmount MonetaryAmount `xml:"sac:SUNATPerceptionAmount"`
+	SUNATPerceptionDate   string         `xml:"sac:SUNATPerceptionDate"`
+	SUNATNetTotalCashed   MonetaryAmount `xml:"sac:SUNATNetTotalCashed"`
+	ExchangeRate          *ExchangeRate  `xml:"cac:ExchangeRate,omitempty"`
+}
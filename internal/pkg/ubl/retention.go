@@ -0,0 +1,46 @@
+package ubl
+
+// Retention represents a SUNAT retention receipt (comprobante de retención, tipo 20)
+type Retention struct {
+	UBLVersionID    string    `xml:"cbc:UBLVersionID"`
+	CustomizationID string    `xml:"cbc:CustomizationID"`
+	Signature       Signature `xml:"cac:Signature"`
+	ID              string    `xml:"cbc:ID"`
+	IssueDate       string    `xml:"cbc:IssueDate"`
+	IssueTime       string    `xml:"cbc:IssueTime,omitempty"`
+
+	AgentParty    Party `xml:"cac:AgentParty"`
+	ReceiverParty Party `xml:"cac:ReceiverParty"`
+
+	SUNATRetentionSystemCode string         `xml:"sac:SUNATRetentionSystemCode"`
+	SUNATRetentionPercent    float64        `xml:"sac:SUNATRetentionPercent"`
+	Note                     string         `xml:"cbc:Note,omitempty"`
+	TotalInvoiceAmount       MonetaryAmount `xml:"cbc:TotalInvoiceAmount"`
+	SUNATTotalPaid           MonetaryAmount `xml:"sac:SUNATTotalPaid"`
+
+	DocumentReferences []RetentionDocumentReference `xml:"sac:SUNATRetentionDocumentReference"`
+}
+
+// RetentionDocumentReference represents a document (invoice) affected by the retention
+type RetentionDocumentReference struct {
+	ID                   Identifier           `xml:"cbc:ID"`
+	IssueDate            string               `xml:"cbc:IssueDate"`
+	TotalInvoiceAmount   MonetaryAmount       `xml:"cbc:TotalInvoiceAmount"`
+	Payment              Payment              `xml:"cac:Payment"`
+	RetentionInformation RetentionInformation `xml:"sac:SUNATRetentionInformation"`
+}
+
+// RetentionInformation represents the retention amounts for a referenced document
+type RetentionInformation struct {
+	SUNATRetentionAmount MonetaryAmount `xml:"sac:SUNATRetentionAmount"`
+	SUNATRetentionDate   string         `xml:"sac:SUNATRetentionDate"`
+	SUNATNetTotalPaid    MonetaryAmount `xml:"sac:SUNATNetTotalPaid"`
+	ExchangeRate         *ExchangeRate  `xml:"cac:ExchangeRate,omitempty"`
+}
+
+// Payment represents a payment (or collection) of a referenced document
+type Payment struct {
+	ID         string         `xml:"cbc:ID"`
+	PaidAmount MonetaryAmount `xml:"cbc:PaidAmount"`
+	PaidDate   string         `xml:"cbc:PaidDate"`
+}